@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/secrets"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/conversion"
+)
+
+var (
+	convertOpts struct {
+		to string
+	}
+)
+
+func newConvertCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Converts input files in the assets directory to another API version",
+		Long:  "",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().StringVar(&convertOpts.to, "to", types.InstallConfigVersion, "API version to convert to")
+	cmd.AddCommand(newConvertInstallConfigCmd())
+	return cmd
+}
+
+func newConvertInstallConfigCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "install-config",
+		Short: "Rewrites the install-config.yaml in the assets directory in another API version",
+		Long: `Rewrites the install-config.yaml in the assets directory in the API version
+given with --to. Fields that were renamed between the versions are moved to
+their new names, and a warning is logged for each deprecated field.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+
+			return convertInstallConfig(filepath.Join(rootOpts.dir, installconfig.InstallConfigFilename), convertOpts.to)
+		},
+	}
+}
+
+// convertInstallConfig converts the install config at path to the given
+// version in place. Defaults are not applied, so the file keeps only the
+// fields the user set.
+func convertInstallConfig(path string, to string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Secret references are kept as they are rather than resolved, so
+	// that the converted file holds no secrets.
+	if data, err = yaml.YAMLToJSON(data); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", path)
+	}
+	data, secretFields, err := secrets.Resolve(context.TODO(), placeholderResolver{}, reflect.TypeOf(types.InstallConfig{}), data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", path)
+	}
+
+	config := &types.InstallConfig{}
+	if err := yaml.UnmarshalStrict(data, config, yaml.DisallowUnknownFields); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", path)
+	}
+
+	from := config.APIVersion
+	if err := conversion.Convert(config, to); err != nil {
+		return errors.Wrapf(err, "failed to convert %s", path)
+	}
+	if from == to {
+		fmt.Printf("%s is already at version %s\n", path, to)
+		return nil
+	}
+
+	data, err = json.Marshal(config)
+	if err == nil {
+		data, err = secrets.Restore(data, secretFields)
+	}
+	if err == nil {
+		data, err = yaml.JSONToYAML(data)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal install config")
+	}
+	if err := asset.WriteFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Printf("Converted %s from %s to %s\n", path, from, to)
+	return nil
+}
+
+// placeholderResolver resolves every secret reference to a placeholder.
+type placeholderResolver struct{}
+
+func (placeholderResolver) Resolve(context.Context, *secrets.Reference) (string, error) {
+	return "<secret reference>", nil
+}
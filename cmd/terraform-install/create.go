@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -13,15 +14,23 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"github.com/bailey84j/terraform_installer/pkg/airgap"
 	"github.com/bailey84j/terraform_installer/pkg/asset"
 	"github.com/bailey84j/terraform_installer/pkg/asset/cluster"
 	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
 	targetassets "github.com/bailey84j/terraform_installer/pkg/asset/targets"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tls"
 
 	assetstore "github.com/bailey84j/terraform_installer/pkg/asset/store"
 
 	"github.com/bailey84j/terraform_installer/pkg/asset/logging"
+	"github.com/bailey84j/terraform_installer/pkg/hooks"
+	"github.com/bailey84j/terraform_installer/pkg/lint"
 	timer "github.com/bailey84j/terraform_installer/pkg/metrics/timer"
+	"github.com/bailey84j/terraform_installer/pkg/terraform/providers"
+	"github.com/bailey84j/terraform_installer/pkg/tfeapi"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/overrides"
 )
 
 type target struct {
@@ -37,6 +46,20 @@ const (
 	exitCodeInstallFailed
 )
 
+var (
+	createOpts struct {
+		dryRun      bool
+		purgePolicy string
+		set         []string
+
+		generateSSHKey        bool
+		checkExternalServices bool
+		insecure              bool
+
+		bundleOutput string
+	}
+)
+
 // each target is a variable to preserve the order when creating subcommands and still
 // allow other functions to directly access each target individually.
 var (
@@ -59,6 +82,10 @@ var (
 			// FIXME: add longer descriptions for our commands with examples for better UX.
 			// Long:  "",
 			PostRun: func(_ *cobra.Command, _ []string) {
+				if createOpts.dryRun {
+					return
+				}
+
 				ctx := context.Background()
 				var err error
 
@@ -183,31 +210,123 @@ func newCreateCmd() *cobra.Command {
 			return cmd.Help()
 		},
 	}
+	cmd.PersistentFlags().BoolVar(&createOpts.dryRun, "dry-run", false, "print which assets would be reused or regenerated, and why, without generating anything")
+	cmd.PersistentFlags().StringVar(&createOpts.purgePolicy, "purge-policy", "", "what to do with consumed input files, one of Delete, Archive or Keep, overriding purgePolicy in the install config")
+	cmd.PersistentFlags().BoolVar(&createOpts.generateSSHKey, "generate-ssh-key", false, "generate a new ed25519 SSH key pair in the auth directory instead of asking for an existing public key")
+	cmd.PersistentFlags().BoolVar(&createOpts.checkExternalServices, "check-external-services", false, "check that the PostgreSQL server of the external services accepts connections before creating the cluster")
+	cmd.PersistentFlags().BoolVar(&createOpts.insecure, "insecure", false, "create the initial admin user of Terraform Enterprise even though its self-signed certificate cannot be verified; the certificate served first is pinned")
+	cmd.PersistentFlags().StringArrayVar(&createOpts.set, "set", nil, "override a field of the install config by its JSON path (e.g. \"platform.aws.region=us-west-2\"); may be repeated and takes precedence over "+overrides.EnvPrefix+"* environment variables")
 
 	for _, t := range targets {
 		t.command.Args = cobra.ExactArgs(0)
 		t.command.Run = runTargetCmd(t.assets...)
 		cmd.AddCommand(t.command)
 	}
+	cmd.AddCommand(newCreateMirrorBundleCmd())
 
 	return cmd
 }
 
-func asFileWriter(a asset.WritableAsset) asset.FileWriter {
-	switch v := a.(type) {
-	case asset.FileWriter:
-		return v
-	default:
-		return asset.NewDefaultFileWriter(a)
+// mirrorBundleFileName is the default name of the mirror bundle in the assets
+// directory.
+const mirrorBundleFileName = "mirror-bundle.tar.gz"
+
+func newCreateMirrorBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mirror-bundle",
+		Short: "Packages what an air-gapped install needs into a single archive",
+		Long: `Packages the terraform binary, the provider mirror and the airgap artifacts
+listed in the install config into a gzipped tar archive that can be carried
+into a disconnected environment. The archive lists the SHA-256 checksum of
+every file in ` + airgap.ChecksumsFile + `.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+
+			installconfig.Overrides = createOpts.set
+			installconfig.GenerateSSHKey = createOpts.generateSSHKey
+			output := createOpts.bundleOutput
+			if output == "" {
+				output = filepath.Join(rootOpts.dir, mirrorBundleFileName)
+			}
+			return createMirrorBundle(rootOpts.dir, output)
+		},
+	}
+	cmd.Flags().StringVar(&createOpts.bundleOutput, "output", "", "path of the archive (default \"<dir>/"+mirrorBundleFileName+"\")")
+	return cmd
+}
+
+// createMirrorBundle writes the mirror bundle for the install config in the
+// given directory to output.
+func createMirrorBundle(directory string, output string) error {
+	assetStore, err := assetstore.NewStore(directory)
+	if err != nil {
+		return errors.Wrap(err, "failed to create asset store")
+	}
+	loaded, err := assetStore.Load(&installconfig.InstallConfig{})
+	if err != nil {
+		return errors.Wrap(err, "failed to load the install config")
+	}
+	if loaded == nil {
+		return errors.Errorf("no %s in %s", installconfig.InstallConfigFilename, directory)
+	}
+	var artifacts []string
+	if a := loaded.(*installconfig.InstallConfig).Config.Airgap; a != nil {
+		artifacts = a.Artifacts
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(output), filepath.Base(output)+".*")
+	if err != nil {
+		return err
 	}
+	defer os.Remove(f.Name())
+	if err := airgap.WriteBundle(f, providers.Mirror(), artifacts); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write the mirror bundle")
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// TempFile creates the file as 0600, but the bundle is not secret and is
+	// usually copied on by another user.
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), output); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the mirror bundle to %s\n", output)
+	return nil
 }
 
 func runTargetCmd(targets ...asset.WritableAsset) func(cmd *cobra.Command, args []string) {
-	runner := func(directory string) error {
-		assetStore, err := assetstore.NewStore(directory)
+	runner := func(directory string) (err error) {
+		policy, err := purgePolicy()
+		if err != nil {
+			return err
+		}
+
+		hookConfig, err := hooks.Load(directory)
+		if err != nil {
+			return err
+		}
+		cluster.Hooks = hookConfig
+
+		assetStore, err := assetstore.NewStore(directory, assetstore.WithPurgePolicy(policy), assetstore.WithHooks(hookConfig))
 		if err != nil {
 			return errors.Wrap(err, "failed to create asset store")
 		}
+		// A lint error already fails the install config with the report.
+		defer func() {
+			if err == nil || !strings.Contains(err.Error(), asset.InstallConfigError) {
+				reportLintFindings(assetStore)
+			}
+		}()
+
+		if createOpts.dryRun {
+			return explainTargets(assetStore, targets)
+		}
 
 		for _, a := range targets {
 			err := assetStore.Fetch(a, targets...)
@@ -215,7 +334,7 @@ func runTargetCmd(targets ...asset.WritableAsset) func(cmd *cobra.Command, args
 				err = errors.Wrapf(err, "failed to fetch %s", a.Name())
 			}
 
-			err2 := asFileWriter(a).PersistToFile(directory)
+			err2 := assetStore.Persist(a)
 			if err2 != nil {
 				err2 = errors.Wrapf(err2, "failed to write asset (%s) to disk", a.Name())
 				if err != nil {
@@ -239,6 +358,9 @@ func runTargetCmd(targets ...asset.WritableAsset) func(cmd *cobra.Command, args
 		defer cleanup()
 
 		cluster.InstallDir = rootOpts.dir
+		installconfig.Overrides = createOpts.set
+		installconfig.GenerateSSHKey = createOpts.generateSSHKey
+		installconfig.CheckExternalServices = createOpts.checkExternalServices
 
 		err := runner(rootOpts.dir)
 		if err != nil {
@@ -252,6 +374,9 @@ func runTargetCmd(targets ...asset.WritableAsset) func(cmd *cobra.Command, args
 			}
 			logrus.Fatal(err)
 		}
+		if createOpts.dryRun {
+			return
+		}
 		switch cmd.Name() {
 		case "cluster", "image":
 		default:
@@ -261,6 +386,60 @@ func runTargetCmd(targets ...asset.WritableAsset) func(cmd *cobra.Command, args
 	}
 }
 
+// purgePolicy returns the purge policy from the --purge-policy flag. When the
+// flag is not set, it is empty and the asset store uses the purge policy of the
+// install config.
+func purgePolicy() (types.PurgePolicy, error) {
+	if createOpts.purgePolicy == "" {
+		return "", nil
+	}
+	for _, p := range []types.PurgePolicy{types.DeletePurgePolicy, types.ArchivePurgePolicy, types.KeepPurgePolicy} {
+		if strings.EqualFold(createOpts.purgePolicy, string(p)) {
+			return p, nil
+		}
+	}
+	return "", errors.Errorf("invalid purge policy %q, must be one of Delete, Archive or Keep", createOpts.purgePolicy)
+}
+
+// explainTargets prints, for every asset needed by the targets, whether it
+// would be reused or regenerated and why. Assets shared between targets are
+// only printed once.
+// reportLintFindings prints the lint findings about the install config that
+// were found in this run.
+func reportLintFindings(assetStore asset.Store) {
+	var findings lint.Findings
+	if loaded, err := assetStore.Load(&installconfig.InstallConfig{}); err == nil && loaded != nil {
+		findings = append(findings, loaded.(*installconfig.InstallConfig).Findings()...)
+	}
+	if loaded, err := assetStore.Load(&installconfig.PlatformProvisionCheck{}); err == nil && loaded != nil {
+		findings = append(findings, loaded.(*installconfig.PlatformProvisionCheck).Findings()...)
+	}
+	if report := findings.Report(); report != "" {
+		fmt.Fprint(os.Stderr, report)
+	}
+}
+
+func explainTargets(assetStore asset.Store, targets []asset.WritableAsset) error {
+	seen := map[string]bool{}
+	for _, a := range targets {
+		explanations, err := assetStore.Explain(a)
+		if err != nil {
+			return errors.Wrapf(err, "failed to explain %s", a.Name())
+		}
+		for _, e := range explanations {
+			if seen[e.Asset] {
+				continue
+			}
+			seen[e.Asset] = true
+			fmt.Println(e.String())
+			if e.Provenance != nil {
+				fmt.Printf("    %s\n", e.Provenance)
+			}
+		}
+	}
+	return nil
+}
+
 func waitForBootstrapComplete(ctx context.Context) *clusterCreateError {
 	var err error
 	//client, err := kubernetes.NewForConfig(config)
@@ -460,19 +639,110 @@ func logComplete(directory, consoleURL string) error {
 	}
 	kubeconfig := filepath.Join(absDir, "auth", "kubeconfig")
 	pwFile := filepath.Join(absDir, "auth", "tfe-password")
+	// The password file is missing when the install config provides the
+	// password.
 	pw, err := ioutil.ReadFile(pwFile)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 	logrus.Info("Install complete!")
 	logrus.Infof("To access the cluster as the system:admin user when using 'oc', run 'export KUBECONFIG=%s'", kubeconfig)
 	if consoleURL != "" {
 		logrus.Infof("Access the OpenShift web-console here: %s", consoleURL)
-		logrus.Infof("Login to the console with user: %q, and password: %q", "tfe", pw)
+		if len(pw) > 0 {
+			logrus.Infof("Login to the console with user: %q, and password: %q", "tfe", pw)
+		} else {
+			logrus.Infof("Login to the console with user: %q, and the password set in the install config", "tfe")
+		}
+	}
+	sshKey := filepath.Join(absDir, installconfig.SSHPrivateKeyPath)
+	if _, err := os.Stat(sshKey); err == nil {
+		logrus.Infof("To access the instances over SSH, use the private key %s", sshKey)
+	}
+	apiToken := filepath.Join(absDir, tfeapi.TokenPath)
+	if _, err := os.Stat(apiToken); err == nil {
+		logrus.Infof("The API token of the initial admin user is in %s", apiToken)
 	}
 	return nil
 }
 
+// bootstrapTFE waits for Terraform Enterprise to become healthy, then creates
+// the initial admin user and the organizations of the install config. Nothing
+// is done unless the install config sets tfe.adminEmail.
+func bootstrapTFE(ctx context.Context, directory string) error {
+	assetStore, err := assetstore.NewStore(directory)
+	if err != nil {
+		return errors.Wrap(err, "failed to create asset store")
+	}
+	installConfig, err := assetStore.Load(&installconfig.InstallConfig{})
+	if err != nil {
+		return errors.Wrap(err, "failed to load the install config")
+	}
+	if installConfig == nil {
+		return nil
+	}
+	if err := installConfig.(*installconfig.InstallConfig).ResolveSecrets(); err != nil {
+		return err
+	}
+	config := installConfig.(*installconfig.InstallConfig).Config.TFE
+	if config == nil || config.AdminEmail == "" {
+		logrus.Debug("tfe.adminEmail is not set, not creating the initial admin user")
+		return nil
+	}
+
+	// The password file only exists when the password was generated.
+	password := []byte(config.AdminPassword)
+	if len(password) == 0 {
+		password, err = ioutil.ReadFile(filepath.Join(directory, "auth", "tfe-password"))
+		if err != nil {
+			return errors.Wrap(err, "failed to read the admin password")
+		}
+	}
+
+	var caBundle []byte
+	if certKey, err := assetStore.Load(&tls.TFECertKey{}); err != nil {
+		return errors.Wrap(err, "failed to load the TFE certificate")
+	} else if certKey != nil {
+		caBundle = certKey.(*tls.TFECertKey).CABundle
+	}
+	// The self-signed certificate is generated by Terraform Enterprise, so no
+	// CA can verify it. The certificate served first is pinned instead.
+	var pin *tfeapi.CertificatePin
+	if config.TLSSource == types.SelfSignedTLSSource {
+		pin = &tfeapi.CertificatePin{}
+	}
+	httpClient, err := tfeapi.NewHTTPClient(caBundle, pin)
+	if err != nil {
+		return err
+	}
+	client := &tfeapi.Client{Address: "https://" + config.Hostname, Client: httpClient}
+
+	timeout := 30 * time.Minute
+	untilTime := time.Now().Add(timeout)
+	logrus.Infof("Waiting up to %v (until %v) for Terraform Enterprise at %s to become healthy...",
+		timeout, untilTime.Format(time.Kitchen), client.Address)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := tfeapi.WaitUntilHealthy(waitCtx, client, 10*time.Second); err != nil {
+		return errors.Wrap(err, "Terraform Enterprise did not become healthy")
+	}
+	if pin != nil {
+		logrus.Infof("Terraform Enterprise serves a self-signed certificate with the SHA-256 fingerprint %s", pin.Fingerprint())
+		if !createOpts.insecure {
+			logrus.Warn("Not sending the admin password over a certificate that cannot be verified; " +
+				"check the fingerprint and create the initial admin user by hand, or pass --insecure to have it created")
+			return nil
+		}
+	}
+
+	user := &tfeapi.AdminUser{
+		Username: config.AdminUsername,
+		Email:    config.AdminEmail,
+		Password: string(password),
+	}
+	return tfeapi.Bootstrap(ctx, client, directory, user, config.Organizations)
+}
+
 func waitForInstallComplete(ctx context.Context, directory string) error {
 	if err := waitForInitializedCluster(ctx); err != nil {
 		return err
@@ -487,6 +757,9 @@ func waitForInstallComplete(ctx context.Context, directory string) error {
 			logrus.Warnf("Cluster does not have a console available: %v", err)
 		}
 	*/
+	if err := bootstrapTFE(ctx, directory); err != nil {
+		return errors.Wrap(err, "failed to bootstrap Terraform Enterprise")
+	}
 	return logComplete(rootOpts.dir, "consoleURL")
 }
 
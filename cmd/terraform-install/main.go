@@ -43,6 +43,9 @@ func installerMain() {
 
 	for _, subCmd := range []*cobra.Command{
 		newCreateCmd(),
+		newStatusCmd(),
+		newConvertCmd(),
+		newRenderCmd(),
 		//newDestroyCmd(),
 	} {
 		rootCmd.AddCommand(subCmd)
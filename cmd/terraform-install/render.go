@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	assetstore "github.com/bailey84j/terraform_installer/pkg/asset/store"
+	"github.com/bailey84j/terraform_installer/pkg/types/overrides"
+)
+
+var (
+	renderOpts struct {
+		set         []string
+		showSecrets bool
+	}
+)
+
+func newRenderCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Prints input files in the assets directory as the installer will consume them",
+		Long:  "",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().StringArrayVar(&renderOpts.set, "set", nil, "override a field of the install config by its JSON path (e.g. \"platform.aws.region=us-west-2\"); may be repeated and takes precedence over "+overrides.EnvPrefix+"* environment variables")
+	cmd.AddCommand(newRenderInstallConfigCmd())
+	return cmd
+}
+
+func newRenderInstallConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install-config",
+		Short: "Prints the install-config.yaml merged with its overlays and overrides",
+		Long: `Prints the install-config.yaml in the assets directory after the overlays in
+` + installconfig.InstallConfigOverlayPattern + ` are merged over it, in lexical order, and the
+` + overrides.EnvPrefix + `* environment variables and --set flags are applied. The result
+is printed before defaults are set and before it is validated, with the
+sensitive fields redacted unless --show-secrets is given.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+
+			installconfig.Overrides = renderOpts.set
+			data, err := installconfig.Render(assetstore.NewFileFetcher(rootOpts.dir), renderOpts.showSecrets)
+			if err != nil {
+				if os.IsNotExist(errors.Cause(err)) {
+					return errors.Errorf("no %s in %s", installconfig.InstallConfigFilename, rootOpts.dir)
+				}
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&renderOpts.showSecrets, "show-secrets", false, "print the sensitive fields instead of redacting them")
+	return cmd
+}
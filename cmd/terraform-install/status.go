@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	targetassets "github.com/bailey84j/terraform_installer/pkg/asset/targets"
+
+	assetstore "github.com/bailey84j/terraform_installer/pkg/asset/store"
+)
+
+func newStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Shows the state of the assets in the assets directory",
+		Long: `Shows, for every asset needed to create a cluster, whether it would be
+reused or regenerated, the expiry and capacity of the licence, and lists the
+consumed input files that were archived by the Archive purge policy.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+
+			assetStore, err := assetstore.NewStore(rootOpts.dir)
+			if err != nil {
+				return errors.Wrap(err, "failed to create asset store")
+			}
+			if err := explainTargets(assetStore, targetassets.Cluster); err != nil {
+				return err
+			}
+			if err := printLicence(assetStore); err != nil {
+				return err
+			}
+			return printConsumedArchives(rootOpts.dir)
+		},
+	}
+}
+
+// printLicence prints the expiry and capacity of the licence, if it was read
+// already.
+func printLicence(assetStore asset.Store) error {
+	loaded, err := assetStore.Load(&installconfig.Licence{})
+	if err != nil {
+		return errors.Wrap(err, "failed to load the licence")
+	}
+	if loaded == nil || loaded.(*installconfig.Licence).Info == nil {
+		return nil
+	}
+	info := loaded.(*installconfig.Licence).Info
+
+	fmt.Println()
+	fmt.Printf("Licence %s for %s:\n", info.ID, info.Customer)
+	fmt.Printf("  expires: %s\n", info.ExpiresAt.Format("2006-01-02"))
+	fmt.Printf("  capacity: %s\n", info.CapacityString())
+	now := time.Now()
+	switch {
+	case info.Expired(now):
+		fmt.Println("  the licence has expired")
+	case info.ExpiresSoon(now):
+		fmt.Printf("  the licence expires in %d days\n", int(info.ExpiresAt.Sub(now).Hours()/24))
+	}
+	return nil
+}
+
+// printConsumedArchives lists the files in each archive of consumed assets.
+func printConsumedArchives(directory string) error {
+	archives, err := assetstore.ConsumedArchives(directory)
+	if err != nil {
+		return errors.Wrap(err, "failed to list archived files")
+	}
+	if len(archives) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("Consumed files archived in the assets directory:")
+	for _, archive := range archives {
+		err := filepath.Walk(archive, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			fmt.Printf("  %s\n", path)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list %s", archive)
+		}
+	}
+	return nil
+}
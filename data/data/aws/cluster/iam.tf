@@ -0,0 +1,163 @@
+locals {
+  mint_credentials        = var.aws_credentials_mode == "Mint"
+  passthrough_credentials = var.aws_credentials_mode == "Passthrough"
+  manual_credentials      = var.aws_credentials_mode == "Manual"
+
+  // The roles of the TFE components: created when minting, provided when
+  // Manual. When passing the installer's credentials through, the object
+  // storage role is still created, but may only fetch the bootstrap objects.
+  object_storage_role_arn = local.manual_credentials ? var.aws_object_storage_role_arn : join("", aws_iam_role.tfe_object_storage[*].arn)
+  agents_role_arn         = local.mint_credentials ? join("", aws_iam_role.tfe_agents[*].arn) : var.aws_agents_role_arn
+
+  // The roles the instance profiles of the machines are made of: those of
+  // the machine pools if set, else those of the TFE components. A role name
+  // is the last element of the path of its ARN.
+  master_role_name = var.aws_master_iam_role_name != "" ? var.aws_master_iam_role_name : element(split("/", local.object_storage_role_arn), length(split("/", local.object_storage_role_arn)) - 1)
+  worker_role_name = var.aws_worker_iam_role_name != "" ? var.aws_worker_iam_role_name : local.agents_role_arn == "" ? "" : element(split("/", local.agents_role_arn), length(split("/", local.agents_role_arn)) - 1)
+
+  // The existing bucket of the external services, or the buckets of the
+  // cluster.
+  object_storage_buckets = var.aws_object_storage_bucket != "" ? var.aws_object_storage_bucket : "${var.cluster_id}-*"
+
+  // The airgap package and bootstrapper that the instances fetch from S3,
+  // as object ARNs. Those served over https:// need no permissions.
+  airgap_objects = [
+    for url in [var.airgap_package_url, var.airgap_bootstrapper_url] :
+    "arn:aws:s3:::${trimprefix(url, "s3://")}" if length(regexall("^s3://", url)) > 0
+  ]
+}
+
+data "aws_iam_policy_document" "ec2_assume_role" {
+  statement {
+    actions = ["sts:AssumeRole"]
+
+    principals {
+      type        = "Service"
+      identifiers = ["ec2.amazonaws.com"]
+    }
+  }
+}
+
+resource "aws_iam_role" "tfe_object_storage" {
+  count = local.manual_credentials ? 0 : 1
+
+  name               = "${var.cluster_id}-tfe-object-storage"
+  assume_role_policy = data.aws_iam_policy_document.ec2_assume_role.json
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-tfe-object-storage"
+    },
+    local.tags,
+  )
+}
+
+// The object storage role may only reach the object storage buckets, and the
+// KMS key that encrypts them, if any. When passing the installer's
+// credentials through, TFE reaches them with those credentials instead.
+data "aws_iam_policy_document" "tfe_object_storage" {
+  dynamic "statement" {
+    for_each = local.mint_credentials ? [1] : []
+
+    content {
+      actions = [
+        "s3:ListBucket",
+        "s3:GetBucketLocation",
+      ]
+      resources = ["arn:aws:s3:::${local.object_storage_buckets}"]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = local.mint_credentials ? [1] : []
+
+    content {
+      actions = [
+        "s3:GetObject",
+        "s3:PutObject",
+        "s3:DeleteObject",
+      ]
+      resources = ["arn:aws:s3:::${local.object_storage_buckets}/*"]
+    }
+  }
+
+  // The instances fetch the licence, the TLS key and the secret settings
+  // from the bootstrap bucket when they boot.
+  statement {
+    actions   = ["s3:GetObject"]
+    resources = ["${aws_s3_bucket.bootstrap.arn}/*"]
+  }
+
+  dynamic "statement" {
+    for_each = length(local.airgap_objects) > 0 ? [local.airgap_objects] : []
+
+    content {
+      actions   = ["s3:GetObject"]
+      resources = statement.value
+    }
+  }
+
+  dynamic "statement" {
+    for_each = local.mint_credentials && var.aws_object_storage_kms_key_arn != "" ? [var.aws_object_storage_kms_key_arn] : []
+
+    content {
+      actions = [
+        "kms:Decrypt",
+        "kms:Encrypt",
+        "kms:GenerateDataKey",
+        "kms:DescribeKey",
+      ]
+      resources = [statement.value]
+    }
+  }
+}
+
+resource "aws_iam_role_policy" "tfe_object_storage" {
+  count = local.manual_credentials ? 0 : 1
+
+  name   = "${var.cluster_id}-tfe-object-storage"
+  role   = aws_iam_role.tfe_object_storage[0].id
+  policy = data.aws_iam_policy_document.tfe_object_storage.json
+}
+
+// Agents get no permissions of their own: runs are given credentials through
+// their workspace variables.
+resource "aws_iam_role" "tfe_agents" {
+  count = local.mint_credentials ? 1 : 0
+
+  name               = "${var.cluster_id}-tfe-agents"
+  assume_role_policy = data.aws_iam_policy_document.ec2_assume_role.json
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-tfe-agents"
+    },
+    local.tags,
+  )
+}
+
+resource "aws_iam_instance_profile" "master" {
+  name = "${var.cluster_id}-master"
+  role = local.master_role_name
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-master"
+    },
+    local.tags,
+  )
+}
+
+resource "aws_iam_instance_profile" "worker" {
+  count = var.aws_worker_count > 0 && local.worker_role_name != "" ? 1 : 0
+
+  name = "${var.cluster_id}-worker"
+  role = local.worker_role_name
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-worker"
+    },
+    local.tags,
+  )
+}
@@ -0,0 +1,334 @@
+locals {
+  // The AMI is used as is in its own region, and through an encrypted copy
+  // anywhere else.
+  ami_id = var.aws_region == var.aws_ami_region ? var.aws_ami : join("", aws_ami_copy.tfe[*].id)
+
+  // Instances go to the private subnets of an existing VPC, or to the
+  // default subnets of the default VPC.
+  vpc_id           = var.aws_vpc != null ? var.aws_vpc : join("", data.aws_vpc.default[*].id)
+  instance_subnets = var.aws_private_subnets != null ? var.aws_private_subnets : flatten(data.aws_subnets.default[*].ids)
+
+  master_subnets = [for s in data.aws_subnet.instance : s.id if contains(var.aws_master_availability_zones, s.availability_zone)]
+  worker_subnets = [for s in data.aws_subnet.instance : s.id if contains(var.aws_worker_availability_zones, s.availability_zone)]
+
+  // IOPS may only be set for the volume types with provisioned IOPS.
+  iops_volume_types = ["io1", "io2", "gp3"]
+}
+
+data "aws_vpc" "default" {
+  count = var.aws_vpc == null ? 1 : 0
+
+  default = true
+}
+
+data "aws_subnets" "default" {
+  count = var.aws_private_subnets == null ? 1 : 0
+
+  filter {
+    name   = "vpc-id"
+    values = [local.vpc_id]
+  }
+
+  filter {
+    name   = "default-for-az"
+    values = ["true"]
+  }
+}
+
+data "aws_subnet" "instance" {
+  for_each = toset(local.instance_subnets)
+
+  id = each.value
+}
+
+resource "aws_ami_copy" "tfe" {
+  count = var.aws_region == var.aws_ami_region ? 0 : 1
+
+  name              = "${var.cluster_id}-tfe"
+  source_ami_id     = var.aws_ami
+  source_ami_region = var.aws_ami_region
+  encrypted         = true
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-tfe"
+    },
+    local.tags,
+  )
+}
+
+data "aws_ami" "tfe" {
+  filter {
+    name   = "image-id"
+    values = [local.ami_id]
+  }
+}
+
+resource "aws_security_group" "tfe" {
+  name        = "${var.cluster_id}-tfe"
+  description = local.description
+  vpc_id      = local.vpc_id
+
+  // The network load balancer keeps the addresses of the clients, so the
+  // instances must accept them directly.
+  ingress {
+    description = "Terraform Enterprise"
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = local.internal ? var.machine_v4_cidrs : ["0.0.0.0/0"]
+  }
+
+  egress {
+    from_port   = 0
+    to_port     = 0
+    protocol    = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-tfe"
+    },
+    local.tags,
+  )
+}
+
+resource "aws_launch_template" "master" {
+  name                   = "${var.cluster_id}-master"
+  description            = local.description
+  image_id               = local.ami_id
+  instance_type          = var.aws_master_instance_type
+  vpc_security_group_ids = [aws_security_group.tfe.id]
+  user_data              = base64encode(var.aws_user_data)
+
+  iam_instance_profile {
+    name = aws_iam_instance_profile.master.name
+  }
+
+  block_device_mappings {
+    device_name = data.aws_ami.tfe.root_device_name
+
+    ebs {
+      volume_type           = var.aws_master_root_volume_type
+      volume_size           = var.aws_master_root_volume_size
+      iops                  = contains(local.iops_volume_types, var.aws_master_root_volume_type) && var.aws_master_root_volume_iops != 0 ? var.aws_master_root_volume_iops : null
+      encrypted             = var.aws_master_root_volume_encrypted
+      kms_key_id            = var.aws_master_root_volume_kms_key_id != "" ? var.aws_master_root_volume_kms_key_id : null
+      delete_on_termination = true
+    }
+  }
+
+  // The data volume outlives the instance, so that the data of Terraform
+  // Enterprise can be recovered when the autoscaling group replaces it.
+  dynamic "block_device_mappings" {
+    for_each = var.aws_master_data_volume_device != "" ? [var.aws_master_data_volume_device] : []
+
+    content {
+      device_name = block_device_mappings.value
+
+      ebs {
+        volume_type           = var.aws_master_root_volume_type
+        volume_size           = var.aws_master_data_volume_size
+        iops                  = contains(local.iops_volume_types, var.aws_master_root_volume_type) && var.aws_master_root_volume_iops != 0 ? var.aws_master_root_volume_iops : null
+        encrypted             = var.aws_master_root_volume_encrypted
+        kms_key_id            = var.aws_master_root_volume_kms_key_id != "" ? var.aws_master_root_volume_kms_key_id : null
+        delete_on_termination = false
+      }
+    }
+  }
+
+  metadata_options {
+    http_endpoint = "enabled"
+    http_tokens   = lower(var.aws_master_instance_metadata_authentication)
+  }
+
+  tag_specifications {
+    resource_type = "instance"
+    tags = merge(
+      {
+        "Name" = "${var.cluster_id}-master"
+      },
+      local.tags,
+    )
+  }
+
+  tag_specifications {
+    resource_type = "volume"
+    tags = merge(
+      {
+        "Name" = "${var.cluster_id}-master"
+      },
+      local.tags,
+    )
+  }
+
+  tags = local.tags
+}
+
+// The control plane runs Terraform Enterprise, with one instance in each of
+// the zones it was spread across.
+resource "aws_autoscaling_group" "master" {
+  name                = "${var.cluster_id}-master"
+  min_size            = length(var.aws_master_availability_zones)
+  max_size            = length(var.aws_master_availability_zones)
+  desired_capacity    = length(var.aws_master_availability_zones)
+  vpc_zone_identifier = local.master_subnets
+  target_group_arns   = aws_lb_target_group.tfe[*].arn
+
+  launch_template {
+    id      = aws_launch_template.master.id
+    version = aws_launch_template.master.latest_version
+  }
+
+  dynamic "tag" {
+    for_each = merge({ "Name" = "${var.cluster_id}-master" }, local.tags)
+
+    content {
+      key                 = tag.key
+      value               = tag.value
+      propagate_at_launch = false
+    }
+  }
+}
+
+resource "aws_lb_target_group" "tfe" {
+  count = length(aws_lb.tfe)
+
+  name     = "${var.cluster_id}-tfe"
+  port     = 443
+  protocol = "TCP"
+  vpc_id   = local.vpc_id
+
+  health_check {
+    protocol = "HTTPS"
+    path     = "/_health_check"
+  }
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-tfe"
+    },
+    local.tags,
+  )
+}
+
+resource "aws_lb_listener" "tfe" {
+  count = length(aws_lb.tfe)
+
+  load_balancer_arn = aws_lb.tfe[0].arn
+  port              = 443
+  protocol          = "TCP"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = aws_lb_target_group.tfe[0].arn
+  }
+}
+
+resource "aws_security_group" "worker" {
+  count = var.aws_worker_count > 0 ? 1 : 0
+
+  name        = "${var.cluster_id}-worker"
+  description = local.description
+  vpc_id      = local.vpc_id
+
+  egress {
+    from_port   = 0
+    to_port     = 0
+    protocol    = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-worker"
+    },
+    local.tags,
+  )
+}
+
+// The compute machines host TFE agents. They only reach out to Terraform
+// Enterprise, so nothing may reach them.
+resource "aws_launch_template" "worker" {
+  count = var.aws_worker_count > 0 ? 1 : 0
+
+  name                   = "${var.cluster_id}-worker"
+  description            = local.description
+  image_id               = local.ami_id
+  instance_type          = var.aws_worker_instance_type
+  vpc_security_group_ids = [aws_security_group.worker[0].id]
+
+  dynamic "iam_instance_profile" {
+    for_each = aws_iam_instance_profile.worker[*].name
+
+    content {
+      name = iam_instance_profile.value
+    }
+  }
+
+  block_device_mappings {
+    device_name = data.aws_ami.tfe.root_device_name
+
+    ebs {
+      volume_type           = var.aws_worker_root_volume_type
+      volume_size           = var.aws_worker_root_volume_size
+      iops                  = contains(local.iops_volume_types, var.aws_worker_root_volume_type) && var.aws_worker_root_volume_iops != 0 ? var.aws_worker_root_volume_iops : null
+      encrypted             = true
+      kms_key_id            = var.aws_worker_root_volume_kms_key_id != "" ? var.aws_worker_root_volume_kms_key_id : null
+      delete_on_termination = true
+    }
+  }
+
+  metadata_options {
+    http_endpoint = "enabled"
+    http_tokens   = lower(var.aws_worker_instance_metadata_authentication)
+  }
+
+  tag_specifications {
+    resource_type = "instance"
+    tags = merge(
+      {
+        "Name" = "${var.cluster_id}-worker"
+      },
+      local.tags,
+    )
+  }
+
+  tag_specifications {
+    resource_type = "volume"
+    tags = merge(
+      {
+        "Name" = "${var.cluster_id}-worker"
+      },
+      local.tags,
+    )
+  }
+
+  tags = local.tags
+}
+
+resource "aws_autoscaling_group" "worker" {
+  count = var.aws_worker_count > 0 ? 1 : 0
+
+  name                = "${var.cluster_id}-worker"
+  min_size            = var.aws_worker_count
+  max_size            = var.aws_worker_count
+  desired_capacity    = var.aws_worker_count
+  vpc_zone_identifier = local.worker_subnets
+
+  launch_template {
+    id      = aws_launch_template.worker[0].id
+    version = aws_launch_template.worker[0].latest_version
+  }
+
+  dynamic "tag" {
+    for_each = merge({ "Name" = "${var.cluster_id}-worker" }, local.tags)
+
+    content {
+      key                 = tag.key
+      value               = tag.value
+      propagate_at_launch = false
+    }
+  }
+}
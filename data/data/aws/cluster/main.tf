@@ -6,4 +6,146 @@ locals {
     var.aws_extra_tags,
   )
   description = "Created By OpenShift Installer"
-}
\ No newline at end of file
+
+  internal = var.aws_publish_strategy == "Internal"
+
+  // Internal installs keep the load balancer and DNS off the Internet: the
+  // load balancer uses the private subnets and the records go to a private
+  // zone, either the one provided or one created in the VPC. Without an
+  // existing VPC, it uses the default subnets of the instances.
+  lb_subnets = local.internal || var.aws_public_subnets == null ? local.instance_subnets : var.aws_public_subnets
+  zone_id    = local.internal ? coalesce(var.aws_internal_zone, join("", aws_route53_zone.private[*].zone_id)) : null
+}
+
+provider "aws" {
+  region = var.aws_region
+}
+
+resource "aws_route53_zone" "private" {
+  count = local.internal && var.aws_internal_zone == null ? 1 : 0
+
+  name          = var.cluster_domain
+  force_destroy = true
+
+  vpc {
+    vpc_id = local.vpc_id
+  }
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-int"
+    },
+    local.tags,
+  )
+}
+
+// The bootstrap bucket holds the licence, the TLS key and the secret
+// settings, which are too large or too sensitive for the user data. The instances fetch them when
+// they boot.
+resource "aws_s3_bucket" "bootstrap" {
+  bucket        = "${var.cluster_id}-bootstrap"
+  force_destroy = true
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-bootstrap"
+    },
+    local.tags,
+  )
+}
+
+resource "aws_s3_bucket_public_access_block" "bootstrap" {
+  bucket = aws_s3_bucket.bootstrap.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_object" "licence" {
+  bucket                 = aws_s3_bucket.bootstrap.id
+  key                    = "tfe-licence.rli"
+  content                = var.tfe_licence
+  server_side_encryption = "AES256"
+}
+
+resource "aws_s3_object" "tls_key" {
+  count = var.tfe_tls_key != "" ? 1 : 0
+
+  bucket                 = aws_s3_bucket.bootstrap.id
+  key                    = "tfe-tls-key.pem"
+  content                = var.tfe_tls_key
+  server_side_encryption = "AES256"
+}
+
+// The settings merged into the application settings on the instances. When
+// passing the installer's credentials through, they also hold those, which
+// TFE then uses for the object storage instead of the instance profile.
+resource "aws_s3_object" "secret_settings" {
+  bucket = aws_s3_bucket.bootstrap.id
+  key    = "tfe-secret-settings.json"
+  content = jsonencode(merge(
+    jsondecode(var.tfe_secret_settings),
+    jsondecode(local.passthrough_credentials ? jsonencode({
+      aws_instance_profile  = { value = "0" }
+      aws_access_key_id     = { value = var.aws_passthrough_access_key_id }
+      aws_secret_access_key = { value = var.aws_passthrough_secret_access_key }
+    }) : "{}"),
+  ))
+  server_side_encryption = "AES256"
+}
+
+resource "aws_lb" "tfe" {
+  count = length(coalesce(local.lb_subnets, [])) > 0 ? 1 : 0
+
+  name                             = "${var.cluster_id}-tfe"
+  load_balancer_type               = "network"
+  internal                         = local.internal
+  subnets                          = local.lb_subnets
+  enable_cross_zone_load_balancing = true
+
+  tags = merge(
+    {
+      "Name" = "${var.cluster_id}-tfe"
+    },
+    local.tags,
+  )
+}
+
+resource "aws_route53_record" "tfe_internal" {
+  count = local.internal && length(aws_lb.tfe) > 0 ? 1 : 0
+
+  zone_id = local.zone_id
+  name    = var.tfe_hostname
+  type    = "A"
+
+  alias {
+    name                   = aws_lb.tfe[0].dns_name
+    zone_id                = aws_lb.tfe[0].zone_id
+    evaluate_target_health = false
+  }
+}
+
+// External installs publish the hostname in the public zone of the base
+// domain, so that the installer and the users can reach TFE from the Internet.
+data "aws_route53_zone" "public" {
+  count = !local.internal && length(aws_lb.tfe) > 0 ? 1 : 0
+
+  name         = var.base_domain
+  private_zone = false
+}
+
+resource "aws_route53_record" "tfe_public" {
+  count = length(data.aws_route53_zone.public)
+
+  zone_id = data.aws_route53_zone.public[0].zone_id
+  name    = var.tfe_hostname
+  type    = "A"
+
+  alias {
+    name                   = aws_lb.tfe[0].dns_name
+    zone_id                = aws_lb.tfe[0].zone_id
+    evaluate_target_health = false
+  }
+}
@@ -0,0 +1,15 @@
+output "tfe_lb_dns_name" {
+  value = join("", aws_lb.tfe[*].dns_name)
+}
+
+output "tfe_internal_zone_id" {
+  value = local.zone_id
+}
+
+output "tfe_object_storage_role_arn" {
+  value = local.object_storage_role_arn
+}
+
+output "tfe_agents_role_arn" {
+  value = local.agents_role_arn
+}
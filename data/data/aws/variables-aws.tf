@@ -0,0 +1,218 @@
+variable "aws_ami" {
+  type        = string
+  description = "AMI for all nodes. An encrypted copy of this AMI will be used."
+}
+
+variable "aws_ami_region" {
+  type        = string
+  description = "Region for the AMI for all nodes. An encrypted copy of this AMI will be used."
+}
+
+variable "custom_endpoints" {
+  type        = map(string)
+  default     = {}
+  description = "Custom AWS endpoints to override existing services, keyed by service name."
+}
+
+variable "aws_extra_tags" {
+  type        = map(string)
+  default     = {}
+  description = "Extra AWS tags to be applied to created resources."
+}
+
+variable "aws_master_instance_type" {
+  type        = string
+  description = "Instance type for the control plane nodes."
+}
+
+variable "aws_worker_instance_type" {
+  type        = string
+  default     = ""
+  description = "Instance type for the compute nodes."
+}
+
+variable "aws_master_availability_zones" {
+  type        = list(string)
+  description = "The availability zone of each control plane node."
+}
+
+variable "aws_worker_availability_zones" {
+  type        = list(string)
+  description = "The availability zones in which to create the compute nodes."
+}
+
+variable "aws_worker_count" {
+  type        = number
+  default     = 0
+  description = "The number of compute nodes."
+}
+
+variable "aws_master_root_volume_type" {
+  type        = string
+  description = "The type of volume for the root block device of the control plane nodes."
+}
+
+variable "aws_master_root_volume_size" {
+  type        = number
+  description = "The size of the volume in gigabytes for the root block device of the control plane nodes."
+}
+
+variable "aws_master_root_volume_iops" {
+  type        = number
+  description = "The amount of provisioned IOPS for the root block device of the control plane nodes. Ignored if the volume type is not io1, io2 or gp3."
+}
+
+variable "aws_master_root_volume_encrypted" {
+  type        = bool
+  description = "Whether the root block device of the control plane nodes is encrypted."
+}
+
+variable "aws_master_root_volume_kms_key_id" {
+  type        = string
+  default     = ""
+  description = "The KMS key id used to encrypt the root block device of the control plane nodes. The default KMS key of the account is used if unset."
+}
+
+variable "aws_master_data_volume_device" {
+  type        = string
+  default     = ""
+  description = "(optional) The device name of the data volume of the control plane nodes, which they only get in the MountedDisk operational mode."
+}
+
+variable "aws_master_data_volume_size" {
+  type        = number
+  default     = 200
+  description = "The size of the data volume in gigabytes of the control plane nodes. It has the type, IOPS and encryption of their root volume."
+}
+
+variable "aws_master_instance_metadata_authentication" {
+  type        = string
+  default     = "optional"
+  description = "Whether the control plane nodes require IMDSv2 to talk to the metadata service."
+}
+
+variable "aws_worker_root_volume_type" {
+  type        = string
+  default     = "gp3"
+  description = "The type of volume for the root block device of the compute nodes."
+}
+
+variable "aws_worker_root_volume_size" {
+  type        = number
+  default     = 120
+  description = "The size of the volume in gigabytes for the root block device of the compute nodes."
+}
+
+variable "aws_worker_root_volume_iops" {
+  type        = number
+  default     = 0
+  description = "The amount of provisioned IOPS for the root block device of the compute nodes. Ignored if the volume type is not io1, io2 or gp3."
+}
+
+variable "aws_worker_root_volume_kms_key_id" {
+  type        = string
+  default     = ""
+  description = "The KMS key id used to encrypt the root block device of the compute nodes. The default KMS key of the account is used if unset."
+}
+
+variable "aws_worker_instance_metadata_authentication" {
+  type        = string
+  default     = "optional"
+  description = "Whether the compute nodes require IMDSv2 to talk to the metadata service."
+}
+
+variable "aws_region" {
+  type        = string
+  description = "The target AWS region for the cluster."
+}
+
+variable "aws_vpc" {
+  type        = string
+  default     = null
+  description = "(optional) An existing network (VPC ID) into which the cluster should be installed."
+}
+
+variable "aws_public_subnets" {
+  type        = list(string)
+  default     = null
+  description = "(optional) Existing public subnets into which the cluster should be installed."
+}
+
+variable "aws_private_subnets" {
+  type        = list(string)
+  default     = null
+  description = "(optional) Existing private subnets into which the cluster should be installed."
+}
+
+variable "aws_internal_zone" {
+  type        = string
+  default     = null
+  description = "(optional) An existing private hosted zone (zone ID) for the records of an Internal install. A private zone associated with aws_vpc is created if unset."
+}
+
+variable "aws_publish_strategy" {
+  type        = string
+  description = "The cluster publishing strategy, either Internal or External."
+}
+
+variable "aws_user_data" {
+  type        = string
+  sensitive   = true
+  description = "The cloud-init user data of the Terraform Enterprise instances."
+}
+
+variable "aws_master_iam_role_name" {
+  type        = string
+  default     = ""
+  description = "The name of an existing IAM role for the control plane instance profile, which must be allowed to fetch the objects of the bootstrap bucket. The object storage role is used if unset."
+}
+
+variable "aws_worker_iam_role_name" {
+  type        = string
+  default     = ""
+  description = "The name of an existing IAM role for the compute instance profile. The agents role is used if unset, and the compute nodes get no instance profile if there is none."
+}
+
+variable "aws_credentials_mode" {
+  type        = string
+  default     = "Mint"
+  description = "How the TFE components get their AWS credentials: Mint, Passthrough or Manual."
+}
+
+variable "aws_object_storage_role_arn" {
+  type        = string
+  default     = ""
+  description = "The ARN of an existing IAM role for the TFE object storage. Required if aws_credentials_mode is Manual."
+}
+
+variable "aws_agents_role_arn" {
+  type        = string
+  default     = ""
+  description = "(optional) The ARN of an existing IAM role for the TFE agents when aws_credentials_mode is Manual."
+}
+
+variable "aws_passthrough_access_key_id" {
+  type        = string
+  default     = ""
+  sensitive   = true
+  description = "The access key ID of the installer, used by TFE when aws_credentials_mode is Passthrough. It is only written to the temporary directory Terraform runs in."
+}
+
+variable "aws_passthrough_secret_access_key" {
+  type        = string
+  default     = ""
+  sensitive   = true
+  description = "The secret access key of the installer, used by TFE when aws_credentials_mode is Passthrough. It is only written to the temporary directory Terraform runs in."
+}
+
+variable "aws_object_storage_bucket" {
+  type        = string
+  default     = ""
+  description = "(optional) The existing bucket of the TFE external services. The object storage role may only reach the buckets of the cluster if unset."
+}
+
+variable "aws_object_storage_kms_key_arn" {
+  type        = string
+  default     = ""
+  description = "(optional) The ARN of the KMS key that the objects in aws_object_storage_bucket are encrypted with."
+}
@@ -1,3 +1,73 @@
 terraform {
   required_version = ">= 0.14"
-}
\ No newline at end of file
+}
+variable "cluster_id" {
+  type        = string
+  description = "The identifier for the cluster."
+}
+
+variable "cluster_domain" {
+  type        = string
+  description = "The domain for the cluster that all DNS records must belong to."
+}
+
+variable "base_domain" {
+  type        = string
+  description = "The base DNS domain of the cluster."
+}
+
+variable "machine_v4_cidrs" {
+  type        = list(string)
+  description = "The list of IPv4 address spaces from which to assign machine IPs."
+}
+
+variable "machine_v6_cidrs" {
+  type        = list(string)
+  description = "The list of IPv6 address spaces from which to assign machine IPs."
+}
+
+variable "use_ipv4" {
+  type        = bool
+  description = "Whether the cluster uses IPv4 addresses."
+}
+
+variable "use_ipv6" {
+  type        = bool
+  description = "Whether the cluster uses IPv6 addresses."
+}
+
+variable "airgap_package_url" {
+  type        = string
+  default     = ""
+  description = "The s3:// or https:// URL of the airgap package the instances install from. Empty for online installs."
+}
+
+variable "airgap_bootstrapper_url" {
+  type        = string
+  default     = ""
+  description = "The s3:// or https:// URL of the bootstrapper archive the instances install from. Empty for online installs."
+}
+
+variable "tfe_hostname" {
+  type        = string
+  description = "The fully qualified domain name users reach Terraform Enterprise at."
+}
+
+variable "tfe_licence" {
+  type        = string
+  sensitive   = true
+  description = "The licence of Terraform Enterprise, stored where the instances fetch it from."
+}
+
+variable "tfe_tls_key" {
+  type        = string
+  default     = ""
+  sensitive   = true
+  description = "The PEM private key of the Terraform Enterprise certificate, stored where the instances fetch it from. Empty when Terraform Enterprise generates a self-signed certificate."
+}
+
+variable "tfe_secret_settings" {
+  type        = string
+  sensitive   = true
+  description = "The JSON secret application settings of Terraform Enterprise, stored where the instances fetch them from and merged into the settings of the user data."
+}
@@ -0,0 +1,145 @@
+// Package airgap packages what an install without internet access needs to
+// carry into the disconnected environment.
+package airgap
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TerraformDir is the directory of the bundle holding the terraform
+	// binary.
+	TerraformDir = "terraform"
+
+	// ProvidersDir is the directory of the bundle holding the provider
+	// mirror, in the layout of a terraform filesystem mirror.
+	ProvidersDir = "providers"
+
+	// ArtifactsDir is the directory of the bundle holding the Terraform
+	// Enterprise artifacts.
+	ArtifactsDir = "artifacts"
+
+	// ChecksumsFile is the file of the bundle listing the SHA-256 checksum
+	// of every other file, in the format of sha256sum.
+	ChecksumsFile = "SHA256SUMS"
+)
+
+// WriteBundle writes a gzipped tar archive to w with the terraform binary and
+// the providers of the mirror, which has the layout of providers.Mirror, and
+// the given artifact files. Files at the top of the mirror, like its README,
+// are left out.
+func WriteBundle(w io.Writer, mirror fs.FS, artifacts []string) error {
+	gz := gzip.NewWriter(w)
+	b := &bundle{
+		tar:       tar.NewWriter(gz),
+		modTime:   time.Now(),
+		checksums: map[string]string{},
+	}
+
+	err := fs.WalkDir(mirror, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.Contains(name, "/") {
+			return err
+		}
+		dest := path.Join(ProvidersDir, name)
+		if strings.HasPrefix(name, TerraformDir+"/") {
+			dest = name
+		}
+		f, err := mirror.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return b.add(dest, f, info.Size(), 0755)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add the provider mirror")
+	}
+
+	for _, artifact := range artifacts {
+		if err := b.addFile(path.Join(ArtifactsDir, filepath.Base(artifact)), artifact); err != nil {
+			return errors.Wrapf(err, "failed to add artifact %s", artifact)
+		}
+	}
+
+	if err := b.addChecksums(); err != nil {
+		return errors.Wrap(err, "failed to add checksums")
+	}
+	if err := b.tar.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+type bundle struct {
+	tar       *tar.Writer
+	modTime   time.Time
+	checksums map[string]string
+}
+
+func (b *bundle) add(name string, r io.Reader, size int64, mode int64) error {
+	if _, ok := b.checksums[name]; ok {
+		return errors.Errorf("%s is added twice", name)
+	}
+	if err := b.tar.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    size,
+		ModTime: b.modTime,
+	}); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(b.tar, h), r); err != nil {
+		return err
+	}
+	b.checksums[name] = fmt.Sprintf("%x", h.Sum(nil))
+	return nil
+}
+
+func (b *bundle) addFile(name string, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errors.New("not a regular file")
+	}
+	return b.add(name, f, info.Size(), 0644)
+}
+
+func (b *bundle) addChecksums() error {
+	names := make([]string, 0, len(b.checksums))
+	for name := range b.checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sums strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sums, "%s  %s\n", b.checksums[name], name)
+	}
+	return b.add(ChecksumsFile, strings.NewReader(sums.String()), int64(sums.Len()), 0644)
+}
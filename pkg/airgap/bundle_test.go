@@ -0,0 +1,108 @@
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteBundle(t *testing.T) {
+	mirror := fstest.MapFS{
+		"README":              {Data: []byte("readme")},
+		"terraform/terraform": {Data: []byte("terraform binary")},
+		"openshift/local/aws/1.0.0/linux_amd64/p": {Data: []byte("aws provider")},
+	}
+	dir := t.TempDir()
+	artifact := filepath.Join(dir, "tfe.airgap")
+	if err := ioutil.WriteFile(artifact, []byte("airgap package"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		artifacts     []string
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			name:      "mirror and artifacts",
+			artifacts: []string{artifact},
+			expected: map[string]string{
+				"terraform/terraform":                               "terraform binary",
+				"providers/openshift/local/aws/1.0.0/linux_amd64/p": "aws provider",
+				"artifacts/tfe.airgap":                              "airgap package",
+			},
+		},
+		{
+			name:          "missing artifact",
+			artifacts:     []string{filepath.Join(dir, "missing.tar.gz")},
+			expectedError: `^failed to add artifact .*missing\.tar\.gz: open .*: no such file or directory$`,
+		},
+		{
+			name:          "directory artifact",
+			artifacts:     []string{dir},
+			expectedError: `^failed to add artifact .*: not a regular file$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := WriteBundle(buf, mirror, tc.artifacts)
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			files := readBundle(t, buf)
+			var names []string
+			for name, content := range tc.expected {
+				assert.Equal(t, content, files[name], name)
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var sums strings.Builder
+			for _, name := range names {
+				fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256([]byte(tc.expected[name])), name)
+			}
+			assert.Equal(t, sums.String(), files[ChecksumsFile])
+			assert.Len(t, files, len(tc.expected)+1)
+		})
+	}
+}
+
+func readBundle(t *testing.T, r io.Reader) map[string]string {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(data)
+	}
+}
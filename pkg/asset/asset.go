@@ -59,13 +59,62 @@ func PersistToFile(asset WritableAsset, directory string) error {
 		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
 			return errors.Wrap(err, "failed to create dir")
 		}
-		if err := ioutil.WriteFile(path, f.Data, 0640); err != nil {
+		if err := WriteFileAtomic(path, f.Data, 0640); err != nil {
 			return errors.Wrap(err, "failed to write file")
 		}
 	}
 	return nil
 }
 
+// WriteFileAtomic writes data to the named file so that readers see either the
+// previous contents or the new contents, never a partial write. The data is
+// written to a temporary file in the same directory, synced to disk and then
+// renamed over the named file.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	defer func() {
+		if tmpName != "" {
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return err
+	}
+	tmpName = ""
+	return syncDir(dir)
+}
+
+// syncDir flushes the directory entry of a renamed file to disk.
+func syncDir(name string) error {
+	d, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
 // DeleteAssetFromDisk removes all the files for asset from disk.
 // this is function is not safe for calling concurrently on the same directory.
 func DeleteAssetFromDisk(asset WritableAsset, directory string) error {
@@ -75,16 +124,48 @@ func DeleteAssetFromDisk(asset WritableAsset, directory string) error {
 		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 			return errors.Wrap(err, "failed to remove file")
 		}
-
-		dir := filepath.Dir(path)
-		ok, err := isDirEmpty(dir)
-		if err != nil && !os.IsNotExist(err) {
-			return errors.Wrap(err, "failed to read directory")
+		if err := removeDirIfEmpty(filepath.Dir(path)); err != nil {
+			return err
 		}
-		if ok {
-			if err := os.Remove(dir); err != nil {
-				return errors.Wrap(err, "failed to remove directory")
+	}
+	return nil
+}
+
+// ArchiveAssetFromDisk moves all the files for asset from directory into
+// archiveDirectory, keeping their paths relative to directory.
+// this is function is not safe for calling concurrently on the same directory.
+func ArchiveAssetFromDisk(asset WritableAsset, directory, archiveDirectory string) error {
+	logrus.Debugf("Archiving asset %q to %q", asset.Name(), archiveDirectory)
+	for _, f := range asset.Files() {
+		path := filepath.Join(directory, f.Filename)
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
 			}
+			return errors.Wrap(err, "failed to read file")
+		}
+		archivePath := filepath.Join(archiveDirectory, f.Filename)
+		if err := os.MkdirAll(filepath.Dir(archivePath), 0750); err != nil {
+			return errors.Wrap(err, "failed to create archive dir")
+		}
+		if err := os.Rename(path, archivePath); err != nil {
+			return errors.Wrap(err, "failed to archive file")
+		}
+		if err := removeDirIfEmpty(filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeDirIfEmpty(dir string) error {
+	ok, err := isDirEmpty(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to read directory")
+	}
+	if ok {
+		if err := os.Remove(dir); err != nil {
+			return errors.Wrap(err, "failed to remove directory")
 		}
 	}
 	return nil
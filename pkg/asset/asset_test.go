@@ -3,6 +3,7 @@ package asset
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -81,6 +82,21 @@ func TestPersistToFile(t *testing.T) {
 	}
 }
 
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+
+	err := WriteFileAtomic(path, []byte("old"), 0640)
+	assert.NoError(t, err, "unexpected error writing file")
+	err = WriteFileAtomic(path, []byte("new"), 0600)
+	assert.NoError(t, err, "unexpected error overwriting file")
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err, "unexpected error reading file info")
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "unexpected file permissions")
+	verifyFilesCreated(t, dir, map[string][]byte{path: []byte("new")})
+}
+
 func verifyFilesCreated(t *testing.T, dir string, expectedFiles map[string][]byte) {
 	dirContents, err := ioutil.ReadDir(dir)
 	assert.NoError(t, err, "could not read contents of directory %q", dir)
@@ -6,11 +6,13 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/pkg/errors"
 
 	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	awsconfig "github.com/bailey84j/terraform_installer/pkg/asset/installconfig/aws"
 	"github.com/bailey84j/terraform_installer/pkg/types"
 	awstypes "github.com/bailey84j/terraform_installer/pkg/types/aws"
 )
@@ -32,12 +34,24 @@ func Metadata(clusterID, infraID string, config *types.InstallConfig) *awstypes.
 // PreTerraform performs any infrastructure initialization which must
 // happen before Terraform creates the remaining infrastructure.
 func PreTerraform(ctx context.Context, clusterID string, installConfig *installconfig.InstallConfig) error {
+	return tagSharedVPCResources(ctx, clusterID, installConfig)
+}
 
-	if err := tagSharedVPCResources(ctx, clusterID, installConfig); err != nil {
-		return err
+// PassthroughCredentials returns the installer's credentials, which are
+// handed to the TFE components when the credentials mode is Passthrough.
+func PassthroughCredentials(ctx context.Context, installConfig *installconfig.InstallConfig) (credentials.Value, error) {
+	session, err := installConfig.AWS.Session(ctx)
+	if err != nil {
+		return credentials.Value{}, err
 	}
-
-	return nil
+	creds, err := session.Config.Credentials.Get()
+	if err != nil {
+		return credentials.Value{}, errors.Wrap(err, "failed to get the AWS credentials")
+	}
+	if !awsconfig.IsStaticCredentials(creds) {
+		return credentials.Value{}, errors.Errorf("credentialsMode Passthrough requires static credentials, but the installer is using credentials from %s", creds.ProviderName)
+	}
+	return creds, nil
 }
 
 func tagSharedVPCResources(ctx context.Context, clusterID string, installConfig *installconfig.InstallConfig) error {
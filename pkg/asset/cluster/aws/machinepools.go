@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	awstypes "github.com/bailey84j/terraform_installer/pkg/types/aws"
+	awsdefaults "github.com/bailey84j/terraform_installer/pkg/types/aws/defaults"
+)
+
+// MachinePool returns the effective AWS configuration of a machine pool: the
+// installer defaults for the pool, overridden by the platform's
+// defaultMachinePlatform and then by the pool's own configuration. When no
+// zones are configured, the zones of the existing private subnets are used,
+// or every availability zone of the region if there are none.
+func MachinePool(ctx context.Context, installConfig *installconfig.InstallConfig, pool *types.MachinePool) (*awstypes.MachinePool, error) {
+	platform := installConfig.Config.Platform.AWS
+
+	mpool := &awstypes.MachinePool{}
+	mpool.Set(platform.DefaultMachinePlatform)
+	mpool.Set(pool.Platform.AWS)
+	awsdefaults.SetMachinePoolDefaults(mpool, platform.Region, pool.Architecture, pool.Name)
+
+	if len(mpool.Zones) > 0 {
+		return mpool, nil
+	}
+
+	if len(platform.Subnets) > 0 {
+		subnets, err := installConfig.AWS.PrivateSubnets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		zones := sets.NewString()
+		for _, subnet := range subnets {
+			zones.Insert(subnet.Zone)
+		}
+		mpool.Zones = zones.List()
+		return mpool, nil
+	}
+
+	zones, err := installConfig.AWS.AvailabilityZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mpool.Zones = append([]string{}, zones...)
+	sort.Strings(mpool.Zones)
+	return mpool, nil
+}
@@ -15,15 +15,45 @@ import (
 	"github.com/bailey84j/terraform_installer/pkg/asset/cluster/aws"
 	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
 	"github.com/bailey84j/terraform_installer/pkg/asset/password"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tfe"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tls"
+	"github.com/bailey84j/terraform_installer/pkg/hooks"
 	"github.com/bailey84j/terraform_installer/pkg/metrics/timer"
 	"github.com/bailey84j/terraform_installer/pkg/terraform"
 	platformstages "github.com/bailey84j/terraform_installer/pkg/terraform/stages/platform"
+	"github.com/bailey84j/terraform_installer/pkg/tfvars"
+	awstfvars "github.com/bailey84j/terraform_installer/pkg/tfvars/aws"
+	"github.com/bailey84j/terraform_installer/pkg/types"
 	typesaws "github.com/bailey84j/terraform_installer/pkg/types/aws"
 )
 
+const (
+	// tfSecretVarsFileName is the name of the Terraform variable file with
+	// the licence, the TLS key and the secret settings. It is only written to the
+	// temporary directory of each stage.
+	tfSecretVarsFileName = "terraform.secrets.auto.tfvars.json"
+
+	// tfPassthroughVarsFileName is the name of the Terraform variable file
+	// with the installer's credentials when the credentials mode is
+	// Passthrough. Like the secrets, it is only written to the temporary
+	// directory of each stage.
+	tfPassthroughVarsFileName = "terraform.passthrough.auto.tfvars.json"
+)
+
+// secretTFVarsFileNames are the Terraform variable files with secrets, which
+// are not handed to the hooks.
+var secretTFVarsFileNames = map[string]bool{
+	tfSecretVarsFileName:      true,
+	tfPassthroughVarsFileName: true,
+}
+
 var (
 	// InstallDir is the directory containing install assets.
 	InstallDir string
+
+	// Hooks are the lifecycle hooks run around the terraform stage applies.
+	// They are the hooks the asset store was created with.
+	Hooks *hooks.Config
 )
 
 // Cluster uses the terraform executable to launch a cluster
@@ -51,8 +81,12 @@ func (c *Cluster) Dependencies() []asset.Asset {
 		// they are put in the dependencies but not fetched in Generate.
 		//&installconfig.PlatformCredsCheck{},
 		//&installconfig.PlatformPermsCheck{},
-		//&installconfig.PlatformProvisionCheck{},
+		&installconfig.PlatformProvisionCheck{},
+		&installconfig.ExternalServicesCheck{},
 		//&quota.PlatformQuotaCheck{},
+		&installconfig.Licence{},
+		&tfe.EncryptionPassword{},
+		&tls.TFECertKey{},
 		&TerraformVariables{},
 		&password.TFEPassword{},
 	}
@@ -66,8 +100,11 @@ func (c *Cluster) Generate(parents asset.Parents) (err error) {
 
 	clusterID := &installconfig.ClusterID{}
 	installConfig := &installconfig.InstallConfig{}
+	licence := &installconfig.Licence{}
+	encryptionPassword := &tfe.EncryptionPassword{}
+	tfeCertKey := &tls.TFECertKey{}
 	terraformVariables := &TerraformVariables{}
-	parents.Get(clusterID, installConfig, terraformVariables)
+	parents.Get(clusterID, installConfig, licence, encryptionPassword, tfeCertKey, terraformVariables)
 	logrus.Debugf("Trace Me:\nclusterID - %+v\ninstallconfig - %+v\ntfvars - %+v", clusterID, installConfig, terraformVariables)
 	/*
 		if fs := installConfig.Config.FeatureSet; strings.HasSuffix(string(fs), "NoUpgrade") {
@@ -132,14 +169,50 @@ func (c *Cluster) Generate(parents asset.Parents) (err error) {
 		*/
 	}
 
-	tfvarsFiles := make([]*asset.File, 0, len(terraformVariables.Files())+len(stages))
+	licenceData, err := licence.Data()
+	if err != nil {
+		return err
+	}
+	if err := installConfig.ResolveSecrets(); err != nil {
+		return err
+	}
+	if !encryptionPassword.Provided && encryptionPassword.Password() == "" {
+		return errors.Errorf("%s is missing; restore it, since the data of Terraform Enterprise cannot be decrypted without it", tfe.EncryptionPasswordFilename)
+	}
+	secretSettings, err := tfe.SecretSettings(installConfig.Config.TFE, encryptionPassword.Password())
+	if err != nil {
+		return err
+	}
+	secretVars, err := tfvars.SecretTFVars(tfvars.SecretTFVarsSources{
+		Licence:  licenceData,
+		TLSKey:   tfeCertKey.KeyRaw,
+		Settings: secretSettings,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get the secret Terraform variables")
+	}
+
+	tfvarsFiles := make([]*asset.File, 0, len(terraformVariables.Files())+len(stages)+2)
 	for _, file := range terraformVariables.Files() {
 		tfvarsFiles = append(tfvarsFiles, file)
 	}
+	tfvarsFiles = append(tfvarsFiles, &asset.File{Filename: tfSecretVarsFileName, Data: secretVars})
+
+	if platform == typesaws.Name && installConfig.Config.CredentialsMode == types.PassthroughCredentialsMode {
+		creds, err := aws.PassthroughCredentials(context.TODO(), installConfig)
+		if err != nil {
+			return err
+		}
+		passthroughVars, err := awstfvars.PassthroughTFVars(creds.AccessKeyID, creds.SecretAccessKey)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the passthrough Terraform variables")
+		}
+		tfvarsFiles = append(tfvarsFiles, &asset.File{Filename: tfPassthroughVarsFileName, Data: passthroughVars})
+	}
 
 	for _, stage := range stages {
-		logrus.Debugf("Trace Me: applyStage: %s; %+v; %s; %+v", platform, stage, terraformDirPath, tfvarsFiles)
-		outputs, err := c.applyStage(platform, stage, terraformDirPath, tfvarsFiles)
+		logrus.Debugf("Trace Me: applyStage: %s; %+v; %s", platform, stage, terraformDirPath)
+		outputs, err := c.applyStage(platform, stage, terraformDirPath, tfvarsFiles, Hooks)
 		if err != nil {
 			return errors.Wrapf(err, "failure applying terraform for %q stage", stage.Name())
 		}
@@ -169,7 +242,7 @@ func (c *Cluster) Load(f asset.FileFetcher) (found bool, err error) {
 	return false, nil
 }
 
-func (c *Cluster) applyStage(platform string, stage terraform.Stage, terraformDir string, tfvarsFiles []*asset.File) (*asset.File, error) {
+func (c *Cluster) applyStage(platform string, stage terraform.Stage, terraformDir string, tfvarsFiles []*asset.File, hookConfig *hooks.Config) (*asset.File, error) {
 	// Copy the terraform.tfvars to a temp directory which will contain the terraform plan.
 	tmpDir, err := ioutil.TempDir("", fmt.Sprintf("openshift-install-%s-", stage.Name()))
 	if err != nil {
@@ -178,14 +251,35 @@ func (c *Cluster) applyStage(platform string, stage terraform.Stage, terraformDi
 	defer os.RemoveAll(tmpDir)
 
 	var extraOpts []tfexec.ApplyOption
+	payload := hooks.Payload{Stage: stage.Name()}
 	for _, file := range tfvarsFiles {
 		if err := ioutil.WriteFile(filepath.Join(tmpDir, file.Filename), file.Data, 0600); err != nil {
 			return nil, err
 		}
 		extraOpts = append(extraOpts, tfexec.VarFile(filepath.Join(tmpDir, file.Filename)))
+		if !secretTFVarsFileNames[file.Filename] {
+			payload.Inputs = append(payload.Inputs, filepath.Join(tmpDir, file.Filename))
+		}
 	}
 
-	return c.applyTerraform(tmpDir, platform, stage, terraformDir, extraOpts...)
+	if err := hookConfig.Run(hooks.PreApply, payload); err != nil {
+		return nil, err
+	}
+
+	outputs, err := c.applyTerraform(tmpDir, platform, stage, terraformDir, extraOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the outputs available to the post-apply hooks next to the state file.
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, outputs.Filename), outputs.Data, 0600); err != nil {
+		return nil, err
+	}
+	payload.Outputs = []string{filepath.Join(tmpDir, terraform.StateFilename), filepath.Join(tmpDir, outputs.Filename)}
+	if err := hookConfig.Run(hooks.PostApply, payload); err != nil {
+		return nil, err
+	}
+	return outputs, nil
 }
 
 func (c *Cluster) applyTerraform(tmpDir string, platform string, stage terraform.Stage, terraformDir string, opts ...tfexec.ApplyOption) (*asset.File, error) {
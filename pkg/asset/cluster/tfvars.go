@@ -1,11 +1,20 @@
 package cluster
 
 import (
+	"context"
 	"os"
+	"sort"
 
-	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/pkg/errors"
 
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/cluster/aws"
 	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tfe"
+	"github.com/bailey84j/terraform_installer/pkg/tfvars"
+	awstfvars "github.com/bailey84j/terraform_installer/pkg/tfvars/aws"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	typesaws "github.com/bailey84j/terraform_installer/pkg/types/aws"
 )
 
 const (
@@ -39,6 +48,8 @@ func (t *TerraformVariables) Dependencies() []asset.Asset {
 	return []asset.Asset{
 		&installconfig.ClusterID{},
 		&installconfig.InstallConfig{},
+		&installconfig.Licence{},
+		&tfe.UserData{},
 		//new(rhcos.Image),
 		//new(rhcos.Release),
 		//new(rhcos.BootstrapImage),
@@ -54,9 +65,11 @@ func (t *TerraformVariables) Dependencies() []asset.Asset {
 
 // Generate generates the terraform.tfvars file.
 func (t *TerraformVariables) Generate(parents asset.Parents) error {
-	//ctx := context.TODO()
-	//clusterID := &installconfig.ClusterID{}
-	//installConfig := &installconfig.InstallConfig{}
+	ctx := context.TODO()
+	clusterID := &installconfig.ClusterID{}
+	installConfig := &installconfig.InstallConfig{}
+	userData := &tfe.UserData{}
+	parents.Get(clusterID, installConfig, userData)
 	/*
 		bootstrapIgnAsset := &bootstrap.Bootstrap{}
 		masterIgnAsset := &machine.Master{}
@@ -82,24 +95,6 @@ func (t *TerraformVariables) Generate(parents asset.Parents) error {
 			return errors.Wrap(err, "unable to inject installation info")
 		}
 
-		var useIPv4, useIPv6 bool
-		for _, network := range installConfig.Config.Networking.ServiceNetwork {
-			if network.IP.To4() != nil {
-				useIPv4 = true
-			} else {
-				useIPv6 = true
-			}
-		}
-
-		machineV4CIDRs, machineV6CIDRs := []string{}, []string{}
-		for _, network := range installConfig.Config.Networking.MachineNetwork {
-			if network.CIDR.IPNet.IP.To4() != nil {
-				machineV4CIDRs = append(machineV4CIDRs, network.CIDR.IPNet.String())
-			} else {
-				machineV6CIDRs = append(machineV6CIDRs, network.CIDR.IPNet.String())
-			}
-		}
-
 		masterCount := len(mastersAsset.MachineFiles)
 		mastersSchedulable := false
 		for _, f := range manifestsAsset.Files() {
@@ -114,30 +109,135 @@ func (t *TerraformVariables) Generate(parents asset.Parents) error {
 			}
 		}
 	*/
-	/*
-		data, err := tfvars.TFVars(
-			clusterID.InfraID,
-			installConfig.Config.ClusterDomain(),
-			installConfig.Config.BaseDomain,
-			//machineV4CIDRs,
-			//machineV6CIDRs,
-			//useIPv4,
-			//useIPv6,
-			//bootstrapIgn,
-			//masterIgn,
-			//masterCount,
-			//mastersSchedulable,
-		)
+
+	var useIPv4, useIPv6 bool
+	for _, network := range installConfig.Config.Networking.ServiceNetwork {
+		if network.IP.To4() != nil {
+			useIPv4 = true
+		} else {
+			useIPv6 = true
+		}
+	}
+
+	machineV4CIDRs, machineV6CIDRs := []string{}, []string{}
+	for _, network := range installConfig.Config.Networking.MachineNetwork {
+		if network.CIDR.IPNet.IP.To4() != nil {
+			machineV4CIDRs = append(machineV4CIDRs, network.CIDR.IPNet.String())
+		} else {
+			machineV6CIDRs = append(machineV6CIDRs, network.CIDR.IPNet.String())
+		}
+	}
+
+	data, err := tfvars.TFVars(tfvars.TFVarsSources{
+		ClusterID:      clusterID.InfraID,
+		ClusterDomain:  installConfig.Config.ClusterDomain(),
+		BaseDomain:     installConfig.Config.BaseDomain,
+		MachineV4CIDRs: machineV4CIDRs,
+		MachineV6CIDRs: machineV6CIDRs,
+		UseIPv4:        useIPv4,
+		UseIPv6:        useIPv6,
+
+		Airgap:      installConfig.Config.Airgap,
+		TFEHostname: installConfig.Config.TFE.Hostname,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get Terraform variables")
+	}
+	t.FileList = []*asset.File{
+		{
+			Filename: TfVarsFileName,
+			Data:     data,
+		},
+	}
+
+	switch platform := installConfig.Config.Platform.Name(); platform {
+	case typesaws.Name:
+		var vpc string
+		var privateSubnets []string
+		var publicSubnets []string
+
+		if len(installConfig.Config.Platform.AWS.Subnets) > 0 {
+			subnets, err := installConfig.AWS.PrivateSubnets(ctx)
+			if err != nil {
+				return err
+			}
+
+			for id := range subnets {
+				privateSubnets = append(privateSubnets, id)
+			}
+
+			subnets, err = installConfig.AWS.PublicSubnets(ctx)
+			if err != nil {
+				return err
+			}
+
+			for id := range subnets {
+				publicSubnets = append(publicSubnets, id)
+			}
+
+			vpc, err = installConfig.AWS.VPC(ctx)
+			if err != nil {
+				return err
+			}
+			sort.Strings(privateSubnets)
+			sort.Strings(publicSubnets)
+		}
+
+		masterPool, err := aws.MachinePool(ctx, installConfig, installConfig.Config.ControlPlane)
 		if err != nil {
-			return errors.Wrap(err, "failed to get Terraform variables")
+			return errors.Wrap(err, "failed to resolve the control plane machine pool")
 		}
-		t.FileList = []*asset.File{
-			{
-				Filename: TfVarsFileName,
-				Data:     data,
-			},
+
+		var objectStorage *types.ObjectStorage
+		if es := installConfig.Config.TFE.ExternalServices; es != nil {
+			objectStorage = &es.ObjectStorage
 		}
-	*/
+
+		var workerPool *typesaws.MachinePool
+		var workerReplicas int64
+		if mp := installConfig.Config.WorkerMachinePool(); mp != nil {
+			workerPool, err = aws.MachinePool(ctx, installConfig, mp)
+			if err != nil {
+				return errors.Wrap(err, "failed to resolve the compute machine pool")
+			}
+			workerReplicas = *mp.Replicas
+		}
+
+		var dataVolumeDevice string
+		if c := installConfig.Config.TFE; c != nil && c.OperationalMode == types.MountedDiskOperationalMode {
+			dataVolumeDevice = tfe.DataVolumeDevice
+		}
+
+		data, err := awstfvars.TFVars(awstfvars.TFVarsSources{
+			VPC:             vpc,
+			PrivateSubnets:  privateSubnets,
+			PublicSubnets:   publicSubnets,
+			InternalZone:    installConfig.Config.AWS.HostedZone,
+			Services:        installConfig.Config.AWS.ServiceEndpoints,
+			Publish:         installConfig.Config.Publish,
+			Region:          installConfig.Config.AWS.Region,
+			UserTags:        installConfig.Config.AWS.UserTags,
+			CredentialsMode: installConfig.Config.CredentialsMode,
+			ComponentRoles:  installConfig.Config.AWS.ComponentRoles,
+			ObjectStorage:   objectStorage,
+			UserData:        string(userData.File.Data),
+			AMIID:           installConfig.Config.AWS.AMIID,
+			AMIRegion:       installConfig.Config.AWS.Region,
+			MasterPool:      masterPool,
+			WorkerPool:      workerPool,
+			MasterReplicas:  *installConfig.Config.ControlPlane.Replicas,
+			WorkerReplicas:  workerReplicas,
+
+			DataVolumeDevice: dataVolumeDevice,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %s Terraform variables", platform)
+		}
+		t.FileList = append(t.FileList, &asset.File{
+			Filename: TfPlatformVarsFileName,
+			Data:     data,
+		})
+	}
 	/*
 		if masterCount == 0 {
 			return errors.Errorf("master slice cannot be empty")
@@ -5,6 +5,15 @@ type FileWriter interface {
 	PersistToFile(directory string) error
 }
 
+// PreviousLoader is implemented by writable assets whose copy in the target
+// directory must survive their regeneration, such as generated passwords that
+// may already be in use. Before such an asset is regenerated because its
+// dependencies changed, its copy in the target directory is loaded with
+// LoadPrevious, so that Generate can keep it.
+type PreviousLoader interface {
+	LoadPrevious(FileFetcher) error
+}
+
 // NewDefaultFileWriter create a new adapter to expose the default implementation as a FileWriter
 func NewDefaultFileWriter(a WritableAsset) FileWriter {
 	return &fileWriterAdapter{a: a}
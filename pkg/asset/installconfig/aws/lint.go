@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/lint"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+var (
+	unknownRegionRule = lint.Register("aws-unknown-region", lint.SeverityWarning,
+		"The region is not one of the regions known to the installer.")
+	undersizedInstanceRule = lint.Register("aws-undersized-instance", lint.SeverityWarning,
+		"The instance type of a machine pool has fewer vCPUs or less memory than recommended.")
+)
+
+// Lint returns the AWS-specific lint findings about the install config that do
+// not need AWS. See LintInstanceTypes for the ones that do.
+func Lint(config *types.InstallConfig) lint.Findings {
+	var findings lint.Findings
+	platform := config.Platform.AWS
+	fldPath := field.NewPath("platform", "aws")
+
+	architecture := types.Architecture(types.ArchitectureAMD64)
+	if config.ControlPlane != nil && config.ControlPlane.Architecture != "" {
+		architecture = config.ControlPlane.Architecture
+	}
+	if !IsKnownPublicRegion(platform.Region, architecture) {
+		findings = append(findings, unknownRegionRule.Found(fldPath.Child("region"), "%s is not a known region for %s", platform.Region, architecture))
+	}
+	return findings
+}
+
+// LintInstanceTypes compares the instance types set in the install config with
+// the recommended resources of the machine pools. Instance types chosen by the
+// installer are not checked. It lists the instance types of the region, so it
+// needs AWS credentials.
+func LintInstanceTypes(ctx context.Context, meta *Metadata, config *types.InstallConfig) lint.Findings {
+	type pool struct {
+		path         *field.Path
+		instanceType string
+		req          resourceRequirements
+	}
+	var pools []pool
+	instanceType := func(mp *types.MachinePool) string {
+		if mp != nil && mp.Platform.AWS != nil && mp.Platform.AWS.InstanceType != "" {
+			return mp.Platform.AWS.InstanceType
+		}
+		if config.Platform.AWS.DefaultMachinePlatform != nil {
+			return config.Platform.AWS.DefaultMachinePlatform.InstanceType
+		}
+		return ""
+	}
+	if t := instanceType(config.ControlPlane); t != "" {
+		pools = append(pools, pool{path: field.NewPath("controlPlane", "platform", "aws", "type"), instanceType: t, req: controlPlaneReq})
+	}
+	for i := range config.Compute {
+		if t := instanceType(&config.Compute[i]); t != "" {
+			pools = append(pools, pool{path: field.NewPath("compute").Index(i).Child("platform", "aws", "type"), instanceType: t, req: computeReq})
+		}
+	}
+	if len(pools) == 0 {
+		return nil
+	}
+
+	instanceTypes, err := meta.InstanceTypes(ctx)
+	if err != nil {
+		logrus.Debugf("Skipping the instance type lint: %v", err)
+		return nil
+	}
+
+	var findings lint.Findings
+	for _, p := range pools {
+		info, ok := instanceTypes[p.instanceType]
+		if !ok {
+			continue
+		}
+		if info.DefaultVCpus < p.req.minimumVCpus {
+			findings = append(findings, undersizedInstanceRule.Found(p.path, "%s has %d vCPUs, fewer than the recommended %d", p.instanceType, info.DefaultVCpus, p.req.minimumVCpus))
+		}
+		if info.MemInMiB < p.req.minimumMemory {
+			findings = append(findings, undersizedInstanceRule.Found(p.path, "%s has %d MiB of memory, less than the recommended %d MiB", p.instanceType, info.MemInMiB, p.req.minimumMemory))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	awstypes "github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+func TestLint(t *testing.T) {
+	meta := &Metadata{
+		Region: "us-east-1",
+		instanceTypes: map[string]InstanceType{
+			"m6i.xlarge": {DefaultVCpus: 4, MemInMiB: 16384},
+			"t3.medium":  {DefaultVCpus: 2, MemInMiB: 4096},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		edit     func(*types.InstallConfig)
+		expected []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "unknown region",
+			edit: func(c *types.InstallConfig) {
+				c.Platform.AWS.Region = "eu-west-1"
+			},
+			expected: []string{"[warning] aws-unknown-region: platform.aws.region: eu-west-1 is not a known region for amd64"},
+		},
+		{
+			name: "undersized instances",
+			edit: func(c *types.InstallConfig) {
+				c.Platform.AWS.DefaultMachinePlatform = &awstypes.MachinePool{InstanceType: "t3.medium"}
+			},
+			expected: []string{
+				"[warning] aws-undersized-instance: controlPlane.platform.aws.type: t3.medium has 2 vCPUs, fewer than the recommended 4",
+				"[warning] aws-undersized-instance: controlPlane.platform.aws.type: t3.medium has 4096 MiB of memory, less than the recommended 16384 MiB",
+				"[warning] aws-undersized-instance: compute[0].platform.aws.type: t3.medium has 4096 MiB of memory, less than the recommended 8192 MiB",
+			},
+		},
+		{
+			name: "pool overrides the default instance type",
+			edit: func(c *types.InstallConfig) {
+				c.Platform.AWS.DefaultMachinePlatform = &awstypes.MachinePool{InstanceType: "t3.medium"}
+				c.ControlPlane.Platform.AWS = &awstypes.MachinePool{InstanceType: "m6i.xlarge"}
+				c.Compute[0].Platform.AWS = &awstypes.MachinePool{InstanceType: "m6i.xlarge"}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &types.InstallConfig{
+				ControlPlane: &types.MachinePool{Name: "master"},
+				Compute:      []types.MachinePool{{Name: "worker"}},
+				Platform: types.Platform{
+					AWS: &awstypes.Platform{Region: "us-east-1"},
+				},
+			}
+			if tc.edit != nil {
+				tc.edit(config)
+			}
+			var actual []string
+			findings := Lint(config)
+			findings = append(findings, LintInstanceTypes(context.TODO(), meta, config)...)
+			for _, finding := range findings {
+				actual = append(actual, finding.String())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -43,7 +43,8 @@ func Validate(ctx context.Context, meta *Metadata, config *types.InstallConfig)
 		return errors.New(field.Required(field.NewPath("platform", "aws"), "AWS validation requires an AWS platform configuration").Error())
 	}
 	allErrs = append(allErrs, validateAMI(ctx, config)...)
-	//allErrs = append(allErrs, validatePlatform(ctx, meta, field.NewPath("platform", "aws"), config.Platform.AWS, config.Networking, config.Publish)...)
+	allErrs = append(allErrs, validateCredentialsMode(ctx, meta, config.CredentialsMode)...)
+	allErrs = append(allErrs, validatePlatform(ctx, meta, field.NewPath("platform", "aws"), config.Platform.AWS, config.Networking, config.Publish)...)
 	/*
 		if config.ControlPlane != nil && config.ControlPlane.Platform.AWS != nil {
 			allErrs = append(allErrs, validateMachinePool(ctx, meta, field.NewPath("controlPlane", "platform", "aws"), config.Platform.AWS, config.ControlPlane.Platform.AWS, controlPlaneReq)...)
@@ -107,6 +108,28 @@ func validateAMI(ctx context.Context, config *types.InstallConfig) field.ErrorLi
 	return field.ErrorList{field.Required(field.NewPath("platform", "aws", "amiID"), "AMI must be provided")}
 }
 
+// validateCredentialsMode checks that the installer's credentials can be
+// handed to TFE when the credentials mode is Passthrough. Temporary
+// credentials would expire while TFE still uses them.
+func validateCredentialsMode(ctx context.Context, meta *Metadata, mode types.CredentialsMode) field.ErrorList {
+	if mode != types.PassthroughCredentialsMode {
+		return nil
+	}
+	fldPath := field.NewPath("credentialsMode")
+	ssn, err := meta.Session(ctx)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, err)}
+	}
+	creds, err := ssn.Config.Credentials.Get()
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, errors.Wrap(err, "failed to get the AWS credentials"))}
+	}
+	if !IsStaticCredentials(creds) {
+		return field.ErrorList{field.Invalid(fldPath, mode, fmt.Sprintf("Passthrough requires static credentials, but the installer is using credentials from %s", creds.ProviderName))}
+	}
+	return nil
+}
+
 func validateSubnets(ctx context.Context, meta *Metadata, fldPath *field.Path, subnets []string, networking *types.Networking, publish types.PublishingStrategy) field.ErrorList {
 	allErrs := field.ErrorList{}
 	privateSubnets, err := meta.PrivateSubnets(ctx)
@@ -147,6 +170,14 @@ func validateSubnets(ctx context.Context, meta *Metadata, fldPath *field.Path, s
 		allErrs = append(allErrs, field.Invalid(fldPath, subnets, errMsg))
 	}
 
+	// A fully private install must not depend on anything reachable from the
+	// Internet, so the load balancer may only use private subnets.
+	if publish == types.InternalPublishingStrategy {
+		for _, id := range sets.StringKeySet(publicSubnetsIdx).List() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(publicSubnetsIdx[id]), id, "public subnets may not be used when publish is Internal"))
+		}
+	}
+
 	return allErrs
 }
 
@@ -224,6 +255,9 @@ var requiredServices = []string{
 }
 
 // ValidateForProvisioning validates if the install config is valid for provisioning the cluster.
+// When publish is Internal and no hosted zone is provided, a private hosted
+// zone associated with the VPC is created during provisioning, so there is
+// nothing to check.
 func ValidateForProvisioning(client API, ic *types.InstallConfig, metadata *Metadata) error {
 	if ic.Publish == types.InternalPublishingStrategy && ic.AWS.HostedZone == "" {
 		return nil
@@ -249,6 +283,9 @@ func ValidateForProvisioning(client API, ic *types.InstallConfig, metadata *Meta
 		if errors = validateHostedZone(zoneOutput, zonePath, zoneName, metadata); len(errors) > 0 {
 			allErrs = append(allErrs, errors...)
 		}
+		if ic.Publish == types.InternalPublishingStrategy && !isHostedZonePrivate(zoneOutput) {
+			allErrs = append(allErrs, field.Invalid(zonePath, zoneName, "hosted zone must be private when publish is Internal"))
+		}
 
 		zone = zoneOutput.HostedZone
 	} else {
@@ -298,3 +335,10 @@ func isHostedZoneAssociatedWithVPC(hostedZone *route53.GetHostedZoneOutput, vpcI
 	}
 	return false
 }
+
+func isHostedZonePrivate(hostedZone *route53.GetHostedZoneOutput) bool {
+	if hostedZone.HostedZone == nil || hostedZone.HostedZone.Config == nil {
+		return false
+	}
+	return aws.BoolValue(hostedZone.HostedZone.Config.PrivateZone)
+}
@@ -0,0 +1,190 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	awstypes "github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+var (
+	privateSubnets = map[string]Subnet{
+		"private-a": {Zone: "us-east-1a"},
+		"private-b": {Zone: "us-east-1b"},
+	}
+	publicSubnets = map[string]Subnet{
+		"public-a": {Zone: "us-east-1a"},
+		"public-b": {Zone: "us-east-1b"},
+	}
+)
+
+// metadata returns the metadata of the subnets as it would be looked up in
+// vpc-1.
+func metadata(subnets ...string) *Metadata {
+	meta := &Metadata{
+		Region:         "us-east-1",
+		Subnets:        subnets,
+		vpc:            "vpc-1",
+		privateSubnets: map[string]Subnet{},
+		publicSubnets:  map[string]Subnet{},
+	}
+	for _, id := range subnets {
+		if subnet, ok := privateSubnets[id]; ok {
+			meta.privateSubnets[id] = subnet
+		}
+		if subnet, ok := publicSubnets[id]; ok {
+			meta.publicSubnets[id] = subnet
+		}
+	}
+	return meta
+}
+
+func TestValidateSubnets(t *testing.T) {
+	cases := []struct {
+		name          string
+		subnets       []string
+		publish       types.PublishingStrategy
+		expectedError string
+	}{
+		{
+			name:    "external with public subnets",
+			subnets: []string{"private-a", "private-b", "public-a", "public-b"},
+			publish: types.ExternalPublishingStrategy,
+		},
+		{
+			name:    "internal with private subnets",
+			subnets: []string{"private-a", "private-b"},
+			publish: types.InternalPublishingStrategy,
+		},
+		{
+			name:          "internal with public subnets",
+			subnets:       []string{"private-a", "public-b", "private-b", "public-a"},
+			publish:       types.InternalPublishingStrategy,
+			expectedError: `^\[subnets\[3\]: Invalid value: "public-a": public subnets may not be used when publish is Internal, subnets\[1\]: Invalid value: "public-b": public subnets may not be used when publish is Internal\]$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSubnets(context.TODO(), metadata(tc.subnets...), field.NewPath("subnets"), tc.subnets, nil, tc.publish).ToAggregate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
+
+type fakeRoute53 struct {
+	zones map[string]*route53.GetHostedZoneOutput
+}
+
+func (f *fakeRoute53) GetHostedZone(hostedZone string) (*route53.GetHostedZoneOutput, error) {
+	zone, ok := f.zones[hostedZone]
+	if !ok {
+		return nil, errors.Errorf("could not get hosted zone: %s", hostedZone)
+	}
+	return zone, nil
+}
+
+func (f *fakeRoute53) ValidateZoneRecords(zone *route53.HostedZone, zoneName string, zonePath *field.Path, ic *types.InstallConfig) field.ErrorList {
+	return nil
+}
+
+func (f *fakeRoute53) GetBaseDomain(baseDomainName string) (*route53.HostedZone, error) {
+	return nil, errors.Errorf("no public zone for %s", baseDomainName)
+}
+
+func (f *fakeRoute53) GetSubDomainDNSRecords(hostedZone *route53.HostedZone, ic *types.InstallConfig) ([]string, error) {
+	return nil, nil
+}
+
+func hostedZone(private bool, vpcs ...string) *route53.GetHostedZoneOutput {
+	zone := &route53.GetHostedZoneOutput{
+		HostedZone: &route53.HostedZone{
+			Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(private)},
+		},
+	}
+	for _, vpc := range vpcs {
+		zone.VPCs = append(zone.VPCs, &route53.VPC{VPCId: aws.String(vpc)})
+	}
+	return zone
+}
+
+func TestValidateForProvisioning(t *testing.T) {
+	client := &fakeRoute53{
+		zones: map[string]*route53.GetHostedZoneOutput{
+			"private":   hostedZone(true, "vpc-1"),
+			"other-vpc": hostedZone(true, "vpc-2"),
+			"public":    hostedZone(false),
+		},
+	}
+
+	cases := []struct {
+		name          string
+		publish       types.PublishingStrategy
+		hostedZone    string
+		expectedError string
+	}{
+		{
+			name:    "internal creates a private zone",
+			publish: types.InternalPublishingStrategy,
+		},
+		{
+			name:       "internal with a private zone",
+			publish:    types.InternalPublishingStrategy,
+			hostedZone: "private",
+		},
+		{
+			name:          "internal with a zone of another VPC",
+			publish:       types.InternalPublishingStrategy,
+			hostedZone:    "other-vpc",
+			expectedError: `^aws.hostedZone: Invalid value: "other-vpc": hosted zone is not associated with the VPC$`,
+		},
+		{
+			name:          "internal with a public zone",
+			publish:       types.InternalPublishingStrategy,
+			hostedZone:    "public",
+			expectedError: `^\[aws.hostedZone: Invalid value: "public": hosted zone is not associated with the VPC, aws.hostedZone: Invalid value: "public": hosted zone must be private when publish is Internal\]$`,
+		},
+		{
+			name:          "internal with a missing zone",
+			publish:       types.InternalPublishingStrategy,
+			hostedZone:    "missing",
+			expectedError: `^aws.hostedZone: Invalid value: "missing": cannot find hosted zone$`,
+		},
+		{
+			name:          "external requires a public base domain",
+			publish:       types.ExternalPublishingStrategy,
+			expectedError: `^baseDomain: Invalid value: "example.com": cannot find base domain$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &types.InstallConfig{
+				BaseDomain: "example.com",
+				Publish:    tc.publish,
+				Platform: types.Platform{
+					AWS: &awstypes.Platform{
+						Region:     "us-east-1",
+						Subnets:    []string{"private-a", "private-b"},
+						HostedZone: tc.hostedZone,
+					},
+				},
+			}
+			err := ValidateForProvisioning(client, ic, metadata(ic.AWS.Subnets...))
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
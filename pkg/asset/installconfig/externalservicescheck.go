@@ -0,0 +1,109 @@
+package installconfig
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+const (
+	// postgresSSLRequestCode is the code of the SSLRequest message of the
+	// PostgreSQL protocol.
+	postgresSSLRequestCode = 80877103
+
+	postgresCheckTimeout = 10 * time.Second
+)
+
+// CheckExternalServices enables the online check of the external services.
+var CheckExternalServices bool
+
+// ExternalServicesCheck is an asset that checks that the external services of
+// the install config can be reached. The check only runs when
+// CheckExternalServices is set.
+type ExternalServicesCheck struct {
+}
+
+var _ asset.Asset = (*ExternalServicesCheck)(nil)
+
+// Dependencies returns the dependencies for ExternalServicesCheck.
+func (a *ExternalServicesCheck) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&InstallConfig{},
+	}
+}
+
+// Generate checks that the PostgreSQL server accepts connections, and SSL
+// connections unless the SSL mode is disable.
+func (a *ExternalServicesCheck) Generate(dependencies asset.Parents) error {
+	ic := &InstallConfig{}
+	dependencies.Get(ic)
+
+	if !CheckExternalServices || ic.Config.TFE == nil || ic.Config.TFE.ExternalServices == nil {
+		return nil
+	}
+
+	postgres := &ic.Config.TFE.ExternalServices.Postgres
+	ctx, cancel := context.WithTimeout(context.TODO(), postgresCheckTimeout)
+	defer cancel()
+	if err := checkPostgres(ctx, postgres.Address(), postgres.SSLMode); err != nil {
+		return field.ErrorList{
+			field.Invalid(field.NewPath("tfe", "externalServices", "postgres", "host"), postgres.Host, err.Error()),
+		}.ToAggregate()
+	}
+	logrus.Infof("PostgreSQL server %s is reachable", postgres.Address())
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *ExternalServicesCheck) Name() string {
+	return "External Services Check"
+}
+
+// checkPostgres connects to the PostgreSQL server at address. Unless the SSL
+// mode is disable, it also sends an SSLRequest and checks that the server
+// accepts SSL. The certificate of the server is not verified.
+func checkPostgres(ctx context.Context, address string, sslMode types.PostgresSSLMode) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect")
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if sslMode == types.PostgresSSLModeDisable {
+		return nil
+	}
+
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return errors.Wrap(err, "failed to send the SSL request")
+	}
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return errors.Wrap(err, "failed to read the response to the SSL request")
+	}
+	switch response[0] {
+	case 'S':
+		return nil
+	case 'N':
+		return errors.Errorf("the server does not accept SSL connections, which sslMode %s requires", sslMode)
+	default:
+		return errors.Errorf("unexpected response %q to the SSL request, the server may not be PostgreSQL", response[0])
+	}
+}
@@ -0,0 +1,106 @@
+package installconfig
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+// fakePostgres listens on a local port and answers every SSLRequest with
+// response. It returns the address of the listener.
+func fakePostgres(t *testing.T, response byte) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				request := make([]byte, 8)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				if binary.BigEndian.Uint32(request[4:8]) != postgresSSLRequestCode {
+					return
+				}
+				conn.Write([]byte{response})
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// closedAddress returns the address of a local port that nothing listens on.
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func TestCheckPostgres(t *testing.T) {
+	cases := []struct {
+		name          string
+		address       string
+		sslMode       types.PostgresSSLMode
+		expectedError string
+	}{
+		{
+			name:    "accepts SSL",
+			address: fakePostgres(t, 'S'),
+			sslMode: types.PostgresSSLModeRequire,
+		},
+		{
+			name:          "refuses SSL",
+			address:       fakePostgres(t, 'N'),
+			sslMode:       types.PostgresSSLModeVerifyFull,
+			expectedError: `^the server does not accept SSL connections, which sslMode verify-full requires$`,
+		},
+		{
+			name:    "SSL disabled",
+			address: fakePostgres(t, 'N'),
+			sslMode: types.PostgresSSLModeDisable,
+		},
+		{
+			name:          "not PostgreSQL",
+			address:       fakePostgres(t, 'H'),
+			sslMode:       types.PostgresSSLModeRequire,
+			expectedError: `^unexpected response 'H' to the SSL request, the server may not be PostgreSQL$`,
+		},
+		{
+			name:          "nothing listening",
+			address:       closedAddress(t),
+			sslMode:       types.PostgresSSLModeRequire,
+			expectedError: `^failed to connect: .*connection refused$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := checkPostgres(ctx, tc.address, tc.sslMode)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
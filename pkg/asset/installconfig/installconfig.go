@@ -2,7 +2,10 @@ package installconfig
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -13,23 +16,59 @@ import (
 
 	"github.com/bailey84j/terraform_installer/pkg/asset"
 	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig/aws"
+	"github.com/bailey84j/terraform_installer/pkg/lint"
+	"github.com/bailey84j/terraform_installer/pkg/secrets"
 	"github.com/bailey84j/terraform_installer/pkg/types"
 	"github.com/bailey84j/terraform_installer/pkg/types/conversion"
 	"github.com/bailey84j/terraform_installer/pkg/types/defaults"
+	"github.com/bailey84j/terraform_installer/pkg/types/overrides"
 	"github.com/bailey84j/terraform_installer/pkg/types/validation"
 	//icazure "github.com/bailey84j/terraform_installer/pkg/asset/installconfig/azure"
 )
 
 const (
-	installConfigFilename = "install-config.yaml"
+	// InstallConfigFilename is the name of the install config file in the
+	// assets directory.
+	InstallConfigFilename = "install-config.yaml"
+
+	// InstallConfigOverlayPattern matches the overlay files that are merged,
+	// in lexical order, over the install config in the assets directory.
+	InstallConfigOverlayPattern = "install-config.d/*.yaml"
 )
 
+// Overrides are "path=value" settings, e.g. from the --set flag, applied to
+// the loaded install config. They take precedence over the overrides.EnvPrefix
+// environment variables.
+var Overrides []string
+
+// SecretResolver resolves the secret references in the sensitive fields of
+// the install config.
+var SecretResolver secrets.Resolver = secrets.DefaultResolver()
+
 // InstallConfig generates the install-config.yaml file.
 type InstallConfig struct {
 	Config *types.InstallConfig `json:"config"`
 	File   *asset.File          `json:"file"`
 	AWS    *aws.Metadata        `json:"aws,omitempty"`
 	//Azure  *icazure.Metadata    `json:"azure,omitempty"`
+
+	// secretFields are the fields of Config that were resolved from secret
+	// references. The references, not the secrets, are written to the
+	// install-config.yaml file and to the state file.
+	secretFields []secrets.ResolvedField
+
+	// unresolved is true when the install config was restored from the
+	// state file and the sensitive fields of Config that held secret
+	// references are still empty. See ResolveSecrets.
+	unresolved bool
+
+	// findings are the lint findings about the install config, when it was
+	// generated or read from disk in this run.
+	findings lint.Findings
+
+	// sshPrivateKey is the private key of the SSH key pair generated by the
+	// SSH key wizard, if any. It is handed to SSHKeyPair and never stored.
+	sshPrivateKey []byte
 }
 
 var _ asset.WritableAsset = (*InstallConfig)(nil)
@@ -42,7 +81,7 @@ func (a *InstallConfig) Dependencies() []asset.Asset {
 		&baseDomain{},
 		&clusterName{},
 		&networking{},
-		&pullSecret{},
+		&licenceFile{},
 		&platform{},
 	}
 }
@@ -52,16 +91,16 @@ func (a *InstallConfig) Generate(parents asset.Parents) error {
 	sshPublicKey := &sshPublicKey{}
 	baseDomain := &baseDomain{}
 	clusterName := &clusterName{}
-	//networking := &networking{}
-	pullSecret := &pullSecret{}
+	networking := &networking{}
+	licenceFile := &licenceFile{}
 	platform := &platform{}
 
 	parents.Get(
 		sshPublicKey,
 		baseDomain,
 		clusterName,
-		//networking,
-		pullSecret,
+		networking,
+		licenceFile,
 		platform,
 	)
 
@@ -75,8 +114,12 @@ func (a *InstallConfig) Generate(parents asset.Parents) error {
 		},
 		SSHKey:     sshPublicKey.Key,
 		BaseDomain: baseDomain.BaseDomain,
-		Licence:    pullSecret.PullSecret,
+		Networking: &types.Networking{
+			MachineNetwork: networking.machineNetwork,
+		},
+		LicencePath: licenceFile.Path,
 	}
+	a.sshPrivateKey = sshPublicKey.privateKey
 
 	logrus.Debugf("Trace Me - config - %+v", a.Config)
 	//a.Config.AlibabaCloud = platform.AlibabaCloud
@@ -99,7 +142,9 @@ func (a *InstallConfig) Generate(parents asset.Parents) error {
 	return a.finish("")
 }
 
-func (a *InstallConfig) finish(filename string) error {
+// finish defaults, validates and lints the install config, along with the
+// lint findings found while reading it, and sets its file.
+func (a *InstallConfig) finish(filename string, found ...lint.Finding) error {
 	defaults.SetInstallConfigDefaults(a.Config)
 
 	if a.Config.AWS != nil {
@@ -131,12 +176,19 @@ func (a *InstallConfig) finish(filename string) error {
 		return err
 	}
 
-	data, err := yaml.Marshal(a.Config)
+	if err := a.lint(found); err != nil {
+		return err
+	}
+
+	data, err := a.marshalConfig()
 	if err != nil {
 		return errors.Wrap(err, "failed to Marshal InstallConfig")
 	}
+	if data, err = yaml.JSONToYAML(data); err != nil {
+		return errors.Wrap(err, "failed to Marshal InstallConfig")
+	}
 	a.File = &asset.File{
-		Filename: installConfigFilename,
+		Filename: InstallConfigFilename,
 		Data:     data,
 	}
 	return nil
@@ -207,40 +259,303 @@ func (a *InstallConfig) Files() []*asset.File {
 	return []*asset.File{}
 }
 
+// PurgePolicy returns what the asset store does with the input files once
+// they have been consumed.
+func (a *InstallConfig) PurgePolicy() types.PurgePolicy {
+	if a.Config == nil {
+		return ""
+	}
+	return a.Config.PurgePolicy
+}
+
 // Load returns the installconfig from disk.
 func (a *InstallConfig) Load(f asset.FileFetcher) (found bool, err error) {
-	file, err := f.FetchByName(installConfigFilename)
+	data, layered, err := render(f)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(errors.Cause(err)) {
 			return false, nil
 		}
 		return false, errors.Wrap(err, asset.InstallConfigError)
 	}
 
+	if data, err = yaml.YAMLToJSON(data); err != nil {
+		err = errors.Wrapf(err, "failed to unmarshal %s", InstallConfigFilename)
+		return false, errors.Wrap(err, asset.InstallConfigError)
+	}
+	if data, a.secretFields, err = resolveSecrets(data); err != nil {
+		return false, errors.Wrap(err, asset.InstallConfigError)
+	}
+
+	var loadFindings lint.Findings
 	config := &types.InstallConfig{}
-	if err := yaml.UnmarshalStrict(file.Data, config, yaml.DisallowUnknownFields); err != nil {
-		err = errors.Wrapf(err, "failed to unmarshal %s", installConfigFilename)
+	if err := yaml.UnmarshalStrict(data, config, yaml.DisallowUnknownFields); err != nil {
+		err = errors.Wrapf(err, "failed to unmarshal %s", InstallConfigFilename)
 		if !strings.Contains(err.Error(), "unknown field") {
 			return false, errors.Wrap(err, asset.InstallConfigError)
 		}
-		err = errors.Wrapf(err, "failed to parse first occurence of unknown field")
-		logrus.Warnf(err.Error())
+		loadFindings = append(loadFindings, unknownFieldRule.Found(nil, "%v", errors.Cause(err)))
 		logrus.Info("Attempting to unmarshal while ignoring unknown keys because strict unmarshaling failed")
-		if err = yaml.UnmarshalStrict(file.Data, config); err != nil {
-			err = errors.Wrapf(err, "failed to unmarshal %s", installConfigFilename)
+		if err = yaml.Unmarshal(data, config); err != nil {
+			err = errors.Wrapf(err, "failed to unmarshal %s", InstallConfigFilename)
 			return false, errors.Wrap(err, asset.InstallConfigError)
 		}
 	}
 	a.Config = config
 
+	// Only references to an inline licence are written to the state file,
+	// so that the licence itself never is.
+	if a.Config.Licence != "" && a.secretReference("licence") == nil {
+		err = errors.Errorf("licence in %s must be a secret reference (fromFile, fromEnv or fromVault); use licencePath for a licence file", InstallConfigFilename)
+		return false, errors.Wrap(err, asset.InstallConfigError)
+	}
+
 	// Upconvert any deprecated fields
 	if err := conversion.ConvertInstallConfig(a.Config); err != nil {
 		return false, errors.Wrap(errors.Wrap(err, "failed to upconvert install config"), asset.InstallConfigError)
 	}
 
-	err = a.finish(installConfigFilename)
+	err = a.finish(InstallConfigFilename, loadFindings...)
 	if err != nil {
 		return false, errors.Wrap(err, asset.InstallConfigError)
 	}
+
+	if layered {
+		logLayeredConfig(a.Config)
+	}
 	return true, nil
 }
+
+// MarshalJSON marshals the install config for the state file, with the
+// secret references in place of the secrets they were resolved to.
+func (a *InstallConfig) MarshalJSON() ([]byte, error) {
+	type plain InstallConfig
+	data, err := json.Marshal((*plain)(a))
+	if err != nil || a.Config == nil || len(a.secretFields) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields["config"], err = a.marshalConfig(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON unmarshals the install config from the state file. Its secret
+// references are kept and the fields that held them are left empty until
+// ResolveSecrets is called, so that loading the state file does not read
+// every secret.
+func (a *InstallConfig) UnmarshalJSON(data []byte) error {
+	type plain InstallConfig
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if config, ok := fields["config"]; ok && string(config) != "null" {
+		config, secretFields, err := secrets.Resolve(context.TODO(), unresolvedResolver{}, reflect.TypeOf(types.InstallConfig{}), config)
+		if err != nil {
+			return err
+		}
+		fields["config"] = config
+		a.secretFields = secretFields
+		a.unresolved = len(secretFields) > 0
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// ResolveSecrets resolves the secret references of an install config restored
+// from the state file into Config. It must be called before the sensitive
+// fields of Config are read. It does nothing when the secrets are already
+// resolved, as they are when the install config is generated or loaded from
+// disk.
+func (a *InstallConfig) ResolveSecrets() error {
+	if !a.unresolved {
+		return nil
+	}
+	data, err := a.marshalConfig()
+	if err != nil {
+		return err
+	}
+	data, secretFields, err := resolveSecrets(data)
+	if err != nil {
+		return errors.Wrap(err, asset.InstallConfigError)
+	}
+	if err := json.Unmarshal(data, a.Config); err != nil {
+		return err
+	}
+	a.secretFields, a.unresolved = secretFields, false
+	return nil
+}
+
+// marshalConfig returns the install config as JSON, with the secret
+// references in place of the secrets they were resolved to.
+func (a *InstallConfig) marshalConfig() ([]byte, error) {
+	data, err := json.Marshal(a.Config)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.Restore(data, a.secretFields)
+}
+
+// secretReference returns the secret reference that the field of Config at
+// the given JSON path was resolved from, or nil if the field held its value.
+func (a *InstallConfig) secretReference(path ...string) *secrets.Reference {
+	for _, f := range a.secretFields {
+		if reflect.DeepEqual(f.Path, path) {
+			ref := f.Reference
+			return &ref
+		}
+	}
+	return nil
+}
+
+// resolveSecrets resolves the secret references in the sensitive fields of
+// the install config in data, which must be JSON.
+func resolveSecrets(data []byte) ([]byte, []secrets.ResolvedField, error) {
+	data, fields, err := secrets.Resolve(context.TODO(), SecretResolver, reflect.TypeOf(types.InstallConfig{}), data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to resolve secrets")
+	}
+	for _, f := range fields {
+		logrus.Debugf("Resolved secret reference in %s", strings.Join(f.Path, "."))
+	}
+	return data, fields, nil
+}
+
+// unresolvedResolver resolves every secret reference to an empty string, so
+// that the references can be kept until they are resolved.
+type unresolvedResolver struct{}
+
+func (unresolvedResolver) Resolve(context.Context, *secrets.Reference) (string, error) {
+	return "", nil
+}
+
+// Render returns the install config in the assets directory with its overlays
+// and overrides applied, before it is validated and defaults are set. Its
+// sensitive fields are redacted unless showSecrets is set. The error
+// satisfies os.IsNotExist, after errors.Cause, if there is no install config.
+func Render(f asset.FileFetcher, showSecrets bool) ([]byte, error) {
+	data, _, err := render(f)
+	if err != nil {
+		return nil, err
+	}
+	if !showSecrets {
+		if data, err = overrides.RedactDocument(data); err != nil {
+			return nil, errors.Wrapf(err, "failed to redact %s", InstallConfigFilename)
+		}
+	}
+	return yaml.JSONToYAML(data)
+}
+
+// render returns the install config with the overlays and overrides applied.
+// It reports whether there were any.
+func render(f asset.FileFetcher) ([]byte, bool, error) {
+	file, err := f.FetchByName(InstallConfigFilename)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			warnUnappliedOverlays(f)
+		}
+		return nil, false, err
+	}
+
+	data, overlaid, err := applyOverlays(f, file.Data)
+	if err != nil {
+		return nil, false, err
+	}
+	data, overridden, err := applyOverrides(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, overlaid || overridden, nil
+}
+
+// warnUnappliedOverlays warns about the overlays left in the assets directory
+// when there is no install config to merge them over, which is the case once
+// the install config was consumed into the state file.
+func warnUnappliedOverlays(f asset.FileFetcher) {
+	files, err := f.FetchByPattern(InstallConfigOverlayPattern)
+	if err != nil || len(files) == 0 {
+		return
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Filename)
+	}
+	sort.Strings(names)
+	logrus.Warnf("Ignoring the install config overlays %s because there is no %s to merge them over; changes to them are not applied to an install config that was already consumed", strings.Join(names, ", "), InstallConfigFilename)
+}
+
+// applyOverlays merges the overlay files over the install config in data. It
+// reports whether there were any.
+func applyOverlays(f asset.FileFetcher, data []byte) ([]byte, bool, error) {
+	files, err := f.FetchByPattern(InstallConfigOverlayPattern)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to read install config overlays")
+	}
+	if len(files) == 0 {
+		return data, false, nil
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+	overlays := make([]overrides.Overlay, 0, len(files))
+	for _, file := range files {
+		logrus.Debugf("Merging overlay %s over %s", file.Filename, InstallConfigFilename)
+		overlays = append(overlays, overrides.Overlay{Filename: file.Filename, Data: file.Data})
+	}
+	data, err = overrides.Merge(data, overlays)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to merge overlays over %s", InstallConfigFilename)
+	}
+	return data, true, nil
+}
+
+// applyOverrides applies the environment and command-line overrides to the
+// install config in data. It reports whether there were any.
+func applyOverrides(data []byte) ([]byte, bool, error) {
+	all, err := overrides.FromEnv(os.Environ())
+	if err != nil {
+		return nil, false, err
+	}
+	for _, s := range Overrides {
+		o, err := overrides.Parse(s)
+		if err != nil {
+			return nil, false, err
+		}
+		all = append(all, o)
+	}
+	if len(all) == 0 {
+		return data, false, nil
+	}
+
+	for _, o := range all {
+		logrus.Debugf("Overriding %s of %s from %s", strings.Join(o.Path, "."), InstallConfigFilename, o.Source)
+	}
+	data, err = overrides.Apply(data, all)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to override %s", InstallConfigFilename)
+	}
+	return data, true, nil
+}
+
+// logLayeredConfig logs the install config after the overlays and overrides
+// were applied, with sensitive fields redacted.
+func logLayeredConfig(config *types.InstallConfig) {
+	redacted, err := overrides.Redact(config)
+	if err != nil {
+		logrus.Debugf("Failed to redact the merged install config: %v", err)
+		return
+	}
+	data, err := yaml.Marshal(redacted)
+	if err != nil {
+		logrus.Debugf("Failed to marshal the merged install config: %v", err)
+		return
+	}
+	logrus.Debugf("Install config after overlays and overrides:\n%s", data)
+}
@@ -0,0 +1,48 @@
+package installconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/secrets"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+func TestInstallConfigStateFile(t *testing.T) {
+	installConfig := &InstallConfig{
+		Config: &types.InstallConfig{
+			BaseDomain: "example.com",
+			TFE:        &types.TFE{Hostname: "tfe.example.com", AdminPassword: "admin-password"},
+		},
+		secretFields: []secrets.ResolvedField{
+			{Path: []string{"tfe", "adminPassword"}, Reference: secrets.Reference{FromEnv: "TEST_TFE_ADMIN_PASSWORD"}},
+		},
+	}
+	data, err := json.Marshal(installConfig)
+	if !assert.NoError(t, err, "unexpected error marshaling the install config") {
+		return
+	}
+	assert.NotContains(t, string(data), "admin-password", "secret written to the state file")
+
+	// Loading the state file must not resolve the references.
+	restored := &InstallConfig{}
+	if !assert.NoError(t, json.Unmarshal(data, restored), "unexpected error unmarshaling the install config") {
+		return
+	}
+	assert.Equal(t, "tfe.example.com", restored.Config.TFE.Hostname)
+	assert.Empty(t, restored.Config.TFE.AdminPassword)
+
+	again, err := json.Marshal(restored)
+	if assert.NoError(t, err, "unexpected error marshaling the restored install config") {
+		assert.JSONEq(t, string(data), string(again), "references not kept in the state file")
+	}
+
+	assert.Regexp(t, `failed to resolve tfe\.adminPassword: `, restored.ResolveSecrets())
+
+	t.Setenv("TEST_TFE_ADMIN_PASSWORD", "admin-password")
+	if assert.NoError(t, restored.ResolveSecrets(), "unexpected error resolving secrets") {
+		assert.Equal(t, "admin-password", restored.Config.TFE.AdminPassword)
+	}
+}
@@ -0,0 +1,138 @@
+package installconfig
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/licence"
+	"github.com/bailey84j/terraform_installer/pkg/secrets"
+)
+
+// Licence is the Terraform Enterprise licence of the install config. Only
+// where the licence is read from and the parsed metadata of the licence are
+// kept in the state file, never the licence itself.
+type Licence struct {
+	// Path is the absolute path of the licence file. It is empty when the
+	// licence is inline in the install config.
+	Path string `json:"path,omitempty"`
+
+	// Reference is the secret reference of the inline licence of the
+	// install config.
+	Reference *secrets.Reference `json:"reference,omitempty"`
+
+	// Info is the metadata of the licence.
+	Info *licence.Licence `json:"info"`
+
+	data []byte
+}
+
+var _ asset.Asset = (*Licence)(nil)
+
+// Dependencies returns the dependencies of the licence.
+func (a *Licence) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&InstallConfig{},
+	}
+}
+
+// Generate reads and checks the licence referenced by the install config. It
+// fails if the licence has expired and warns if it expires soon.
+func (a *Licence) Generate(parents asset.Parents) error {
+	installConfig := &InstallConfig{}
+	parents.Get(installConfig)
+	if err := installConfig.ResolveSecrets(); err != nil {
+		return err
+	}
+
+	*a = Licence{}
+	if path := installConfig.Config.LicencePath; path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve %s", path)
+		}
+		data, err := ioutil.ReadFile(abs)
+		if err != nil {
+			return errors.Wrap(err, "failed to read licence file")
+		}
+		info, err := licence.Parse(data)
+		if err != nil {
+			return errors.Wrapf(err, "invalid licence file %s", abs)
+		}
+		a.Path, a.Info, a.data = abs, info, data
+	} else {
+		ref := installConfig.secretReference("licence")
+		if ref == nil {
+			return errors.Errorf("licence in %s must be a secret reference", InstallConfigFilename)
+		}
+		data := []byte(installConfig.Config.Licence)
+		info, err := licence.Parse(data)
+		if err != nil {
+			return errors.Wrapf(err, "invalid licence in %s", InstallConfigFilename)
+		}
+		a.Reference, a.Info, a.data = ref, info, data
+	}
+
+	if a.Info.Opaque {
+		logrus.Warnf("Cannot read the metadata of licence %s; Terraform Enterprise checks it when it starts", a.Info.ID)
+		return nil
+	}
+	now := time.Now()
+	if a.Info.Expired(now) {
+		return errors.Errorf("licence %s expired on %s", a.Info.ID, a.Info.ExpiresAt.Format("2006-01-02"))
+	}
+	if a.Info.ExpiresSoon(now) {
+		logrus.Warnf("Licence %s expires on %s, in %d days", a.Info.ID, a.Info.ExpiresAt.Format("2006-01-02"), int(a.Info.ExpiresAt.Sub(now).Hours()/24))
+	}
+	logrus.Infof("Using licence %s for %s, valid until %s for %s", a.Info.ID, a.Info.Customer, a.Info.ExpiresAt.Format("2006-01-02"), a.Info.CapacityString())
+	return nil
+}
+
+// Data returns the licence. After the asset is loaded from the state file, the
+// licence is read again from Path or resolved again from Reference, and must
+// still be the same licence.
+func (a *Licence) Data() ([]byte, error) {
+	if a.data != nil {
+		return a.data, nil
+	}
+
+	var data []byte
+	var source string
+	switch {
+	case a.Path != "":
+		var err error
+		if data, err = ioutil.ReadFile(a.Path); err != nil {
+			return nil, errors.Wrap(err, "failed to read licence file")
+		}
+		source = "licence file " + a.Path
+	case a.Reference != nil:
+		value, err := SecretResolver.Resolve(context.TODO(), a.Reference)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve the licence")
+		}
+		data = []byte(value)
+		source = "licence in " + InstallConfigFilename
+	default:
+		return nil, errors.New("the licence has neither a path nor a secret reference; regenerate the install config")
+	}
+
+	info, err := licence.Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", source)
+	}
+	if a.Info != nil && info.ID != a.Info.ID {
+		return nil, errors.Errorf("%s changed from licence %s to %s", source, a.Info.ID, info.ID)
+	}
+	a.data = data
+	return data, nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *Licence) Name() string {
+	return "Licence"
+}
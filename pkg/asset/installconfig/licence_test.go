@@ -0,0 +1,63 @@
+package installconfig
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/licence"
+	"github.com/bailey84j/terraform_installer/pkg/secrets"
+)
+
+const testLicence = `{"license_id":"lic-123","customer":"Example Corp","expires_at":"2027-01-01T00:00:00Z"}`
+
+func TestLicenceData(t *testing.T) {
+	t.Setenv("TEST_TFE_LICENCE", testLicence)
+	path := filepath.Join(t.TempDir(), "tfe-licence.rli")
+	if err := ioutil.WriteFile(path, []byte(testLicence), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		licence       *Licence
+		expectedError string
+	}{
+		{
+			name:    "licence file",
+			licence: &Licence{Path: path, Info: &licence.Licence{ID: "lic-123"}},
+		},
+		{
+			name:    "secret reference",
+			licence: &Licence{Reference: &secrets.Reference{FromEnv: "TEST_TFE_LICENCE"}, Info: &licence.Licence{ID: "lic-123"}},
+		},
+		{
+			name:          "changed licence",
+			licence:       &Licence{Reference: &secrets.Reference{FromEnv: "TEST_TFE_LICENCE"}, Info: &licence.Licence{ID: "lic-456"}},
+			expectedError: `^licence in install-config.yaml changed from licence lic-456 to lic-123$`,
+		},
+		{
+			name:          "missing licence file",
+			licence:       &Licence{Path: filepath.Join(t.TempDir(), "missing.rli"), Info: &licence.Licence{ID: "lic-123"}},
+			expectedError: `^failed to read licence file: `,
+		},
+		{
+			name:          "no source",
+			licence:       &Licence{Info: &licence.Licence{ID: "lic-123"}},
+			expectedError: `^the licence has neither a path nor a secret reference; regenerate the install config$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.licence.Data()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, testLicence, string(data))
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package installconfig
+
+import (
+	"path/filepath"
+
+	survey "github.com/AlecAivazis/survey/v2"
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/licence"
+)
+
+type licenceFile struct {
+	Path string
+}
+
+var _ asset.Asset = (*licenceFile)(nil)
+
+// Dependencies returns no dependencies.
+func (a *licenceFile) Dependencies() []asset.Asset {
+	return []asset.Asset{}
+}
+
+// Generate queries for the licence file from the user.
+func (a *licenceFile) Generate(asset.Parents) error {
+	var path string
+	if err := survey.Ask([]*survey.Question{
+		{
+			Prompt: &survey.Input{
+				Message: "Licence File",
+				Help:    "The path of the Terraform Enterprise licence file (.rli). Only the path is stored in the install config.",
+			},
+			Validate: survey.ComposeValidators(survey.Required, func(ans interface{}) error {
+				_, err := licence.ParseFile(ans.(string))
+				return err
+			}),
+		},
+	}, &path); err != nil {
+		return errors.Wrap(err, "failed UserInput")
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", path)
+	}
+	a.Path = abs
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *licenceFile) Name() string {
+	return "Licence File"
+}
@@ -0,0 +1,54 @@
+package installconfig
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig/aws"
+	"github.com/bailey84j/terraform_installer/pkg/lint"
+)
+
+var (
+	unknownFieldRule = lint.Register("unknown-field", lint.SeverityWarning,
+		"The install config has a field the installer does not know, which is ignored.")
+	unknownLintRule = lint.Register("unknown-lint-rule", lint.SeverityWarning,
+		"A suppressed lint rule does not exist.")
+)
+
+// Findings returns the lint findings about the install config that are not
+// suppressed. They are only known when the install config was generated or
+// read from disk in this run.
+func (a *InstallConfig) Findings() lint.Findings {
+	return a.findings
+}
+
+// suppress returns the findings that the install config does not suppress.
+func (a *InstallConfig) suppress(found lint.Findings) lint.Findings {
+	if a.Config.Lint == nil {
+		return found
+	}
+	return found.Suppress(a.Config.Lint.Suppress)
+}
+
+// lint adds the lint findings about the install config to found, drops the
+// suppressed ones, and fails if any of the remaining ones is an error.
+func (a *InstallConfig) lint(found lint.Findings) error {
+	if a.Config.Lint != nil {
+		for i, id := range a.Config.Lint.Suppress {
+			if _, ok := lint.Lookup(id); !ok {
+				found = append(found, unknownLintRule.Found(field.NewPath("lint", "suppress").Index(i), "no lint rule has the ID %q", id))
+			}
+		}
+	}
+
+	if a.Config.Platform.AWS != nil {
+		found = append(found, aws.Lint(a.Config)...)
+	}
+
+	// The findings are reported once the command is done, with Findings.
+	a.findings = a.suppress(found)
+	if errs := a.findings.Errors(); len(errs) > 0 {
+		return errors.Errorf("invalid install config: %s", errs.Report())
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package installconfig
 
 import (
 	survey "github.com/AlecAivazis/survey/v2"
+	"github.com/pkg/errors"
 
 	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/ipnet"
 	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/defaults"
+	"github.com/bailey84j/terraform_installer/pkg/validate"
 )
 
 type networking struct {
@@ -24,22 +28,39 @@ func (a *networking) Dependencies() []asset.Asset {
 func (a *networking) Generate(parents asset.Parents) error {
 	platform := &platform{}
 	parents.Get(platform)
+
+	cidr, err := selectMachineNetworkCIDR()
+	if err != nil {
+		return err
+	}
+	a.machineNetwork = []types.MachineNetworkEntry{{CIDR: *cidr}}
 	return nil
 }
 
-func selectMachineNetworkCIDR() (string, error) {
+func selectMachineNetworkCIDR() (*ipnet.IPNet, error) {
 	var selectedCIDR string
 
 	err := survey.Ask([]*survey.Question{
 		{
 			Prompt: &survey.Input{
 				Message: "Machine Network CIDR",
-				Help:    "The IP address pool for machines.",
+				Help:    "The IP address pool for machines. The cluster and service networks must not overlap with it.",
+				Default: defaults.DefaultMachineCIDR.String(),
 			},
+			Validate: survey.ComposeValidators(survey.Required, func(ans interface{}) error {
+				cidr, err := ipnet.ParseCIDR(ans.(string))
+				if err != nil {
+					return err
+				}
+				return validate.SubnetCIDR(&cidr.IPNet)
+			}),
 		},
 	}, &selectedCIDR)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed UserInput")
+	}
 
-	return selectedCIDR, err
+	return ipnet.ParseCIDR(selectedCIDR)
 }
 
 // Name returns the human-friendly name of the asset.
@@ -0,0 +1,62 @@
+package installconfig
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	awsconfig "github.com/bailey84j/terraform_installer/pkg/asset/installconfig/aws"
+	"github.com/bailey84j/terraform_installer/pkg/lint"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+// PlatformProvisionCheck is an asset that validates the install-config platform for
+// any requirements specific for provisioning infrastructure.
+type PlatformProvisionCheck struct {
+	// findings are the lint findings about the install config that need
+	// the platform, when the check ran in this run.
+	findings lint.Findings
+}
+
+var _ asset.Asset = (*PlatformProvisionCheck)(nil)
+
+// Dependencies returns the dependencies for PlatformProvisionCheck
+func (a *PlatformProvisionCheck) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&InstallConfig{},
+	}
+}
+
+// Generate checks that the infrastructure described by the install config can
+// be provisioned on the platform.
+func (a *PlatformProvisionCheck) Generate(dependencies asset.Parents) error {
+	ic := &InstallConfig{}
+	dependencies.Get(ic)
+
+	switch ic.Config.Platform.Name() {
+	case aws.Name:
+		session, err := ic.AWS.Session(context.TODO())
+		if err != nil {
+			return errors.Wrap(err, "failed to create AWS session")
+		}
+		client := awsconfig.NewClient(session)
+		if err := awsconfig.ValidateForProvisioning(client, ic.Config, ic.AWS); err != nil {
+			return err
+		}
+		a.findings = ic.suppress(awsconfig.LintInstanceTypes(context.TODO(), ic.AWS, ic.Config))
+	}
+	return nil
+}
+
+// Findings returns the lint findings about the install config that need the
+// platform and are not suppressed. They are reported along with the findings
+// of InstallConfig.
+func (a *PlatformProvisionCheck) Findings() lint.Findings {
+	return a.findings
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *PlatformProvisionCheck) Name() string {
+	return "Platform Provisioning Check"
+}
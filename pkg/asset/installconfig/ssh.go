@@ -17,11 +17,19 @@ import (
 )
 
 const (
-	noSSHKey = "<none>"
+	noSSHKey       = "<none>"
+	generateSSHKey = "<generate new key>"
 )
 
+// GenerateSSHKey, e.g. from the --generate-ssh-key flag, generates a new SSH
+// key pair instead of asking for an existing public key.
+var GenerateSSHKey bool
+
 type sshPublicKey struct {
 	Key string
+
+	// privateKey is the private key when the key pair was generated.
+	privateKey []byte
 }
 
 var _ asset.Asset = (*sshPublicKey)(nil)
@@ -50,8 +58,13 @@ func readSSHKey(path string) (string, error) {
 // Generate generates the SSH public key asset.
 func (a *sshPublicKey) Generate(asset.Parents) error {
 	logrus.Debugf("Trace Me - In ssh.Generate.()")
+	if GenerateSSHKey {
+		return a.generateKeyPair()
+	}
+
 	pubKeys := map[string]string{
-		noSSHKey: "",
+		noSSHKey:       "",
+		generateSSHKey: "",
 	}
 	home := os.Getenv("HOME")
 	if home != "" {
@@ -68,12 +81,6 @@ func (a *sshPublicKey) Generate(asset.Parents) error {
 		}
 	}
 
-	if len(pubKeys) == 1 {
-		for _, value := range pubKeys {
-			a.Key = value
-		}
-		return nil
-	}
 	logrus.Debugf("Trace Me - In ssh.Generate.() - D1")
 	var paths []string
 	for path := range pubKeys {
@@ -85,7 +92,7 @@ func (a *sshPublicKey) Generate(asset.Parents) error {
 	if err := survey.AskOne(
 		&survey.Select{
 			Message: "SSH Public Key",
-			Help:    "The SSH public key used to access all nodes within the cluster. This is optional.",
+			Help:    "The SSH public key used to access all nodes within the cluster. This is optional. A new key pair can be generated in the auth directory.",
 			Options: paths,
 			Default: noSSHKey,
 		},
@@ -103,10 +110,22 @@ func (a *sshPublicKey) Generate(asset.Parents) error {
 		return errors.Wrap(err, "failed UserInput")
 	}
 
+	if path == generateSSHKey {
+		return a.generateKeyPair()
+	}
 	a.Key = pubKeys[path]
 	return nil
 }
 
+func (a *sshPublicKey) generateKeyPair() error {
+	publicKey, privateKey, err := newSSHKeyPair()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate SSH key pair")
+	}
+	a.Key, a.privateKey = publicKey, privateKey
+	return nil
+}
+
 // Name returns the human-friendly name of the asset.
 func (a sshPublicKey) Name() string {
 	return "SSH Key"
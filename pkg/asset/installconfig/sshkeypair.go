@@ -0,0 +1,167 @@
+package installconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+)
+
+var (
+	// SSHPrivateKeyPath is the path where the generated SSH private key is
+	// stored.
+	SSHPrivateKeyPath = filepath.Join("auth", "id_ed25519")
+
+	// sshPublicKeyPath is the path where the generated SSH public key is
+	// stored.
+	sshPublicKeyPath = SSHPrivateKeyPath + ".pub"
+)
+
+// SSHKeyPair is the SSH key pair generated by the SSH key wizard. Only the
+// public key is kept in the state file. The private key is written to
+// auth/id_ed25519, readable only by its owner, when it is generated and is not
+// stored anywhere else.
+type SSHKeyPair struct {
+	PublicKey string `json:"publicKey,omitempty"`
+
+	privateKey []byte
+}
+
+var _ asset.WritableAsset = (*SSHKeyPair)(nil)
+var _ asset.FileWriter = (*SSHKeyPair)(nil)
+
+// Dependencies returns the dependencies of the key pair.
+func (a *SSHKeyPair) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&InstallConfig{},
+	}
+}
+
+// Generate takes the key pair generated for the install config, if any.
+func (a *SSHKeyPair) Generate(parents asset.Parents) error {
+	installConfig := &InstallConfig{}
+	parents.Get(installConfig)
+
+	*a = SSHKeyPair{}
+	if installConfig.sshPrivateKey != nil {
+		a.PublicKey = installConfig.Config.SSHKey
+		a.privateKey = installConfig.sshPrivateKey
+	}
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *SSHKeyPair) Name() string {
+	return "SSH Key Pair"
+}
+
+// Files returns the key files when the key pair was generated in this run.
+func (a *SSHKeyPair) Files() []*asset.File {
+	if a.privateKey == nil {
+		return []*asset.File{}
+	}
+	return []*asset.File{
+		{Filename: SSHPrivateKeyPath, Data: a.privateKey},
+		{Filename: sshPublicKeyPath, Data: []byte(a.PublicKey)},
+	}
+}
+
+// Load never loads the key pair from disk, so that the key files are not
+// consumed by later targets.
+func (a *SSHKeyPair) Load(asset.FileFetcher) (bool, error) {
+	return false, nil
+}
+
+// PersistToFile writes the key files with the permissions ssh expects.
+func (a *SSHKeyPair) PersistToFile(directory string) error {
+	if a.privateKey == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(directory, filepath.Dir(SSHPrivateKeyPath)), 0750); err != nil {
+		return errors.Wrap(err, "failed to create dir")
+	}
+	if err := asset.WriteFileAtomic(filepath.Join(directory, SSHPrivateKeyPath), a.privateKey, 0600); err != nil {
+		return errors.Wrap(err, "failed to write SSH private key")
+	}
+	if err := asset.WriteFileAtomic(filepath.Join(directory, sshPublicKeyPath), []byte(a.PublicKey), 0644); err != nil {
+		return errors.Wrap(err, "failed to write SSH public key")
+	}
+	return nil
+}
+
+// newSSHKeyPair generates an ed25519 key pair. It returns the public key in
+// the authorized_keys format and the private key in the OpenSSH format.
+func newSSHKeyPair() (string, []byte, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", nil, err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return "", nil, err
+	}
+	privateKey, err := marshalED25519PrivateKey(sshPublic, private)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(ssh.MarshalAuthorizedKey(sshPublic)), privateKey, nil
+}
+
+// marshalED25519PrivateKey encodes an unencrypted ed25519 private key in the
+// openssh-key-v1 format described in PROTOCOL.key of OpenSSH.
+func marshalED25519PrivateKey(public ssh.PublicKey, private ed25519.PrivateKey) ([]byte, error) {
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+
+	key := struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+		Pub     []byte
+		Priv    []byte
+		Comment string
+		Pad     []byte `ssh:"rest"`
+	}{
+		Check1:  checkInt,
+		Check2:  checkInt,
+		Keytype: ssh.KeyAlgoED25519,
+		Pub:     private.Public().(ed25519.PublicKey),
+		Priv:    private,
+	}
+	// The private section is padded with 1, 2, 3... to the cipher block
+	// size, which is 8 without a cipher.
+	for i := 0; len(ssh.Marshal(key))%8 != 0; i++ {
+		key.Pad = append(key.Pad, byte(i+1))
+	}
+
+	envelope := struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		CipherName:   "none",
+		KdfName:      "none",
+		NumKeys:      1,
+		PubKey:       public.Marshal(),
+		PrivKeyBlock: ssh.Marshal(key),
+	}
+
+	magic := append([]byte("openssh-key-v1"), 0)
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append(magic, ssh.Marshal(envelope)...),
+	}), nil
+}
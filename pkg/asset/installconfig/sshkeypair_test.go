@@ -0,0 +1,73 @@
+package installconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/bailey84j/terraform_installer/pkg/validate"
+)
+
+func TestNewSSHKeyPair(t *testing.T) {
+	publicKey, privateKey, err := newSSHKeyPair()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, validate.SSHPublicKey(publicKey))
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ssh.KeyAlgoED25519, signer.PublicKey().Type())
+	assert.Equal(t, publicKey, string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+func TestSSHKeyPairPersistToFile(t *testing.T) {
+	publicKey, privateKey, err := newSSHKeyPair()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		name     string
+		keyPair  *SSHKeyPair
+		expected map[string]os.FileMode
+	}{
+		{
+			name:    "generated",
+			keyPair: &SSHKeyPair{PublicKey: publicKey, privateKey: privateKey},
+			expected: map[string]os.FileMode{
+				"auth/id_ed25519":     0600,
+				"auth/id_ed25519.pub": 0644,
+			},
+		},
+		{
+			name:     "loaded from the state file",
+			keyPair:  &SSHKeyPair{PublicKey: publicKey},
+			expected: map[string]os.FileMode{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if !assert.NoError(t, tc.keyPair.PersistToFile(dir)) {
+				return
+			}
+			actual := map[string]os.FileMode{}
+			err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				actual[filepath.ToSlash(rel)] = info.Mode().Perm()
+				return err
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
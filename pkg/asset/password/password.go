@@ -1,92 +1,137 @@
 package password
 
 import (
+	"bytes"
 	"crypto/rand"
 	"math/big"
 	"os"
 	"path/filepath"
 
+	"github.com/pkg/errors"
+
 	"github.com/bailey84j/terraform_installer/pkg/asset"
-	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/types"
 )
 
 var (
-	// tfePasswordPath is the path where tfe user password is stored.
+	// tfePasswordPath is the path where the generated tfe user password is
+	// stored.
 	tfePasswordPath = filepath.Join("auth", "tfe-password")
+
+	// legacyHashPath is where a hash of the password used to be read from.
+	legacyHashPath = filepath.Join("tls", "tfe-password.hash")
+
+	// characters are the characters of each class that generated passwords
+	// are made of. Characters that are easily mistaken for others are left
+	// out, as are the symbols that shells or URLs treat specially.
+	characters = map[types.CharacterClass]string{
+		types.LowercaseCharacterClass: "abcdefghijkmnopqrstuvwxyz",
+		types.UppercaseCharacterClass: "ABCDEFGHIJKLMNPQRSTUVWXYZ",
+		types.DigitsCharacterClass:    "23456789",
+		types.SymbolsCharacterClass:   "!%*+,-.:=@^_~",
+	}
 )
 
-// TFEPassword is the asset for the tfe user password
+// TFEPassword is the asset for the tfe user password. The password comes from
+// the install config or is generated once following its password policy. Only
+// whether the install config provides it is kept in the state file. A
+// generated password is written to auth/tfe-password, readable only by its
+// owner, and is not stored anywhere else. It is read back from that file by
+// later runs, also when the install config changes, since the admin user may
+// already have been created with it. A password from the install config is
+// never written out.
 type TFEPassword struct {
-	Password     string
-	PasswordHash []byte
-	File         *asset.File
+	Provided bool `json:"provided,omitempty"`
+
+	password []byte
 }
 
 var _ asset.WritableAsset = (*TFEPassword)(nil)
+var _ asset.FileWriter = (*TFEPassword)(nil)
+var _ asset.PreviousLoader = (*TFEPassword)(nil)
 
-// Dependencies returns no dependencies.
+// Dependencies returns the dependencies of the password.
 func (a *TFEPassword) Dependencies() []asset.Asset {
-	return []asset.Asset{}
+	return []asset.Asset{
+		&installconfig.InstallConfig{},
+	}
 }
 
 // Generate the tfe password
-func (a *TFEPassword) Generate(asset.Parents) error {
-	logrus.Debugf("Trace Me - Password - Generate...")
-	err := a.generateRandomPasswordHash(23)
-	if err != nil {
+func (a *TFEPassword) Generate(parents asset.Parents) error {
+	installConfig := &installconfig.InstallConfig{}
+	parents.Get(installConfig)
+	if err := installConfig.ResolveSecrets(); err != nil {
 		return err
 	}
+
+	config := installConfig.Config.TFE
+	if config.AdminPassword != "" {
+		a.Provided, a.password = true, nil
+		return nil
+	}
+	a.Provided = false
+	// A password generated by an earlier run is kept, even if it no longer
+	// follows the password policy.
+	if a.password != nil {
+		return nil
+	}
+	password, err := generatePassword(config.AdminPasswordPolicy)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate the admin password")
+	}
+	a.password = []byte(password)
 	return nil
 }
 
-// generateRandomPasswordHash generates a hash of a random ASCII password
-// 5char-5char-5char-5char
-func (a *TFEPassword) generateRandomPasswordHash(length int) error {
-	const (
-		lowerLetters = "abcdefghijkmnopqrstuvwxyz"
-		upperLetters = "ABCDEFGHIJKLMNPQRSTUVWXYZ"
-		digits       = "23456789"
-		all          = lowerLetters + upperLetters + digits
-	)
-	var password string
-	for i := 0; i < length; i++ {
-		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(all))))
-		if err != nil {
-			return err
-		}
-		newchar := string(all[n.Int64()])
-		if password == "" {
-			password = newchar
+// generatePassword generates a random password of the length of the policy
+// with at least one character of each of its classes.
+func generatePassword(policy *types.PasswordPolicy) (string, error) {
+	if len(policy.CharacterClasses) == 0 || policy.Length < len(policy.CharacterClasses) {
+		return "", errors.Errorf("a password of %d characters cannot have characters of %d classes", policy.Length, len(policy.CharacterClasses))
+	}
+
+	var all string
+	password := make([]byte, 0, policy.Length)
+	for _, c := range policy.CharacterClasses {
+		chars, ok := characters[c]
+		if !ok {
+			return "", errors.Errorf("unknown character class %q", c)
 		}
-		if i < length-1 {
-			n, err = rand.Int(rand.Reader, big.NewInt(int64(len(password)+1)))
-			if err != nil {
-				return err
-			}
-			j := n.Int64()
-			password = password[0:j] + newchar + password[j:]
+		all += chars
+		char, err := randomChar(chars)
+		if err != nil {
+			return "", err
 		}
+		password = append(password, char)
 	}
-	pw := []rune(password)
-	for _, replace := range []int{5, 11, 17} {
-		pw[replace] = '-'
+	for len(password) < policy.Length {
+		char, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, char)
 	}
-	if a.Password == "" {
-		a.Password = string(pw)
+
+	// Shuffle, so that the characters picked for each class are not always
+	// at the start.
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
 	}
-	bytes, err := bcrypt.GenerateFromPassword([]byte(a.Password), bcrypt.DefaultCost)
+	return string(password), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
 	if err != nil {
-		return err
+		return 0, err
 	}
-	a.PasswordHash = bytes
-	logrus.Debugf("Trace Me - Password - Create File")
-	a.File = &asset.File{
-		Filename: tfePasswordPath,
-		Data:     []byte(a.Password),
-	}
-
-	return nil
+	return chars[n.Int64()], nil
 }
 
 // Name returns the human-friendly name of the asset.
@@ -94,30 +139,56 @@ func (a *TFEPassword) Name() string {
 	return "TFE Password"
 }
 
-// Files returns the password file.
+// Files returns the password file when the password is generated.
 func (a *TFEPassword) Files() []*asset.File {
-	if a.File != nil {
-		return []*asset.File{a.File}
+	if a.password == nil {
+		return []*asset.File{}
 	}
-	return []*asset.File{}
+	return []*asset.File{{Filename: tfePasswordPath, Data: a.password}}
 }
 
-// Load loads a predefined hash only, if one is supplied
+// PersistToFile writes the generated password, readable only by its owner.
+// When the password comes from the install config, it removes the password
+// file left by an earlier run, which would no longer be the admin password.
+func (a *TFEPassword) PersistToFile(directory string) error {
+	path := filepath.Join(directory, tfePasswordPath)
+	if a.Provided {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to remove the stale admin password")
+		}
+		return nil
+	}
+	if a.password == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return errors.Wrap(err, "failed to create dir")
+	}
+	return errors.Wrap(asset.WriteFileAtomic(path, a.password, 0600), "failed to write the admin password")
+}
+
+// Load reads the password generated by an earlier run. It fails if a hash of
+// the password is still provided in the file the installer used to read it
+// from, rather than ignore it, since the initial admin user cannot be created
+// from a hash.
 func (a *TFEPassword) Load(f asset.FileFetcher) (found bool, err error) {
-	hashFilePath := filepath.Join("tls", "tfe-password.hash")
-	hashFile, err := f.FetchByName(hashFilePath)
+	if _, err := f.FetchByName(legacyHashPath); err == nil {
+		return false, errors.Errorf("%s is no longer read; move it out of the install directory and set tfe.adminPassword in %s instead", legacyHashPath, installconfig.InstallConfigFilename)
+	}
+	file, err := f.FetchByName(tfePasswordPath)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(errors.Cause(err)) {
 			return false, nil
 		}
 		return false, err
 	}
-
-	a.PasswordHash = hashFile.Data
-	// Assisted-service expects to always see a password file, so generate an
-	// empty one
-	a.File = &asset.File{
-		Filename: tfePasswordPath,
-	}
+	a.password = bytes.TrimSuffix(file.Data, []byte("\n"))
 	return true, nil
 }
+
+// LoadPrevious reads the password generated by an earlier run, so that it is
+// kept when the install config changes.
+func (a *TFEPassword) LoadPrevious(f asset.FileFetcher) error {
+	_, err := a.Load(f)
+	return err
+}
@@ -0,0 +1,245 @@
+package password
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/asset/store"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	cases := []struct {
+		name          string
+		policy        types.PasswordPolicy
+		expectedError string
+	}{
+		{
+			name: "default",
+			policy: types.PasswordPolicy{
+				Length:           23,
+				CharacterClasses: []types.CharacterClass{types.LowercaseCharacterClass, types.UppercaseCharacterClass, types.DigitsCharacterClass},
+			},
+		},
+		{
+			name: "all classes",
+			policy: types.PasswordPolicy{
+				Length:           10,
+				CharacterClasses: []types.CharacterClass{types.LowercaseCharacterClass, types.UppercaseCharacterClass, types.DigitsCharacterClass, types.SymbolsCharacterClass},
+			},
+		},
+		{
+			name: "digits only",
+			policy: types.PasswordPolicy{
+				Length:           12,
+				CharacterClasses: []types.CharacterClass{types.DigitsCharacterClass},
+			},
+		},
+		{
+			name: "shorter than the classes",
+			policy: types.PasswordPolicy{
+				Length:           1,
+				CharacterClasses: []types.CharacterClass{types.LowercaseCharacterClass, types.DigitsCharacterClass},
+			},
+			expectedError: `^a password of 1 characters cannot have characters of 2 classes$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			password, err := generatePassword(&tc.policy)
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, password, tc.policy.Length)
+			var allowed string
+			for _, c := range tc.policy.CharacterClasses {
+				allowed += characters[c]
+				assert.True(t, strings.IndexFunc(password, c.Contains) >= 0, "the password has no %s character", c)
+			}
+			for _, r := range password {
+				assert.Contains(t, allowed, string(r))
+			}
+		})
+	}
+}
+
+func TestTFEPasswordGenerate(t *testing.T) {
+	policy := &types.PasswordPolicy{
+		Length:           16,
+		CharacterClasses: []types.CharacterClass{types.LowercaseCharacterClass},
+	}
+
+	cases := []struct {
+		name             string
+		tfe              types.TFE
+		previous         []byte
+		expectedProvided bool
+	}{
+		{
+			name: "generated",
+			tfe:  types.TFE{AdminPasswordPolicy: policy},
+		},
+		{
+			name:             "provided password",
+			tfe:              types.TFE{AdminPassword: "user-supplied-password", AdminPasswordPolicy: policy},
+			expectedProvided: true,
+		},
+		{
+			name:     "previous password kept",
+			tfe:      types.TFE{AdminPasswordPolicy: policy},
+			previous: []byte("previous-password"),
+		},
+		{
+			name:             "provided password replaces the previous one",
+			tfe:              types.TFE{AdminPassword: "user-supplied-password", AdminPasswordPolicy: policy},
+			previous:         []byte("previous-password"),
+			expectedProvided: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parents := asset.Parents{}
+			parents.Add(&installconfig.InstallConfig{Config: &types.InstallConfig{TFE: &tc.tfe}})
+			a := &TFEPassword{password: tc.previous}
+			if !assert.NoError(t, a.Generate(parents)) {
+				return
+			}
+
+			assert.Equal(t, tc.expectedProvided, a.Provided)
+			switch files := a.Files(); {
+			case tc.expectedProvided:
+				assert.Empty(t, files)
+			case tc.previous != nil:
+				if assert.Len(t, files, 1) {
+					assert.Equal(t, tc.previous, files[0].Data)
+				}
+			default:
+				if assert.Len(t, files, 1) {
+					assert.Equal(t, "auth/tfe-password", files[0].Filename)
+					assert.Len(t, files[0].Data, policy.Length)
+				}
+			}
+
+			data, err := json.Marshal(a)
+			if assert.NoError(t, err) {
+				assert.NotContains(t, string(data), "user-supplied-password")
+				if a.password != nil {
+					assert.NotContains(t, string(data), string(a.password))
+				}
+			}
+		})
+	}
+}
+
+func TestTFEPasswordPersistToFile(t *testing.T) {
+	cases := []struct {
+		name         string
+		password     *TFEPassword
+		stale        bool
+		expectedFile string
+	}{
+		{
+			name:         "generated",
+			password:     &TFEPassword{password: []byte("generated")},
+			stale:        true,
+			expectedFile: "generated",
+		},
+		{
+			name:     "provided removes the stale password",
+			password: &TFEPassword{Provided: true},
+			stale:    true,
+		},
+		{
+			name:         "generated and taken from the state file",
+			password:     &TFEPassword{},
+			stale:        true,
+			expectedFile: "stale",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "auth", "tfe-password")
+			if tc.stale {
+				if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte("stale"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !assert.NoError(t, tc.password.PersistToFile(dir)) {
+				return
+			}
+			data, err := ioutil.ReadFile(path)
+			if tc.expectedFile == "" {
+				assert.True(t, os.IsNotExist(err), "the password file must not exist")
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedFile, string(data))
+			}
+		})
+	}
+}
+
+func TestTFEPasswordLoad(t *testing.T) {
+	cases := []struct {
+		name             string
+		files            map[string]string
+		expectedFound    bool
+		expectedPassword string
+		expectedError    string
+	}{
+		{
+			name: "no password file",
+		},
+		{
+			name:             "generated by an earlier run",
+			files:            map[string]string{"auth/tfe-password": "generated\n"},
+			expectedFound:    true,
+			expectedPassword: "generated",
+		},
+		{
+			name:          "legacy hash",
+			files:         map[string]string{"tls/tfe-password.hash": "$2a$10$hash"},
+			expectedError: `^tls/tfe-password\.hash is no longer read; move it out of the install directory and set tfe\.adminPassword in install-config\.yaml instead$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tc.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			a := &TFEPassword{}
+			found, err := a.Load(store.NewFileFetcher(dir))
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedFound, found)
+				assert.Equal(t, tc.expectedPassword, string(a.password))
+			}
+		})
+	}
+}
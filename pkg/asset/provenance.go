@@ -0,0 +1,46 @@
+package asset
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProvenanceSource is where an asset recorded in the state file came from.
+type ProvenanceSource string
+
+const (
+	// ProvenanceGenerated indicates that the asset was generated by the installer.
+	ProvenanceGenerated ProvenanceSource = "Generated"
+	// ProvenanceOnDisk indicates that the asset was loaded from the target directory.
+	ProvenanceOnDisk ProvenanceSource = "OnDisk"
+)
+
+// Provenance records which installer produced an asset, when and from where.
+type Provenance struct {
+	// InstallerVersion is the version of the installer that recorded the asset.
+	InstallerVersion string `json:"installerVersion"`
+	// InstallerCommit is the commit from which that installer was built.
+	InstallerCommit string `json:"installerCommit,omitempty"`
+	// Timestamp is when the asset was recorded.
+	Timestamp time.Time `json:"timestamp"`
+	// Source is where the asset came from.
+	Source ProvenanceSource `json:"source"`
+	// User is the name of the user on the host that ran the installer.
+	User string `json:"user,omitempty"`
+	// Files maps the name of each file of a writable asset to the SHA-256
+	// hash of its contents.
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// String returns a one-line, human-readable description of the provenance.
+func (p *Provenance) String() string {
+	s := fmt.Sprintf("%s by installer %s", p.Source, p.InstallerVersion)
+	if p.InstallerCommit != "" {
+		s += fmt.Sprintf(" (%s)", p.InstallerCommit)
+	}
+	s += " at " + p.Timestamp.Format(time.RFC3339)
+	if p.User != "" {
+		s += " as " + p.User
+	}
+	return s
+}
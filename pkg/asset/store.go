@@ -1,5 +1,7 @@
 package asset
 
+import "strings"
+
 // Store is a store for the states of assets.
 type Store interface {
 	// Fetch retrieves the state of the given asset, generating it and its
@@ -7,6 +9,10 @@ type Store interface {
 	// assets in assetsToPreserve will be purged.
 	Fetch(assetToFetch Asset, assetsToPreserve ...WritableAsset) error
 
+	// Persist writes the files of the asset to disk and runs the hooks that
+	// wait for them to be written.
+	Persist(WritableAsset) error
+
 	// Destroy removes the asset from all its internal state and also from
 	// disk if possible.
 	Destroy(Asset) error
@@ -18,4 +24,71 @@ type Store interface {
 	// Load retrieves the state of the given asset but does not generate it if it
 	// does not exist and instead will return nil if not found.
 	Load(Asset) (Asset, error)
+
+	// LoadWithProvenance retrieves the state of the given asset like Load,
+	// along with the provenance recorded for it, if any.
+	LoadWithProvenance(Asset) (Asset, *Provenance, error)
+
+	// Explain reports, for the given asset and all of its dependencies, whether
+	// fetching it would reuse an existing copy or regenerate it. No asset is
+	// generated and nothing is purged from disk.
+	Explain(Asset) ([]Explanation, error)
+}
+
+// ExplanationReason is the reason an asset would be reused or regenerated.
+type ExplanationReason string
+
+const (
+	// ReasonOnDiskChanged indicates that the asset was provided in the target
+	// directory and differs from the copy in the state file.
+	ReasonOnDiskChanged ExplanationReason = "OnDiskChanged"
+	// ReasonProvidedOnDisk indicates that the asset was provided in the
+	// target directory and is not in the state file.
+	ReasonProvidedOnDisk ExplanationReason = "ProvidedOnDisk"
+	// ReasonDirtyParent indicates that at least one dependency of the asset is
+	// dirty, so the asset must be regenerated.
+	ReasonDirtyParent ExplanationReason = "DirtyParent"
+	// ReasonAbsent indicates that the asset is neither in the target directory
+	// nor in the state file.
+	ReasonAbsent ExplanationReason = "Absent"
+	// ReasonUnchanged indicates that the asset is reused from the state file.
+	ReasonUnchanged ExplanationReason = "Unchanged"
+)
+
+// Explanation describes what fetching an asset would do.
+type Explanation struct {
+	// Asset is the human-friendly name of the asset.
+	Asset string
+	// Regenerate is true when the asset would be generated rather than reused.
+	Regenerate bool
+	// Reason is why the asset would be reused or regenerated.
+	Reason ExplanationReason
+	// DirtyParents are the names of the dependencies that are dirty. It is only
+	// set when Reason is ReasonDirtyParent.
+	DirtyParents []string
+	// Provenance records where the existing copy of the asset came from, if
+	// known.
+	Provenance *Provenance
+}
+
+// String returns a one-line, human-readable description of the explanation.
+func (e Explanation) String() string {
+	action := "reuse"
+	if e.Regenerate {
+		action = "regenerate"
+	}
+	var reason string
+	switch e.Reason {
+	case ReasonOnDiskChanged:
+		reason = "the file in the target directory differs from the state file"
+	case ReasonProvidedOnDisk:
+		reason = "provided in the target directory"
+	case ReasonDirtyParent:
+		reason = "dependencies are dirty: " + strings.Join(e.DirtyParents, ", ")
+	case ReasonAbsent:
+		reason = "not found in the target directory or the state file"
+	case ReasonUnchanged:
+		reason = "unchanged in the state file"
+	}
+	return e.Asset + ": " + action + " (" + reason + ")"
 }
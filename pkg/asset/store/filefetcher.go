@@ -11,6 +11,12 @@ type fileFetcher struct {
 	directory string
 }
 
+// NewFileFetcher returns a FileFetcher that reads the asset files in the
+// given directory.
+func NewFileFetcher(dir string) asset.FileFetcher {
+	return &fileFetcher{directory: dir}
+}
+
 // FetchByName returns the file with the given name.
 func (f *fileFetcher) FetchByName(name string) (*asset.File, error) {
 	data, err := ioutil.ReadFile(filepath.Join(f.directory, name))
@@ -1,20 +1,42 @@
 package store
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/user"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/hooks"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/version"
 )
 
 const (
 	stateFileName = ".openshift_install_state.json"
+
+	// stateFileBackups is the number of rotated backups of the state file
+	// that are kept in the target directory.
+	stateFileBackups = 5
+
+	// provenanceKey is the key under which the provenance of an asset is
+	// recorded in the asset's entry in the state file.
+	provenanceKey = "$provenance"
+
+	// ConsumedDirName is the directory in the target directory into which
+	// consumed assets are moved by the archive purge policy.
+	ConsumedDirName = ".consumed"
 )
 
 // assetSource indicates from where the asset was fetched
@@ -42,6 +64,10 @@ type assetState struct {
 	// presentOnDisk is true if the asset in on-disk. This is set whether the
 	// asset is sourced from on-disk or not. It is used in purging consumed assets.
 	presentOnDisk bool
+	// provenance records where the asset came from. It is nil for assets
+	// that have not been fetched or that were found in a state file which
+	// did not record their provenance.
+	provenance *asset.Provenance
 }
 
 // storeImpl is the implementation of Store.
@@ -50,23 +76,63 @@ type storeImpl struct {
 	assets          map[reflect.Type]*assetState
 	stateFileAssets map[string]json.RawMessage
 	fileFetcher     asset.FileFetcher
+	hooks           *hooks.Config
+	purgePolicy     types.PurgePolicy
+
+	// targets are the assets being fetched and their preserved assets, which
+	// the caller writes to disk once fetched.
+	targets map[reflect.Type]bool
+	// postGenerate holds the postGenerate hook payloads of the targets that
+	// were generated, to be run once their files have been persisted.
+	postGenerate map[reflect.Type]hooks.Payload
+}
+
+// StoreOption is an option for configuring an asset store.
+type StoreOption func(s *storeImpl)
+
+// WithPurgePolicy sets what the store does with on-disk assets once they have
+// been consumed, overriding the purge policy of the fetched assets. Consumed
+// assets are deleted by default.
+func WithPurgePolicy(policy types.PurgePolicy) StoreOption {
+	return func(s *storeImpl) {
+		s.purgePolicy = policy
+	}
+}
+
+// WithHooks sets the lifecycle hooks that the store runs around asset
+// generation. The hooks file in the store directory is loaded by default.
+func WithHooks(config *hooks.Config) StoreOption {
+	return func(s *storeImpl) {
+		s.hooks = config
+	}
 }
 
 // NewStore returns an asset store that implements the asset.Store interface.
-func NewStore(dir string) (asset.Store, error) {
-	return newStore(dir)
+func NewStore(dir string, opts ...StoreOption) (asset.Store, error) {
+	return newStore(dir, opts...)
 }
 
-func newStore(dir string) (*storeImpl, error) {
+func newStore(dir string, opts ...StoreOption) (*storeImpl, error) {
 	store := &storeImpl{
 		directory:   dir,
 		fileFetcher: &fileFetcher{directory: dir},
 		assets:      map[reflect.Type]*assetState{},
 	}
+	for _, opt := range opts {
+		opt(store)
+	}
 
 	if err := store.loadStateFile(); err != nil {
 		return nil, err
 	}
+
+	if store.hooks == nil {
+		hookConfig, err := hooks.Load(dir)
+		if err != nil {
+			return nil, err
+		}
+		store.hooks = hookConfig
+	}
 	return store, nil
 }
 
@@ -74,6 +140,10 @@ func newStore(dir string) (*storeImpl, error) {
 // dependencies if necessary. When purging consumed assets, none of the
 // assets in preserved will be purged.
 func (s *storeImpl) Fetch(a asset.Asset, preserved ...asset.WritableAsset) error {
+	s.targets = map[reflect.Type]bool{reflect.TypeOf(a): true}
+	for _, p := range preserved {
+		s.targets[reflect.TypeOf(p)] = true
+	}
 	if err := s.fetch(a, ""); err != nil {
 		return err
 	}
@@ -86,6 +156,33 @@ func (s *storeImpl) Fetch(a asset.Asset, preserved ...asset.WritableAsset) error
 	return nil
 }
 
+// Persist writes the files of the asset into the store directory. If the asset
+// was generated by a fetch, its postGenerate hooks are run once the files are
+// written, with the absolute paths of the files as outputs.
+func (s *storeImpl) Persist(a asset.WritableAsset) error {
+	var writer asset.FileWriter = asset.NewDefaultFileWriter(a)
+	if fw, ok := a.(asset.FileWriter); ok {
+		writer = fw
+	}
+	if err := writer.PersistToFile(s.directory); err != nil {
+		return err
+	}
+
+	payload, ok := s.postGenerate[reflect.TypeOf(a)]
+	if !ok {
+		return nil
+	}
+	delete(s.postGenerate, reflect.TypeOf(a))
+	for _, f := range a.Files() {
+		path, err := filepath.Abs(filepath.Join(s.directory, f.Filename))
+		if err != nil {
+			return err
+		}
+		payload.Outputs = append(payload.Outputs, path)
+	}
+	return s.hooks.Run(hooks.PostGenerate, payload)
+}
+
 // Destroy removes the asset from all its internal state and also from
 // disk if possible.
 func (s *storeImpl) Destroy(a asset.Asset) error {
@@ -111,22 +208,24 @@ func (s *storeImpl) Destroy(a asset.Asset) error {
 	return s.saveStateFile()
 }
 
-// DestroyState removes the state file from disk
+// DestroyState removes the state file and its backups from disk
 func (s *storeImpl) DestroyState() error {
 	s.stateFileAssets = nil
-	path := filepath.Join(s.directory, stateFileName)
-	err := os.Remove(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
+	paths := []string{filepath.Join(s.directory, stateFileName)}
+	for i := 1; i <= stateFileBackups; i++ {
+		paths = append(paths, s.stateFileBackupPath(i))
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
 		}
-		return err
 	}
 	return nil
 }
 
 // loadStateFile retrieves the state from the state file present in the given directory
-// and returns the assets map
+// and returns the assets map. If the state file is corrupt, the newest valid
+// backup is used instead.
 func (s *storeImpl) loadStateFile() error {
 	path := filepath.Join(s.directory, stateFileName)
 	assets := map[string]json.RawMessage{}
@@ -139,12 +238,68 @@ func (s *storeImpl) loadStateFile() error {
 	}
 	err = json.Unmarshal(data, &assets)
 	if err != nil {
-		return errors.Wrapf(err, "failed to unmarshal state file %q", path)
+		recovered, backup := s.recoverStateFile()
+		if recovered == nil {
+			return errors.Wrapf(err, "failed to unmarshal state file %q", path)
+		}
+		logrus.Warnf("State file %q is corrupt (%v), recovered the state from backup %q", path, err, backup)
+		assets = recovered
 	}
 	s.stateFileAssets = assets
 	return nil
 }
 
+// recoverStateFile returns the assets from the newest backup of the state file
+// that can be unmarshaled, along with the path of that backup. It returns nil
+// if there is no valid backup.
+func (s *storeImpl) recoverStateFile() (map[string]json.RawMessage, string) {
+	for i := 1; i <= stateFileBackups; i++ {
+		path := s.stateFileBackupPath(i)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logrus.Debugf("Skipping state file backup %q: %v", path, err)
+			}
+			continue
+		}
+		assets := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &assets); err != nil {
+			logrus.Debugf("Skipping corrupt state file backup %q: %v", path, err)
+			continue
+		}
+		return assets, path
+	}
+	return nil, ""
+}
+
+// stateFileBackupPath returns the path of the i-th backup of the state file,
+// where 1 is the newest.
+func (s *storeImpl) stateFileBackupPath(i int) string {
+	return filepath.Join(s.directory, fmt.Sprintf("%s.%d", stateFileName, i))
+}
+
+// backupStateFile rotates the backups of the state file and copies the current
+// state file into the newest backup. Nothing is done if there is no current
+// state file, if it is corrupt or if it already holds the given data.
+func (s *storeImpl) backupStateFile(data []byte) error {
+	current, err := ioutil.ReadFile(filepath.Join(s.directory, stateFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if bytes.Equal(current, data) || !json.Valid(current) {
+		return nil
+	}
+	for i := stateFileBackups - 1; i >= 1; i-- {
+		if err := os.Rename(s.stateFileBackupPath(i), s.stateFileBackupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return asset.WriteFileAtomic(s.stateFileBackupPath(1), current, 0640)
+}
+
 // loadAssetFromState renders the asset object arguments from the state file contents.
 func (s *storeImpl) loadAssetFromState(a asset.Asset) error {
 	bytes, ok := s.stateFileAssets[reflect.TypeOf(a).String()]
@@ -154,6 +309,25 @@ func (s *storeImpl) loadAssetFromState(a asset.Asset) error {
 	return json.Unmarshal(bytes, a)
 }
 
+// loadProvenanceFromState returns the provenance recorded for the asset in the
+// state file, or nil if none was recorded.
+func (s *storeImpl) loadProvenanceFromState(a asset.Asset) (*asset.Provenance, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(s.stateFileAssets[reflect.TypeOf(a).String()], &fields); err != nil {
+		// Only assets that marshal to a JSON object record their provenance.
+		return nil, nil
+	}
+	data, ok := fields[provenanceKey]
+	if !ok {
+		return nil, nil
+	}
+	provenance := &asset.Provenance{}
+	if err := json.Unmarshal(data, provenance); err != nil {
+		return nil, err
+	}
+	return provenance, nil
+}
+
 // isAssetInState tests whether the asset is in the state file.
 func (s *storeImpl) isAssetInState(a asset.Asset) bool {
 	_, ok := s.stateFileAssets[reflect.TypeOf(a).String()]
@@ -169,7 +343,7 @@ func (s *storeImpl) saveStateFile() error {
 		if v.source == unfetched {
 			continue
 		}
-		data, err := json.MarshalIndent(v.asset, "", "    ")
+		data, err := marshalWithProvenance(v.asset, v.provenance)
 		if err != nil {
 			return err
 		}
@@ -184,10 +358,10 @@ func (s *storeImpl) saveStateFile() error {
 	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(path, data, 0640); err != nil {
-		return err
+	if err := s.backupStateFile(data); err != nil {
+		return errors.Wrap(err, "failed to back up state file")
 	}
-	return nil
+	return asset.WriteFileAtomic(path, data, 0640)
 }
 
 // fetch populates the given asset, generating it and its dependencies if
@@ -223,15 +397,94 @@ func (s *storeImpl) fetch(a asset.Asset, indent string) error {
 		}
 		parents.Add(d)
 	}
+	payload := hooks.Payload{Asset: a.Name()}
+	if err := s.hooks.Run(hooks.PreGenerate, payload); err != nil {
+		return err
+	}
+	if p, ok := a.(asset.PreviousLoader); ok && assetState.presentOnDisk {
+		if err := p.LoadPrevious(s.fileFetcher); err != nil {
+			return errors.Wrapf(err, "failed to load the previous %q", a.Name())
+		}
+	}
 	logrus.Debugf("%sGenerating %s...", indent, a.Name())
 	if err := a.Generate(parents); err != nil {
 		return errors.Wrapf(err, "failed to generate asset %q", a.Name())
 	}
+	// The files of the targets are only written once they have been fetched,
+	// so their hooks are held back until then. Other assets are never written.
+	if _, ok := a.(asset.WritableAsset); ok && s.targets[reflect.TypeOf(a)] {
+		if s.postGenerate == nil {
+			s.postGenerate = map[reflect.Type]hooks.Payload{}
+		}
+		s.postGenerate[reflect.TypeOf(a)] = payload
+	} else if err := s.hooks.Run(hooks.PostGenerate, payload); err != nil {
+		return err
+	}
 	assetState.asset = a
 	assetState.source = generatedSource
+	assetState.provenance = newProvenance(a, asset.ProvenanceGenerated)
 	return nil
 }
 
+// newProvenance records that the given asset was just taken from source by
+// this installer.
+func newProvenance(a asset.Asset, source asset.ProvenanceSource) *asset.Provenance {
+	provenance := &asset.Provenance{
+		InstallerVersion: version.Raw,
+		InstallerCommit:  version.Commit,
+		Timestamp:        time.Now().UTC(),
+		Source:           source,
+	}
+	if u, err := user.Current(); err == nil {
+		provenance.User = u.Username
+	}
+	if wa, ok := a.(asset.WritableAsset); ok {
+		for _, f := range wa.Files() {
+			if provenance.Files == nil {
+				provenance.Files = map[string]string{}
+			}
+			sum := sha256.Sum256(f.Data)
+			provenance.Files[f.Filename] = hex.EncodeToString(sum[:])
+		}
+	}
+	return provenance
+}
+
+// marshalWithProvenance returns the state file entry of the asset, which is the
+// asset itself with its provenance added under provenanceKey. The asset is
+// recorded as is when its provenance is unknown or when it does not marshal to
+// a JSON object.
+func marshalWithProvenance(a asset.Asset, provenance *asset.Provenance) ([]byte, error) {
+	data, err := json.MarshalIndent(a, "", "    ")
+	if err != nil || provenance == nil {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return data, nil
+	}
+	if fields[provenanceKey], err = json.Marshal(provenance); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(fields, "", "    ")
+}
+
+// matchesStateFile reports whether the on-disk asset would be recorded in the
+// state file as the asset loaded from it was. Unexported fields, such as
+// resolved secrets that the state file only keeps references to, are not
+// compared.
+func matchesStateFile(onDiskAsset, stateFileAsset asset.Asset) bool {
+	onDisk, err := json.Marshal(onDiskAsset)
+	if err != nil {
+		return false
+	}
+	stateFile, err := json.Marshal(stateFileAsset)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(onDisk, stateFile)
+}
+
 // load loads the asset and all of its ancestors from on-disk and the state file.
 func (s *storeImpl) load(a asset.Asset, indent string) (*assetState, error) {
 	logrus.Debugf("%sLoading %s...", indent, a.Name())
@@ -289,7 +542,7 @@ func (s *storeImpl) load(a asset.Asset, indent string) (*assetState, error) {
 
 			// If the on-disk asset is the same as the one in the state file, there
 			// is no need to consider the one on disk and to mark the asset dirty.
-			onDiskMatchesStateFile = reflect.DeepEqual(onDiskAsset, stateFileAsset)
+			onDiskMatchesStateFile = matchesStateFile(onDiskAsset, stateFileAsset)
 			if onDiskMatchesStateFile {
 				logrus.Debugf("%sOn-disk %s matches asset in state file", indent, a.Name())
 			}
@@ -299,11 +552,14 @@ func (s *storeImpl) load(a asset.Asset, indent string) (*assetState, error) {
 	var (
 		assetToStore asset.Asset
 		source       assetSource
+		provenance   *asset.Provenance
 	)
 	switch {
 	// A parent is dirty. The asset must be re-generated.
 	case anyParentsDirty:
-		if foundOnDisk {
+		if _, ok := a.(asset.PreviousLoader); ok && foundOnDisk {
+			logrus.Debugf("%sRegenerating %s from the copy in the target directory because its dependencies are dirty", indent, a.Name())
+		} else if foundOnDisk {
 			logrus.Warningf("%sDiscarding the %s that was provided in the target directory because its dependencies are dirty and it needs to be regenerated", indent, a.Name())
 		}
 		source = unfetched
@@ -313,11 +569,21 @@ func (s *storeImpl) load(a asset.Asset, indent string) (*assetState, error) {
 		logrus.Debugf("%sUsing %s loaded from target directory", indent, a.Name())
 		assetToStore = onDiskAsset
 		source = onDiskSource
+		provenance = newProvenance(onDiskAsset, asset.ProvenanceOnDisk)
 	// The asset is in the state file. The asset is sourced from state file.
 	case foundInStateFile:
 		logrus.Debugf("%sUsing %s loaded from state file", indent, a.Name())
 		assetToStore = stateFileAsset
+		// The on-disk asset matches the state file, and may hold what the
+		// state file leaves out, such as secrets.
+		if foundOnDisk {
+			assetToStore = onDiskAsset
+		}
 		source = stateFileSource
+		var err error
+		if provenance, err = s.loadProvenanceFromState(a); err != nil {
+			return nil, errors.Wrapf(err, "failed to load provenance of asset %q from state file", a.Name())
+		}
 	// There is no existing source for the asset. The asset will be generated.
 	default:
 		source = unfetched
@@ -328,12 +594,34 @@ func (s *storeImpl) load(a asset.Asset, indent string) (*assetState, error) {
 		source:          source,
 		anyParentsDirty: anyParentsDirty,
 		presentOnDisk:   foundOnDisk,
+		provenance:      provenance,
 	}
 	s.assets[reflect.TypeOf(a)] = state
 	return state, nil
 }
 
-// purge deletes the on-disk assets that are consumed already.
+// purgePolicySource is implemented by assets that set the purge policy of the
+// store once fetched, unless the store was created with a purge policy.
+type purgePolicySource interface {
+	PurgePolicy() types.PurgePolicy
+}
+
+// currentPurgePolicy returns the purge policy the store was created with or,
+// when there is none, the one of the fetched assets.
+func (s *storeImpl) currentPurgePolicy() types.PurgePolicy {
+	if s.purgePolicy != "" {
+		return s.purgePolicy
+	}
+	for _, assetState := range s.assets {
+		if source, ok := assetState.asset.(purgePolicySource); ok && assetState.source != unfetched {
+			return source.PurgePolicy()
+		}
+	}
+	return ""
+}
+
+// purge deletes, archives or keeps the on-disk assets that are consumed
+// already, according to the purge policy of the store.
 // E.g., install-config.yaml will be deleted after fetching 'manifests'.
 // The target asset is excluded.
 func (s *storeImpl) purge(excluded []asset.WritableAsset) error {
@@ -341,19 +629,101 @@ func (s *storeImpl) purge(excluded []asset.WritableAsset) error {
 	for _, a := range excluded {
 		excl[reflect.TypeOf(a)] = true
 	}
+	policy := s.currentPurgePolicy()
+	archiveDir := filepath.Join(s.directory, ConsumedDirName, time.Now().UTC().Format("20060102T150405Z"))
 	for _, assetState := range s.assets {
 		if !assetState.presentOnDisk || excl[reflect.TypeOf(assetState.asset)] {
 			continue
 		}
-		logrus.Infof("Consuming %s from target directory", assetState.asset.Name())
-		if err := asset.DeleteAssetFromDisk(assetState.asset.(asset.WritableAsset), s.directory); err != nil {
-			return err
+		wa := assetState.asset.(asset.WritableAsset)
+		switch policy {
+		case types.KeepPurgePolicy:
+			logrus.Debugf("Keeping consumed %s in target directory", wa.Name())
+			continue
+		case types.ArchivePurgePolicy:
+			logrus.Infof("Consuming %s from target directory, archiving it in %s", wa.Name(), archiveDir)
+			if err := asset.ArchiveAssetFromDisk(wa, s.directory, archiveDir); err != nil {
+				return err
+			}
+		default:
+			logrus.Infof("Consuming %s from target directory", wa.Name())
+			if err := asset.DeleteAssetFromDisk(wa, s.directory); err != nil {
+				return err
+			}
 		}
 		assetState.presentOnDisk = false
 	}
 	return nil
 }
 
+// ConsumedArchives returns the directories, oldest first, into which consumed
+// assets were archived in the given target directory.
+func ConsumedArchives(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, ConsumedDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var archives []string
+	for _, e := range entries {
+		if e.IsDir() {
+			archives = append(archives, filepath.Join(dir, ConsumedDirName, e.Name()))
+		}
+	}
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// Explain loads the given asset and its ancestors and reports, in dependency
+// order, whether each would be reused or regenerated by Fetch. It does not
+// generate any asset or purge anything from disk.
+func (s *storeImpl) Explain(a asset.Asset) ([]asset.Explanation, error) {
+	if _, err := s.load(a, ""); err != nil {
+		return nil, errors.Wrap(err, "failed to load asset")
+	}
+	var explanations []asset.Explanation
+	s.explain(a, map[reflect.Type]bool{}, &explanations)
+	return explanations, nil
+}
+
+// explain appends the explanation for the given asset to explanations after
+// those of its dependencies. Assets in seen are skipped.
+func (s *storeImpl) explain(a asset.Asset, seen map[reflect.Type]bool, explanations *[]asset.Explanation) {
+	if seen[reflect.TypeOf(a)] {
+		return
+	}
+	seen[reflect.TypeOf(a)] = true
+
+	var dirtyParents []string
+	for _, d := range a.Dependencies() {
+		s.explain(d, seen, explanations)
+		if state := s.assets[reflect.TypeOf(d)]; state.anyParentsDirty || state.source == onDiskSource {
+			dirtyParents = append(dirtyParents, d.Name())
+		}
+	}
+
+	state := s.assets[reflect.TypeOf(a)]
+	explanation := asset.Explanation{Asset: a.Name(), Provenance: state.provenance}
+	switch {
+	case state.anyParentsDirty:
+		explanation.Regenerate = true
+		explanation.Reason = asset.ReasonDirtyParent
+		explanation.DirtyParents = dirtyParents
+	case state.source == onDiskSource && !s.isAssetInState(a):
+		explanation.Reason = asset.ReasonProvidedOnDisk
+	case state.source == onDiskSource:
+		explanation.Reason = asset.ReasonOnDiskChanged
+	case state.source == stateFileSource:
+		explanation.Reason = asset.ReasonUnchanged
+	default:
+		explanation.Regenerate = true
+		explanation.Reason = asset.ReasonAbsent
+	}
+	*explanations = append(*explanations, explanation)
+}
+
 func increaseIndent(indent string) string {
 	return indent + "  "
 }
@@ -361,14 +731,23 @@ func increaseIndent(indent string) string {
 // Load retrieves the given asset if it is present in the store and does not generate the asset
 // if it does not exist and will return nil.
 func (s *storeImpl) Load(a asset.Asset) (asset.Asset, error) {
+	loaded, _, err := s.LoadWithProvenance(a)
+	return loaded, err
+}
+
+// LoadWithProvenance retrieves the given asset like Load, along with where the
+// asset came from. The provenance is nil if the asset is not found or if the
+// state file did not record it.
+func (s *storeImpl) LoadWithProvenance(a asset.Asset) (asset.Asset, *asset.Provenance, error) {
 	foundOnDisk, err := s.load(a, "")
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to load asset")
+		return nil, nil, errors.Wrap(err, "failed to load asset")
 	}
 
 	if foundOnDisk.source == unfetched {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	return s.assets[reflect.TypeOf(a)].asset, nil
+	state := s.assets[reflect.TypeOf(a)]
+	return state.asset, state.provenance, nil
 }
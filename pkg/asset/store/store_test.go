@@ -1,14 +1,21 @@
 package store
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/hooks"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/version"
 )
 
 var (
@@ -410,7 +417,7 @@ func TestStoreFetchIdempotency(t *testing.T) {
 	expectedFiles := []string{"a", "b"}
 	actualFiles := []string{}
 	walkFunc := func(path string, fi os.FileInfo, err error) error {
-		if fi.IsDir() || fi.Name() == stateFileName {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), stateFileName) {
 			return nil
 		}
 		actualFiles = append(actualFiles, fi.Name())
@@ -474,3 +481,559 @@ func TestStoreLoadOnDiskAssets(t *testing.T) {
 		})
 	}
 }
+
+func TestStoreExplain(t *testing.T) {
+	cases := []struct {
+		name                 string
+		assets               map[string][]string
+		onDiskAssets         []string
+		stateFileAssets      []string
+		target               string
+		expectedExplanations []asset.Explanation
+	}{
+		{
+			name: "absent assets are regenerated",
+			assets: map[string][]string{
+				"a": {"b"},
+				"b": {},
+			},
+			target: "a",
+			expectedExplanations: []asset.Explanation{
+				{Asset: "b", Regenerate: true, Reason: asset.ReasonAbsent},
+				{Asset: "a", Regenerate: true, Reason: asset.ReasonAbsent},
+			},
+		},
+		{
+			name: "state file assets are reused",
+			assets: map[string][]string{
+				"a": {"b"},
+				"b": {},
+			},
+			stateFileAssets: []string{"a", "b"},
+			target:          "a",
+			expectedExplanations: []asset.Explanation{
+				{Asset: "b", Reason: asset.ReasonUnchanged},
+				{Asset: "a", Reason: asset.ReasonUnchanged},
+			},
+		},
+		{
+			name: "asset provided on disk without a state file",
+			assets: map[string][]string{
+				"a": {"b"},
+				"b": {},
+			},
+			onDiskAssets: []string{"b"},
+			target:       "a",
+			expectedExplanations: []asset.Explanation{
+				{Asset: "b", Reason: asset.ReasonProvidedOnDisk},
+				{Asset: "a", Regenerate: true, Reason: asset.ReasonDirtyParent, DirtyParents: []string{"b"}},
+			},
+		},
+		{
+			name: "on-disk dependency dirties its children",
+			assets: map[string][]string{
+				"a": {"b", "c"},
+				"b": {"d"},
+				"c": {},
+				"d": {},
+			},
+			onDiskAssets:    []string{"d"},
+			stateFileAssets: []string{"a", "b", "c"},
+			target:          "a",
+			expectedExplanations: []asset.Explanation{
+				{Asset: "d", Reason: asset.ReasonProvidedOnDisk},
+				{Asset: "b", Regenerate: true, Reason: asset.ReasonDirtyParent, DirtyParents: []string{"d"}},
+				{Asset: "c", Reason: asset.ReasonUnchanged},
+				{Asset: "a", Regenerate: true, Reason: asset.ReasonDirtyParent, DirtyParents: []string{"b"}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearAssetBehaviors()
+			store := &storeImpl{
+				assets:          map[reflect.Type]*assetState{},
+				stateFileAssets: map[string]json.RawMessage{},
+			}
+			assets := make(map[string]asset.Asset, len(tc.assets))
+			for name := range tc.assets {
+				assets[name] = newTestStoreAsset(name)
+			}
+			for name, deps := range tc.assets {
+				dependenciesOfAsset := make([]asset.Asset, len(deps))
+				for i, d := range deps {
+					dependenciesOfAsset[i] = assets[d]
+				}
+				dependencies[reflect.TypeOf(assets[name])] = dependenciesOfAsset
+			}
+			for _, name := range tc.onDiskAssets {
+				onDiskAssets[reflect.TypeOf(assets[name])] = true
+			}
+			for _, name := range tc.stateFileAssets {
+				store.stateFileAssets[reflect.TypeOf(assets[name]).String()] = json.RawMessage("{}")
+			}
+			explanations, err := store.Explain(assets[tc.target])
+			assert.NoError(t, err, "unexpected error")
+			for i := range explanations {
+				explanations[i].Provenance = nil
+			}
+			assert.Equal(t, tc.expectedExplanations, explanations)
+			assert.Empty(t, generationLog, "no asset should be generated")
+		})
+	}
+}
+
+func TestStoreStateFileBackups(t *testing.T) {
+	dir := t.TempDir()
+	store := &storeImpl{
+		directory: dir,
+		assets:    map[reflect.Type]*assetState{},
+	}
+	for i := 0; i < stateFileBackups+2; i++ {
+		store.stateFileAssets = map[string]json.RawMessage{
+			"generation": json.RawMessage(fmt.Sprintf("%d", i)),
+		}
+		err := store.saveStateFile()
+		if !assert.NoError(t, err, "(loop %d) unexpected error saving state file", i) {
+			t.FailNow()
+		}
+	}
+
+	for i := 1; i <= stateFileBackups; i++ {
+		data, err := ioutil.ReadFile(store.stateFileBackupPath(i))
+		if assert.NoError(t, err, "unexpected error reading backup %d", i) {
+			assert.Contains(t, string(data), fmt.Sprintf(`"generation": %d`, stateFileBackups+1-i))
+		}
+	}
+	_, err := os.Stat(store.stateFileBackupPath(stateFileBackups + 1))
+	assert.True(t, os.IsNotExist(err), "too many backups were kept")
+}
+
+func TestStoreLoadCorruptStateFile(t *testing.T) {
+	cases := []struct {
+		name           string
+		backups        []string
+		expectedAssets map[string]json.RawMessage
+		expectedError  bool
+	}{
+		{
+			name:          "no backups",
+			expectedError: true,
+		},
+		{
+			name:           "newest backup is valid",
+			backups:        []string{`{"a": 2}`, `{"a": 1}`},
+			expectedAssets: map[string]json.RawMessage{"a": json.RawMessage("2")},
+		},
+		{
+			name:           "newest backup is corrupt",
+			backups:        []string{`{"a": 2`, `{"a": 1}`},
+			expectedAssets: map[string]json.RawMessage{"a": json.RawMessage("1")},
+		},
+		{
+			name:          "all backups are corrupt",
+			backups:       []string{`{"a": 2`, `{"a"`},
+			expectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := &storeImpl{directory: dir}
+			err := ioutil.WriteFile(filepath.Join(dir, stateFileName), []byte(`{"a": 3`), 0640)
+			assert.NoError(t, err, "unexpected error writing state file")
+			for i, backup := range tc.backups {
+				err := ioutil.WriteFile(store.stateFileBackupPath(i+1), []byte(backup), 0640)
+				assert.NoError(t, err, "unexpected error writing backup")
+			}
+
+			err = store.loadStateFile()
+			if tc.expectedError {
+				assert.Error(t, err, "expected an error loading the corrupt state file")
+				return
+			}
+			assert.NoError(t, err, "unexpected error loading state file")
+			assert.Equal(t, tc.expectedAssets, store.stateFileAssets)
+		})
+	}
+}
+
+// testStorePolicyAsset is an asset that sets the purge policy of the store.
+type testStorePolicyAsset struct {
+	policy types.PurgePolicy
+}
+
+func (a *testStorePolicyAsset) Name() string                   { return "policy" }
+func (a *testStorePolicyAsset) Dependencies() []asset.Asset    { return nil }
+func (a *testStorePolicyAsset) Generate(asset.Parents) error   { return nil }
+func (a *testStorePolicyAsset) PurgePolicy() types.PurgePolicy { return a.policy }
+
+func TestStorePurge(t *testing.T) {
+	cases := []struct {
+		name             string
+		policy           types.PurgePolicy
+		assetPolicy      types.PurgePolicy
+		expectedOnDisk   bool
+		expectedArchived bool
+	}{
+		{
+			name:   "default deletes",
+			policy: "",
+		},
+		{
+			name:   "delete",
+			policy: types.DeletePurgePolicy,
+		},
+		{
+			name:             "archive",
+			policy:           types.ArchivePurgePolicy,
+			expectedArchived: true,
+		},
+		{
+			name:           "keep",
+			policy:         types.KeepPurgePolicy,
+			expectedOnDisk: true,
+		},
+		{
+			name:           "policy of fetched asset",
+			assetPolicy:    types.KeepPurgePolicy,
+			expectedOnDisk: true,
+		},
+		{
+			name:             "store policy overrides fetched asset",
+			policy:           types.ArchivePurgePolicy,
+			assetPolicy:      types.KeepPurgePolicy,
+			expectedArchived: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearAssetBehaviors()
+			dir := t.TempDir()
+			store := &storeImpl{
+				directory:   dir,
+				assets:      map[reflect.Type]*assetState{},
+				purgePolicy: tc.policy,
+			}
+			consumed := &testStoreAssetB{}
+			store.assets[reflect.TypeOf(consumed)] = &assetState{asset: consumed, presentOnDisk: true}
+			if tc.assetPolicy != "" {
+				policy := &testStorePolicyAsset{policy: tc.assetPolicy}
+				store.assets[reflect.TypeOf(policy)] = &assetState{asset: policy, source: generatedSource}
+			}
+			err := ioutil.WriteFile(filepath.Join(dir, consumed.Name()), []byte("# comment"), 0640)
+			assert.NoError(t, err, "unexpected error writing consumed asset")
+
+			err = store.purge([]asset.WritableAsset{&testStoreAssetA{}})
+			assert.NoError(t, err, "unexpected error purging")
+
+			_, err = os.Stat(filepath.Join(dir, consumed.Name()))
+			assert.Equal(t, tc.expectedOnDisk, err == nil, "unexpected presence of consumed asset")
+
+			archives, err := ConsumedArchives(dir)
+			assert.NoError(t, err, "unexpected error listing archives")
+			if !tc.expectedArchived {
+				assert.Empty(t, archives)
+				return
+			}
+			if assert.Len(t, archives, 1) {
+				data, err := ioutil.ReadFile(filepath.Join(archives[0], consumed.Name()))
+				assert.NoError(t, err, "consumed asset was not archived")
+				assert.Equal(t, "# comment", string(data))
+			}
+		})
+	}
+}
+
+func TestStoreProvenance(t *testing.T) {
+	clearAssetBehaviors()
+	dir := t.TempDir()
+
+	store, err := newStore(dir)
+	if !assert.NoError(t, err, "unexpected error creating store") {
+		t.FailNow()
+	}
+	err = store.Fetch(&testStoreAssetA{})
+	if !assert.NoError(t, err, "unexpected error fetching asset") {
+		t.FailNow()
+	}
+
+	store, err = newStore(dir)
+	if !assert.NoError(t, err, "unexpected error creating store") {
+		t.FailNow()
+	}
+	found, provenance, err := store.LoadWithProvenance(&testStoreAssetA{})
+	assert.NoError(t, err, "unexpected error loading asset")
+	assert.NotNil(t, found, "asset not found in state file")
+	if assert.NotNil(t, provenance, "provenance not recorded in state file") {
+		assert.Equal(t, asset.ProvenanceGenerated, provenance.Source)
+		assert.Equal(t, version.Raw, provenance.InstallerVersion)
+		assert.False(t, provenance.Timestamp.IsZero(), "timestamp not recorded")
+		// sha256 of the empty file
+		assert.Equal(t, map[string]string{"a": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}, provenance.Files)
+	}
+}
+
+func TestStoreLegacyProvenance(t *testing.T) {
+	clearAssetBehaviors()
+	dir := t.TempDir()
+	key := reflect.TypeOf(&testStoreAssetA{}).String()
+	state := fmt.Sprintf(`{%q: {}}`, key)
+	if err := ioutil.WriteFile(filepath.Join(dir, stateFileName), []byte(state), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := newStore(dir)
+	if !assert.NoError(t, err, "unexpected error creating store") {
+		t.FailNow()
+	}
+	err = store.Fetch(&testStoreAssetA{})
+	if !assert.NoError(t, err, "unexpected error fetching asset") {
+		t.FailNow()
+	}
+
+	store, err = newStore(dir)
+	if !assert.NoError(t, err, "unexpected error creating store") {
+		t.FailNow()
+	}
+	found, provenance, err := store.LoadWithProvenance(&testStoreAssetA{})
+	assert.NoError(t, err, "unexpected error loading asset")
+	assert.NotNil(t, found, "asset not found in state file")
+	assert.Nil(t, provenance, "provenance recorded for an asset whose origin is unknown")
+}
+
+func TestStorePostGenerateHooks(t *testing.T) {
+	clearAssetBehaviors()
+	dir := t.TempDir()
+	hooksConfig := `hooks:
+- name: log
+  event: postGenerate
+  command: [sh, -c, "cat >> hooks.log && echo >> hooks.log"]
+- name: written
+  event: postGenerate
+  assets: [a]
+  command: [sh, -c, "test -f a"]
+`
+	err := ioutil.WriteFile(filepath.Join(dir, "hooks.yaml"), []byte(hooksConfig), 0640)
+	if !assert.NoError(t, err, "unexpected error writing hooks file") {
+		t.FailNow()
+	}
+	dependencies[reflect.TypeOf(&testStoreAssetA{})] = []asset.Asset{&testStoreAssetB{}}
+
+	store, err := newStore(dir)
+	if !assert.NoError(t, err, "unexpected error creating store") {
+		t.FailNow()
+	}
+	target := &testStoreAssetA{}
+	err = store.Fetch(target)
+	if !assert.NoError(t, err, "unexpected error fetching asset") {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"b"}, hookAssets(t, dir), "only the dependency should have run its hooks before the target is written")
+
+	err = store.Persist(target)
+	if !assert.NoError(t, err, "unexpected error persisting asset") {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"b", "a"}, hookAssets(t, dir), "the target should run its hooks once written")
+
+	payloads := hookPayloads(t, dir)
+	assert.Empty(t, payloads[0].Outputs)
+	assert.Equal(t, []string{filepath.Join(dir, "a")}, payloads[1].Outputs)
+}
+
+func hookPayloads(t *testing.T, dir string) []hooks.Payload {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "hooks.log"))
+	if err != nil {
+		t.Fatalf("failed to read hooks log: %v", err)
+	}
+	payloads := []hooks.Payload{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		payload := hooks.Payload{}
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			t.Fatalf("failed to unmarshal hook payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func hookAssets(t *testing.T, dir string) []string {
+	names := []string{}
+	for _, p := range hookPayloads(t, dir) {
+		names = append(names, p.Asset)
+	}
+	return names
+}
+
+// testStoreNullAsset is an asset that marshals to null.
+type testStoreNullAsset struct{}
+
+func (a *testStoreNullAsset) Name() string                 { return "null" }
+func (a *testStoreNullAsset) Dependencies() []asset.Asset  { return nil }
+func (a *testStoreNullAsset) Generate(asset.Parents) error { return nil }
+func (a *testStoreNullAsset) MarshalJSON() ([]byte, error) { return []byte("null"), nil }
+
+// testStoreStringAsset is an asset that marshals to a string.
+type testStoreStringAsset struct{}
+
+func (a *testStoreStringAsset) Name() string                 { return "string" }
+func (a *testStoreStringAsset) Dependencies() []asset.Asset  { return nil }
+func (a *testStoreStringAsset) Generate(asset.Parents) error { return nil }
+func (a *testStoreStringAsset) MarshalJSON() ([]byte, error) { return []byte(`"value"`), nil }
+
+func TestMarshalWithProvenance(t *testing.T) {
+	provenance := &asset.Provenance{Source: asset.ProvenanceGenerated}
+	cases := []struct {
+		name               string
+		asset              asset.Asset
+		provenance         *asset.Provenance
+		expectedProvenance bool
+	}{
+		{
+			name:               "object",
+			asset:              &testStorePolicyAsset{},
+			provenance:         provenance,
+			expectedProvenance: true,
+		},
+		{
+			name:  "object with unknown provenance",
+			asset: &testStorePolicyAsset{},
+		},
+		{
+			name:       "null",
+			asset:      &testStoreNullAsset{},
+			provenance: provenance,
+		},
+		{
+			name:       "string",
+			asset:      &testStoreStringAsset{},
+			provenance: provenance,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := marshalWithProvenance(tc.asset, tc.provenance)
+			if !assert.NoError(t, err, "unexpected error marshaling asset") {
+				return
+			}
+			fields := map[string]json.RawMessage{}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				expected, err := json.Marshal(tc.asset)
+				assert.NoError(t, err, "unexpected error marshaling asset")
+				assert.JSONEq(t, string(expected), string(data), "unexpected state file entry")
+				return
+			}
+			_, ok := fields[provenanceKey]
+			assert.Equal(t, tc.expectedProvenance, ok, "unexpected presence of provenance")
+		})
+	}
+}
+
+// testStoreSecretAsset is an asset that keeps a secret out of the state file.
+type testStoreSecretAsset struct {
+	Reference string `json:"reference"`
+	secret    string
+}
+
+func (a *testStoreSecretAsset) Name() string                 { return "secret" }
+func (a *testStoreSecretAsset) Dependencies() []asset.Asset  { return nil }
+func (a *testStoreSecretAsset) Generate(asset.Parents) error { return nil }
+
+func TestMatchesStateFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		onDisk   asset.Asset
+		expected bool
+	}{
+		{
+			name:     "same",
+			onDisk:   &testStoreSecretAsset{Reference: "env"},
+			expected: true,
+		},
+		{
+			name:     "resolved secret",
+			onDisk:   &testStoreSecretAsset{Reference: "env", secret: "value"},
+			expected: true,
+		},
+		{
+			name:   "changed",
+			onDisk: &testStoreSecretAsset{Reference: "file"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchesStateFile(tc.onDisk, &testStoreSecretAsset{Reference: "env"}))
+		})
+	}
+}
+
+// testStoreKeptAsset is an asset whose copy in the target directory holds a
+// value that is not recorded in the state file and that must be kept when it
+// is regenerated.
+type testStoreKeptAsset struct {
+	Generated bool `json:"generated"`
+	value     string
+}
+
+func (a *testStoreKeptAsset) Name() string { return "kept" }
+
+func (a *testStoreKeptAsset) Dependencies() []asset.Asset {
+	return []asset.Asset{&testStoreAssetA{}}
+}
+
+func (a *testStoreKeptAsset) Generate(asset.Parents) error {
+	a.Generated = true
+	if a.value == "" {
+		a.value = "generated"
+	}
+	return nil
+}
+
+func (a *testStoreKeptAsset) Files() []*asset.File {
+	return []*asset.File{{Filename: "kept", Data: []byte(a.value)}}
+}
+
+func (a *testStoreKeptAsset) Load(asset.FileFetcher) (bool, error) {
+	a.value = "on disk"
+	return true, nil
+}
+
+func (a *testStoreKeptAsset) LoadPrevious(f asset.FileFetcher) error {
+	_, err := a.Load(f)
+	return err
+}
+
+func TestStoreLoadPrevious(t *testing.T) {
+	clearAssetBehaviors()
+	onDiskAssets[reflect.TypeOf(&testStoreAssetA{})] = true
+	store := &storeImpl{
+		directory: t.TempDir(),
+		assets:    map[reflect.Type]*assetState{},
+	}
+
+	kept := &testStoreKeptAsset{}
+	if !assert.NoError(t, store.fetch(kept, ""), "unexpected error fetching asset") {
+		return
+	}
+	assert.True(t, kept.Generated, "asset not regenerated although its dependency is dirty")
+	assert.Equal(t, "on disk", kept.value, "copy in the target directory not kept")
+}
+
+func TestStoreOnDiskMatchesStateFile(t *testing.T) {
+	clearAssetBehaviors()
+	dir := t.TempDir()
+	state := fmt.Sprintf(`{%q: {}, %q: {"generated": false}}`, reflect.TypeOf(&testStoreAssetA{}).String(), reflect.TypeOf(&testStoreKeptAsset{}).String())
+	if err := ioutil.WriteFile(filepath.Join(dir, stateFileName), []byte(state), 0640); err != nil {
+		t.Fatal(err)
+	}
+	store, err := newStore(dir)
+	if !assert.NoError(t, err, "unexpected error creating store") {
+		t.FailNow()
+	}
+
+	loaded, err := store.Load(&testStoreKeptAsset{})
+	if assert.NoError(t, err, "unexpected error loading asset") {
+		assert.Equal(t, "on disk", loaded.(*testStoreKeptAsset).value, "what the state file leaves out was not taken from the matching on-disk copy")
+	}
+}
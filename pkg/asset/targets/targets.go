@@ -5,12 +5,15 @@ import (
 	"github.com/bailey84j/terraform_installer/pkg/asset/cluster"
 	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
 	"github.com/bailey84j/terraform_installer/pkg/asset/password"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tfe"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tls"
 )
 
 var (
 	// InstallConfig are the install-config targeted assets.
 	InstallConfig = []asset.WritableAsset{
 		&installconfig.InstallConfig{},
+		&installconfig.SSHKeyPair{},
 	}
 
 	// Manifests are the manifests targeted assets.
@@ -58,10 +61,14 @@ var (
 		//&cluster.Metadata{},
 		//&machine.MasterIgnitionCustomizations{},
 		//&machine.WorkerIgnitionCustomizations{},
+		&installconfig.SSHKeyPair{},
+		&tfe.Settings{},
+		&tfe.EncryptionPassword{},
+		&tfe.UserData{},
 		&cluster.TerraformVariables{},
 		//&kubeconfig.AdminClient{},
 		&password.TFEPassword{},
-		//&tls.JournalCertKey{},
+		&tls.RootCA{},
 		&cluster.Cluster{},
 	}
 )
@@ -0,0 +1,120 @@
+package tfe
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+)
+
+var (
+	// EncryptionPasswordFilename is the path of the generated encryption
+	// password.
+	EncryptionPasswordFilename = filepath.Join("auth", "tfe-encryption-password")
+)
+
+// EncryptionPassword is the password that Terraform Enterprise encrypts its
+// data with when the install config does not set one. It is generated once and
+// written to auth/tfe-encryption-password, readable only by its owner, since
+// the data cannot be recovered without it. The password is read back from that
+// file by later runs, also when the install config changes, and only whether
+// the install config provides the password is kept in the state file.
+type EncryptionPassword struct {
+	Provided bool `json:"provided,omitempty"`
+
+	password []byte
+}
+
+var _ asset.WritableAsset = (*EncryptionPassword)(nil)
+var _ asset.FileWriter = (*EncryptionPassword)(nil)
+var _ asset.PreviousLoader = (*EncryptionPassword)(nil)
+
+// Dependencies returns the dependencies of the encryption password.
+func (a *EncryptionPassword) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&installconfig.InstallConfig{},
+	}
+}
+
+// Generate generates a random encryption password, unless the install config
+// provides one or the password was already generated.
+func (a *EncryptionPassword) Generate(parents asset.Parents) error {
+	installConfig := &installconfig.InstallConfig{}
+	parents.Get(installConfig)
+	if err := installConfig.ResolveSecrets(); err != nil {
+		return err
+	}
+
+	if config := installConfig.Config.TFE; config != nil && config.EncryptionPassword != "" {
+		a.Provided, a.password = true, nil
+		return nil
+	}
+	a.Provided = false
+	if a.password != nil {
+		return nil
+	}
+	password, err := generateEncryptionPassword()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate the encryption password")
+	}
+	a.password = []byte(password)
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *EncryptionPassword) Name() string {
+	return "TFE Encryption Password"
+}
+
+// Files returns the password file, unless the install config provides the
+// password.
+func (a *EncryptionPassword) Files() []*asset.File {
+	if a.password == nil {
+		return []*asset.File{}
+	}
+	return []*asset.File{{Filename: EncryptionPasswordFilename, Data: a.password}}
+}
+
+// PersistToFile writes the generated password, readable only by its owner. A
+// password file is never removed, even when the install config provides the
+// password, since it may be the only copy of a password that is in use.
+func (a *EncryptionPassword) PersistToFile(directory string) error {
+	if a.password == nil {
+		return nil
+	}
+	path := filepath.Join(directory, EncryptionPasswordFilename)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return errors.Wrap(err, "failed to create dir")
+	}
+	return errors.Wrap(asset.WriteFileAtomic(path, a.password, 0600), "failed to write the encryption password")
+}
+
+// Load reads the password generated by an earlier run.
+func (a *EncryptionPassword) Load(f asset.FileFetcher) (bool, error) {
+	file, err := f.FetchByName(EncryptionPasswordFilename)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return false, nil
+		}
+		return false, err
+	}
+	a.password = bytes.TrimSuffix(file.Data, []byte("\n"))
+	return true, nil
+}
+
+// LoadPrevious reads the password generated by an earlier run, so that it is
+// kept when the install config changes.
+func (a *EncryptionPassword) LoadPrevious(f asset.FileFetcher) error {
+	_, err := a.Load(f)
+	return err
+}
+
+// Password returns the generated password. It is empty when the install config
+// provides the password, or when the password file of an earlier run is gone.
+func (a *EncryptionPassword) Password() string {
+	return string(a.password)
+}
@@ -0,0 +1,129 @@
+package tfe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/asset/store"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+func TestEncryptionPasswordGenerate(t *testing.T) {
+	cases := []struct {
+		name             string
+		tfe              types.TFE
+		previous         []byte
+		expectedProvided bool
+		expectedPassword string
+	}{
+		{
+			name: "generated",
+		},
+		{
+			name:             "provided",
+			tfe:              types.TFE{EncryptionPassword: "user-supplied-password"},
+			previous:         []byte("previous-password"),
+			expectedProvided: true,
+		},
+		{
+			name:             "previous password kept",
+			previous:         []byte("previous-password"),
+			expectedPassword: "previous-password",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parents := asset.Parents{}
+			parents.Add(&installconfig.InstallConfig{Config: &types.InstallConfig{TFE: &tc.tfe}})
+			a := &EncryptionPassword{password: tc.previous}
+			if !assert.NoError(t, a.Generate(parents)) {
+				return
+			}
+
+			assert.Equal(t, tc.expectedProvided, a.Provided)
+			switch {
+			case tc.expectedProvided:
+				assert.Empty(t, a.Files())
+			case tc.expectedPassword != "":
+				assert.Equal(t, tc.expectedPassword, a.Password())
+			default:
+				assert.Len(t, a.Password(), 32)
+			}
+
+			data, err := json.Marshal(a)
+			if assert.NoError(t, err) {
+				assert.NotContains(t, string(data), "user-supplied-password")
+				if a.password != nil {
+					assert.NotContains(t, string(data), a.Password())
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptionPasswordPersistToFile(t *testing.T) {
+	cases := []struct {
+		name         string
+		password     *EncryptionPassword
+		expectedFile string
+	}{
+		{
+			name:         "generated",
+			password:     &EncryptionPassword{password: []byte("generated")},
+			expectedFile: "generated",
+		},
+		{
+			name:         "provided keeps the previous password",
+			password:     &EncryptionPassword{Provided: true},
+			expectedFile: "previous",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "auth", "tfe-encryption-password")
+			if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte("previous"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if !assert.NoError(t, tc.password.PersistToFile(dir)) {
+				return
+			}
+			info, err := os.Stat(path)
+			if assert.NoError(t, err) {
+				assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+			}
+			data, err := ioutil.ReadFile(path)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedFile, string(data))
+			}
+		})
+	}
+}
+
+func TestEncryptionPasswordLoad(t *testing.T) {
+	dir := t.TempDir()
+	a := &EncryptionPassword{}
+	found, err := a.Load(store.NewFileFetcher(dir))
+	if assert.NoError(t, err) {
+		assert.False(t, found, "password found without a password file")
+	}
+
+	if err := (&EncryptionPassword{password: []byte("generated")}).PersistToFile(dir); err != nil {
+		t.Fatal(err)
+	}
+	found, err = a.Load(store.NewFileFetcher(dir))
+	if assert.NoError(t, err) {
+		assert.True(t, found, "password file not found")
+		assert.Equal(t, "generated", a.Password())
+	}
+}
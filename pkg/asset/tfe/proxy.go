@@ -0,0 +1,89 @@
+package tfe
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+	"github.com/bailey84j/terraform_installer/pkg/types/azure"
+)
+
+// metadataEndpoint is the link-local address of the instance metadata
+// service on AWS and Azure.
+const metadataEndpoint = "169.254.169.254"
+
+// Proxy returns the proxy settings for the instances, with the endpoints that
+// must always be reached directly added to the user's noProxy. It returns nil
+// if the install config has no proxy.
+func Proxy(installConfig *types.InstallConfig) *types.Proxy {
+	if installConfig.Proxy == nil {
+		return nil
+	}
+	p := *installConfig.Proxy
+	if p.NoProxy != "*" {
+		p.NoProxy = noProxy(installConfig)
+	}
+	return &p
+}
+
+// noProxy returns the comma-separated, sorted union of the user's noProxy,
+// localhost, the cluster domain, the machine networks, the cloud metadata
+// endpoints and the hosts of the custom AWS service endpoints, which are
+// usually VPC endpoints that the proxy cannot reach.
+func noProxy(installConfig *types.InstallConfig) string {
+	set := sets.NewString(
+		"127.0.0.1",
+		"localhost",
+		"."+installConfig.ClusterDomain(),
+	)
+
+	if installConfig.Networking != nil {
+		for _, network := range installConfig.Networking.MachineNetwork {
+			set.Insert(network.CIDR.String())
+		}
+	}
+
+	switch installConfig.Platform.Name() {
+	case aws.Name:
+		set.Insert(metadataEndpoint)
+		region := installConfig.Platform.AWS.Region
+		if region == "us-east-1" {
+			set.Insert(".ec2.internal")
+		} else {
+			set.Insert(fmt.Sprintf(".%s.compute.internal", region))
+		}
+		for _, endpoint := range installConfig.Platform.AWS.ServiceEndpoints {
+			if u, err := url.Parse(endpoint.URL); err == nil && u.Hostname() != "" {
+				set.Insert(u.Hostname())
+			}
+		}
+	case azure.Name:
+		set.Insert(metadataEndpoint)
+	}
+
+	for _, userValue := range strings.Split(installConfig.Proxy.NoProxy, ",") {
+		if userValue = strings.TrimSpace(userValue); userValue != "" {
+			set.Insert(userValue)
+		}
+	}
+
+	return strings.Join(set.List(), ",")
+}
+
+// AdditionalTrustBundle returns the CA bundle to add to the trust store of the
+// instances, following the additionalTrustBundlePolicy of the install config.
+func AdditionalTrustBundle(installConfig *types.InstallConfig) string {
+	switch installConfig.AdditionalTrustBundlePolicy {
+	case types.PolicyAlways:
+		return installConfig.AdditionalTrustBundle
+	case types.PolicyProxyOnly:
+		if installConfig.Proxy != nil {
+			return installConfig.AdditionalTrustBundle
+		}
+	}
+	return ""
+}
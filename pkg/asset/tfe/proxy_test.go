@@ -0,0 +1,101 @@
+package tfe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bailey84j/terraform_installer/pkg/ipnet"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+	"github.com/bailey84j/terraform_installer/pkg/types/azure"
+)
+
+func TestProxy(t *testing.T) {
+	cases := []struct {
+		name     string
+		platform types.Platform
+		proxy    *types.Proxy
+		expected *types.Proxy
+	}{
+		{
+			name:     "no proxy",
+			platform: types.Platform{AWS: &aws.Platform{Region: "eu-west-1"}},
+		},
+		{
+			name:     "no proxy for any host",
+			platform: types.Platform{AWS: &aws.Platform{Region: "eu-west-1"}},
+			proxy:    &types.Proxy{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: "*"},
+			expected: &types.Proxy{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: "*"},
+		},
+		{
+			name:     "aws",
+			platform: types.Platform{AWS: &aws.Platform{Region: "eu-west-1"}},
+			proxy:    &types.Proxy{HTTPSProxy: "http://proxy.example.com:3128"},
+			expected: &types.Proxy{
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    ".eu-west-1.compute.internal,.test.example.com,10.0.0.0/16,127.0.0.1,169.254.169.254,localhost",
+			},
+		},
+		{
+			name:     "aws in us-east-1",
+			platform: types.Platform{AWS: &aws.Platform{Region: "us-east-1"}},
+			proxy:    &types.Proxy{HTTPProxy: "http://proxy.example.com:3128"},
+			expected: &types.Proxy{
+				HTTPProxy: "http://proxy.example.com:3128",
+				NoProxy:   ".ec2.internal,.test.example.com,10.0.0.0/16,127.0.0.1,169.254.169.254,localhost",
+			},
+		},
+		{
+			name: "aws with service endpoints",
+			platform: types.Platform{AWS: &aws.Platform{
+				Region: "eu-west-1",
+				ServiceEndpoints: []aws.ServiceEndpoint{
+					{Name: "s3", URL: "https://s3.eu-west-1.amazonaws.com"},
+					{Name: "sts", URL: "https://vpce-0123.sts.eu-west-1.vpce.amazonaws.com:443/path"},
+				},
+			}},
+			proxy: &types.Proxy{HTTPSProxy: "http://proxy.example.com:3128"},
+			expected: &types.Proxy{
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    ".eu-west-1.compute.internal,.test.example.com,10.0.0.0/16,127.0.0.1,169.254.169.254,localhost,s3.eu-west-1.amazonaws.com,vpce-0123.sts.eu-west-1.vpce.amazonaws.com",
+			},
+		},
+		{
+			name:     "azure",
+			platform: types.Platform{Azure: &azure.Platform{Region: "westeurope"}},
+			proxy:    &types.Proxy{HTTPSProxy: "http://proxy.example.com:3128"},
+			expected: &types.Proxy{
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    ".test.example.com,10.0.0.0/16,127.0.0.1,169.254.169.254,localhost",
+			},
+		},
+		{
+			name:     "user noProxy is deduplicated",
+			platform: types.Platform{AWS: &aws.Platform{Region: "eu-west-1"}},
+			proxy: &types.Proxy{
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    " .corp.example.com,localhost,,10.0.0.0/16, .corp.example.com ,169.254.169.254",
+			},
+			expected: &types.Proxy{
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    ".corp.example.com,.eu-west-1.compute.internal,.test.example.com,10.0.0.0/16,127.0.0.1,169.254.169.254,localhost",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			installConfig := &types.InstallConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				BaseDomain: "example.com",
+				Networking: &types.Networking{
+					MachineNetwork: []types.MachineNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.0.0.0/16")}},
+				},
+				Platform: tc.platform,
+				Proxy:    tc.proxy,
+			}
+			assert.Equal(t, tc.expected, Proxy(installConfig))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Package tfe generates the configuration of the Terraform Enterprise
+// application that runs on the instances.
+package tfe
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+const (
+	// tlsCertPath and tlsKeyPath are where the TLS certificate and key are
+	// placed on the instances.
+	tlsCertPath = "/etc/tfe/tls/cert.pem"
+	tlsKeyPath  = "/etc/tfe/tls/key.pem"
+
+	encryptionPasswordLen = 32
+)
+
+var (
+	// SettingsFilename is the path of the application settings file.
+	SettingsFilename = filepath.Join("tfe", "settings.json")
+)
+
+// Settings is the application settings file of Terraform Enterprise, in the
+// format that the installer on the instances reads: a JSON object that maps
+// every setting to {"value": <string>}.
+type Settings struct {
+	File *asset.File
+}
+
+var _ asset.WritableAsset = (*Settings)(nil)
+
+// Dependencies returns the dependencies of the settings.
+func (a *Settings) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&installconfig.InstallConfig{},
+	}
+}
+
+// Generate renders the settings from the tfe section of the install config.
+// They hold no secrets, which are rendered apart by SecretSettings.
+func (a *Settings) Generate(parents asset.Parents) error {
+	installConfig := &installconfig.InstallConfig{}
+	parents.Get(installConfig)
+
+	data, err := json.MarshalIndent(settings(installConfig.Config.TFE), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the TFE settings")
+	}
+	a.File = &asset.File{
+		Filename: SettingsFilename,
+		Data:     data,
+	}
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *Settings) Name() string {
+	return "TFE Settings"
+}
+
+// Files returns the settings file.
+func (a *Settings) Files() []*asset.File {
+	if a.File != nil {
+		return []*asset.File{a.File}
+	}
+	return []*asset.File{}
+}
+
+// Load never loads the settings from disk. They are always rendered from the
+// install config.
+func (a *Settings) Load(asset.FileFetcher) (bool, error) {
+	return false, nil
+}
+
+type setting struct {
+	Value string `json:"value"`
+}
+
+// SecretSettings renders the settings that are kept out of the settings
+// file, which the instances merge into it: the encryption password and the
+// password of the external PostgreSQL database. The encryption password is
+// that of the install config, or encryptionPassword if it has none.
+func SecretSettings(config *types.TFE, encryptionPassword string) ([]byte, error) {
+	data, err := json.MarshalIndent(secretSettings(config, encryptionPassword), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the TFE secret settings")
+	}
+	return data, nil
+}
+
+// secretSettings returns the secret application settings for the
+// configuration.
+func secretSettings(config *types.TFE, encryptionPassword string) map[string]setting {
+	if config.EncryptionPassword != "" {
+		encryptionPassword = config.EncryptionPassword
+	}
+	s := map[string]setting{
+		"enc_password": {Value: encryptionPassword},
+	}
+	if config.OperationalMode == types.ExternalServicesOperationalMode && config.ExternalServices != nil {
+		s["pg_password"] = setting{Value: config.ExternalServices.Postgres.Password}
+	}
+	return s
+}
+
+// settings returns the application settings for the configuration, without
+// the secret ones.
+func settings(config *types.TFE) map[string]setting {
+	s := map[string]setting{
+		"hostname":             {Value: config.Hostname},
+		"capacity_concurrency": {Value: strconv.Itoa(config.CapacityConcurrency)},
+	}
+
+	switch config.OperationalMode {
+	case types.DemoOperationalMode:
+		s["installation_type"] = setting{Value: "poc"}
+	case types.MountedDiskOperationalMode:
+		s["installation_type"] = setting{Value: "production"}
+		s["production_type"] = setting{Value: "disk"}
+		s["disk_path"] = setting{Value: config.DiskPath}
+	case types.ExternalServicesOperationalMode:
+		s["installation_type"] = setting{Value: "production"}
+		s["production_type"] = setting{Value: "external"}
+		if es := config.ExternalServices; es != nil {
+			externalServicesSettings(s, es)
+		}
+	}
+
+	switch config.TLSSource {
+	case types.SelfSignedTLSSource:
+		s["tls_bootstrap_type"] = setting{Value: "self-signed"}
+	case types.GeneratedTLSSource, types.ProvidedTLSSource:
+		s["tls_bootstrap_type"] = setting{Value: "server-path"}
+		s["tls_bootstrap_cert"] = setting{Value: tlsCertPath}
+		s["tls_bootstrap_key"] = setting{Value: tlsKeyPath}
+	}
+
+	if len(config.IACTSubnets) > 0 {
+		subnets := make([]string, 0, len(config.IACTSubnets))
+		for _, subnet := range config.IACTSubnets {
+			subnets = append(subnets, subnet.String())
+		}
+		s["iact_subnet_list"] = setting{Value: strings.Join(subnets, ",")}
+	}
+	return s
+}
+
+// externalServicesSettings adds the settings of the external PostgreSQL
+// database, but for its password, and of the object storage to s. The
+// instances reach the object storage with the credentials of their instance
+// profile, unless the secret settings hand them the installer's credentials
+// instead.
+func externalServicesSettings(s map[string]setting, es *types.ExternalServices) {
+	pg := &es.Postgres
+	s["pg_netloc"] = setting{Value: pg.Address()}
+	s["pg_dbname"] = setting{Value: pg.Database}
+	s["pg_user"] = setting{Value: pg.User}
+	s["pg_extra_params"] = setting{Value: "sslmode=" + string(pg.SSLMode)}
+
+	storage := &es.ObjectStorage
+	s["placement"] = setting{Value: "placement_s3"}
+	s["aws_instance_profile"] = setting{Value: "1"}
+	s["s3_bucket"] = setting{Value: storage.Bucket}
+	s["s3_region"] = setting{Value: storage.Region}
+	if storage.KMSKeyARN != "" {
+		s["s3_sse"] = setting{Value: "aws:kms"}
+		s["s3_sse_kms_key_id"] = setting{Value: storage.KMSKeyARN}
+	}
+	if storage.Endpoint != "" {
+		s["s3_endpoint"] = setting{Value: storage.Endpoint}
+	}
+}
+
+func generateEncryptionPassword() (string, error) {
+	b := make([]byte, encryptionPasswordLen/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
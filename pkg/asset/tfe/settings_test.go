@@ -0,0 +1,247 @@
+package tfe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/ipnet"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+func TestSettings(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   types.TFE
+		expected map[string]string
+	}{
+		{
+			name: "demo with a self-signed certificate",
+			config: types.TFE{
+				Hostname:            "tfe.test.example.com",
+				OperationalMode:     types.DemoOperationalMode,
+				CapacityConcurrency: 10,
+				EncryptionPassword:  "secret",
+				TLSSource:           types.SelfSignedTLSSource,
+			},
+			expected: map[string]string{
+				"hostname":             "tfe.test.example.com",
+				"capacity_concurrency": "10",
+				"installation_type":    "poc",
+				"tls_bootstrap_type":   "self-signed",
+			},
+		},
+		{
+			name: "mounted disk with a provided certificate",
+			config: types.TFE{
+				Hostname:            "tfe.example.com",
+				OperationalMode:     types.MountedDiskOperationalMode,
+				DiskPath:            "/data",
+				CapacityConcurrency: 5,
+				EncryptionPassword:  "secret",
+				TLSSource:           types.ProvidedTLSSource,
+			},
+			expected: map[string]string{
+				"hostname":             "tfe.example.com",
+				"capacity_concurrency": "5",
+				"installation_type":    "production",
+				"production_type":      "disk",
+				"disk_path":            "/data",
+				"tls_bootstrap_type":   "server-path",
+				"tls_bootstrap_cert":   "/etc/tfe/tls/cert.pem",
+				"tls_bootstrap_key":    "/etc/tfe/tls/key.pem",
+			},
+		},
+		{
+			name: "demo with a generated certificate",
+			config: types.TFE{
+				Hostname:            "tfe.test.example.com",
+				OperationalMode:     types.DemoOperationalMode,
+				CapacityConcurrency: 10,
+				EncryptionPassword:  "secret",
+				TLSSource:           types.GeneratedTLSSource,
+			},
+			expected: map[string]string{
+				"hostname":             "tfe.test.example.com",
+				"capacity_concurrency": "10",
+				"installation_type":    "poc",
+				"tls_bootstrap_type":   "server-path",
+				"tls_bootstrap_cert":   "/etc/tfe/tls/cert.pem",
+				"tls_bootstrap_key":    "/etc/tfe/tls/key.pem",
+			},
+		},
+		{
+			name: "IACT subnets",
+			config: types.TFE{
+				Hostname:            "tfe.test.example.com",
+				OperationalMode:     types.DemoOperationalMode,
+				CapacityConcurrency: 10,
+				EncryptionPassword:  "secret",
+				TLSSource:           types.SelfSignedTLSSource,
+				IACTSubnets: []ipnet.IPNet{
+					*ipnet.MustParseCIDR("10.0.0.0/16"),
+					*ipnet.MustParseCIDR("192.0.2.10/32"),
+				},
+			},
+			expected: map[string]string{
+				"hostname":             "tfe.test.example.com",
+				"capacity_concurrency": "10",
+				"installation_type":    "poc",
+				"tls_bootstrap_type":   "self-signed",
+				"iact_subnet_list":     "10.0.0.0/16,192.0.2.10/32",
+			},
+		},
+		{
+			name: "external services",
+			config: types.TFE{
+				Hostname:            "tfe.example.com",
+				OperationalMode:     types.ExternalServicesOperationalMode,
+				CapacityConcurrency: 10,
+				EncryptionPassword:  "secret",
+				TLSSource:           types.SelfSignedTLSSource,
+				ExternalServices: &types.ExternalServices{
+					Postgres: types.Postgres{
+						Host:     "db.example.com",
+						Database: "tfe",
+						User:     "tfe",
+						Password: "pg-secret",
+						SSLMode:  types.PostgresSSLModeRequire,
+					},
+					ObjectStorage: types.ObjectStorage{
+						Bucket: "tfe-data",
+						Region: "us-east-1",
+					},
+				},
+			},
+			expected: map[string]string{
+				"hostname":             "tfe.example.com",
+				"capacity_concurrency": "10",
+				"installation_type":    "production",
+				"production_type":      "external",
+				"tls_bootstrap_type":   "self-signed",
+				"pg_netloc":            "db.example.com:5432",
+				"pg_dbname":            "tfe",
+				"pg_user":              "tfe",
+				"pg_extra_params":      "sslmode=require",
+				"placement":            "placement_s3",
+				"aws_instance_profile": "1",
+				"s3_bucket":            "tfe-data",
+				"s3_region":            "us-east-1",
+			},
+		},
+		{
+			name: "external services with KMS and a custom endpoint",
+			config: types.TFE{
+				Hostname:            "tfe.example.com",
+				OperationalMode:     types.ExternalServicesOperationalMode,
+				CapacityConcurrency: 10,
+				EncryptionPassword:  "secret",
+				TLSSource:           types.SelfSignedTLSSource,
+				ExternalServices: &types.ExternalServices{
+					Postgres: types.Postgres{
+						Host:     "10.0.0.5:6432",
+						Database: "tfe",
+						User:     "tfe",
+						Password: "pg-secret",
+						SSLMode:  types.PostgresSSLModeVerifyFull,
+					},
+					ObjectStorage: types.ObjectStorage{
+						Bucket:    "tfe-data",
+						Region:    "us-east-1",
+						KMSKeyARN: "arn:aws:kms:us-east-1:123456789012:key/1234abcd",
+						Endpoint:  "https://s3.example.com",
+					},
+				},
+			},
+			expected: map[string]string{
+				"hostname":             "tfe.example.com",
+				"capacity_concurrency": "10",
+				"installation_type":    "production",
+				"production_type":      "external",
+				"tls_bootstrap_type":   "self-signed",
+				"pg_netloc":            "10.0.0.5:6432",
+				"pg_dbname":            "tfe",
+				"pg_user":              "tfe",
+				"pg_extra_params":      "sslmode=verify-full",
+				"placement":            "placement_s3",
+				"aws_instance_profile": "1",
+				"s3_bucket":            "tfe-data",
+				"s3_region":            "us-east-1",
+				"s3_sse":               "aws:kms",
+				"s3_sse_kms_key_id":    "arn:aws:kms:us-east-1:123456789012:key/1234abcd",
+				"s3_endpoint":          "https://s3.example.com",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := map[string]string{}
+			for k, v := range settings(&tc.config) {
+				actual[k] = v.Value
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSecretSettings(t *testing.T) {
+	cases := []struct {
+		name               string
+		config             types.TFE
+		encryptionPassword string
+		expected           map[string]string
+	}{
+		{
+			name:               "generated encryption password",
+			config:             types.TFE{OperationalMode: types.DemoOperationalMode},
+			encryptionPassword: "generated",
+			expected: map[string]string{
+				"enc_password": "generated",
+			},
+		},
+		{
+			name: "external services",
+			config: types.TFE{
+				OperationalMode: types.ExternalServicesOperationalMode,
+				ExternalServices: &types.ExternalServices{
+					Postgres: types.Postgres{Host: "db.example.com", Password: "pg-secret"},
+				},
+			},
+			encryptionPassword: "generated",
+			expected: map[string]string{
+				"enc_password": "generated",
+				"pg_password":  "pg-secret",
+			},
+		},
+		{
+			name:               "encryption password of the install config",
+			config:             types.TFE{OperationalMode: types.DemoOperationalMode, EncryptionPassword: "secret"},
+			encryptionPassword: "generated",
+			expected: map[string]string{
+				"enc_password": "secret",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := map[string]string{}
+			for k, v := range secretSettings(&tc.config, tc.encryptionPassword) {
+				actual[k] = v.Value
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGenerateEncryptionPassword(t *testing.T) {
+	a, err := generateEncryptionPassword()
+	if !assert.NoError(t, err) {
+		return
+	}
+	b, err := generateEncryptionPassword()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, a, encryptionPasswordLen)
+	assert.NotEqual(t, a, b)
+}
@@ -0,0 +1,423 @@
+package tfe
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/asset/tls"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	typesaws "github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+const (
+	// MaxUserDataSize is the largest user data EC2 accepts, in bytes.
+	MaxUserDataSize = 16384
+
+	// LicenceObjectKey is the key of the licence in the bootstrap bucket,
+	// where the instances fetch it from.
+	LicenceObjectKey = "tfe-licence.rli"
+
+	// TLSKeyObjectKey is the key of the TLS private key in the bootstrap
+	// bucket.
+	TLSKeyObjectKey = "tfe-tls-key.pem"
+
+	// SecretSettingsObjectKey is the key of the secret settings in the
+	// bootstrap bucket. They are merged into the settings on the instances.
+	SecretSettingsObjectKey = "tfe-secret-settings.json"
+
+	settingsPath       = "/etc/tfe/settings.json"
+	secretSettingsPath = "/etc/tfe/secret-settings.json"
+	licencePath        = "/etc/tfe/licence.rli"
+	proxyPath          = "/etc/profile.d/tfe-proxy.sh"
+
+	// dockerProxyPath is the drop-in that passes the proxy to the Docker
+	// daemon, which pulls the images of Terraform Enterprise.
+	dockerProxyPath = "/etc/systemd/system/docker.service.d/http-proxy.conf"
+
+	// registriesPath is the registries configuration of the container tools
+	// that read containers-registries.conf, and dockerDaemonPath the
+	// configuration of the Docker daemon, which only mirrors Docker Hub.
+	registriesPath   = "/etc/containers/registries.conf"
+	dockerDaemonPath = "/etc/docker/daemon.json"
+
+	// airgapDir is where the airgap package and the bootstrapper archive
+	// are fetched to, and the bootstrapper is extracted.
+	airgapDir              = "/var/lib/tfe"
+	airgapPackagePath      = airgapDir + "/tfe.airgap"
+	airgapBootstrapperPath = airgapDir + "/replicated.tar.gz"
+
+	// dataDiskPath is the script that formats and mounts the data volume
+	// in the MountedDisk operational mode, and dataLabel the label of its
+	// file system.
+	dataDiskPath = "/usr/local/sbin/tfe-data-disk"
+	dataLabel    = "tfe-data"
+)
+
+// DataVolumeDevice is the device name that the data volume of the control
+// plane machines is mapped to in the MountedDisk operational mode.
+const DataVolumeDevice = "/dev/sdf"
+
+var (
+	// UserDataFilename is the path of the cloud-init user data file.
+	UserDataFilename = filepath.Join("tfe", "user-data.yaml")
+)
+
+// UserData is the cloud-init user data of the Terraform Enterprise instances.
+// It installs the packages the instances need, configures the proxy, the
+// trusted CAs and the image mirrors, places the settings and the TLS
+// certificate of Terraform Enterprise, fetches the licence and the secret
+// settings and, for airgap installs, the airgap package and bootstrapper, and
+// mounts the data disk. It holds no secrets, which are only fetched from the
+// bootstrap bucket.
+type UserData struct {
+	File *asset.File
+}
+
+var _ asset.WritableAsset = (*UserData)(nil)
+
+// Dependencies returns the dependencies of the user data.
+func (a *UserData) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&installconfig.ClusterID{},
+		&installconfig.InstallConfig{},
+		&Settings{},
+		&tls.TFECertKey{},
+	}
+}
+
+// Generate renders the user data and checks that EC2 accepts its size.
+func (a *UserData) Generate(parents asset.Parents) error {
+	clusterID := &installconfig.ClusterID{}
+	installConfig := &installconfig.InstallConfig{}
+	settings := &Settings{}
+	certKey := &tls.TFECertKey{}
+	parents.Get(clusterID, installConfig, settings, certKey)
+
+	config := installConfig.Config
+	sources := &userDataSources{
+		TFE:                   config.TFE,
+		Proxy:                 Proxy(config),
+		AdditionalTrustBundle: AdditionalTrustBundle(config),
+		Settings:              settings.File.Data,
+		TLSCert:               certKey.CertRaw,
+		CABundle:              certKey.CABundle,
+		ImageMirrors:          config.ImageMirrors,
+		Airgap:                config.Airgap,
+	}
+	if config.Platform.Name() == typesaws.Name {
+		sources.LicenceURL = fmt.Sprintf("s3://%s-bootstrap/%s", clusterID.InfraID, LicenceObjectKey)
+		sources.SecretSettingsURL = fmt.Sprintf("s3://%s-bootstrap/%s", clusterID.InfraID, SecretSettingsObjectKey)
+		if len(certKey.KeyRaw) > 0 {
+			sources.TLSKeyURL = fmt.Sprintf("s3://%s-bootstrap/%s", clusterID.InfraID, TLSKeyObjectKey)
+		}
+		sources.Region = config.Platform.AWS.Region
+	}
+
+	data, err := userData(sources)
+	if err != nil {
+		return err
+	}
+	a.File = &asset.File{
+		Filename: UserDataFilename,
+		Data:     data,
+	}
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *UserData) Name() string {
+	return "TFE User Data"
+}
+
+// Files returns the user data file.
+func (a *UserData) Files() []*asset.File {
+	if a.File != nil {
+		return []*asset.File{a.File}
+	}
+	return []*asset.File{}
+}
+
+// Load never loads the user data from disk. It is always rendered from the
+// install config.
+func (a *UserData) Load(asset.FileFetcher) (bool, error) {
+	return false, nil
+}
+
+// userDataSources are the inputs of the user data.
+type userDataSources struct {
+	TFE                   *types.TFE
+	Proxy                 *types.Proxy
+	AdditionalTrustBundle string
+	Settings              []byte
+	TLSCert               []byte
+	CABundle              []byte
+
+	ImageMirrors []types.ImageMirror
+	Airgap       *types.Airgap
+
+	// LicenceURL is the s3:// URL the licence is fetched from, and Region
+	// the region of the instances, where the s3:// URLs are fetched from.
+	// No licence is fetched if LicenceURL is empty.
+	LicenceURL string
+	Region     string
+
+	// SecretSettingsURL is the s3:// URL of the settings that are merged
+	// into Settings on the instances, and TLSKeyURL that of the private key
+	// of TLSCert, if any.
+	SecretSettingsURL string
+	TLSKeyURL         string
+}
+
+// cloudConfig is the subset of the cloud-config format of cloud-init that the
+// user data uses.
+type cloudConfig struct {
+	PackageUpdate bool        `json:"package_update,omitempty"`
+	Packages      []string    `json:"packages,omitempty"`
+	Apt           *aptConfig  `json:"apt,omitempty"`
+	CACerts       *caCerts    `json:"ca_certs,omitempty"`
+	WriteFiles    []writeFile `json:"write_files,omitempty"`
+	RunCmd        [][]string  `json:"runcmd,omitempty"`
+}
+
+type aptConfig struct {
+	HTTPProxy  string `json:"http_proxy,omitempty"`
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+}
+
+type caCerts struct {
+	Trusted []string `json:"trusted"`
+}
+
+type writeFile struct {
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Permissions string `json:"permissions"`
+}
+
+// userData renders the cloud-config user data. It fails if the result is
+// larger than EC2 accepts.
+func userData(sources *userDataSources) ([]byte, error) {
+	c := &cloudConfig{
+		PackageUpdate: true,
+	}
+
+	urls := []string{sources.LicenceURL, sources.SecretSettingsURL, sources.TLSKeyURL}
+	if a := sources.Airgap; a != nil {
+		urls = append(urls, a.PackageURL, a.BootstrapperURL)
+	}
+	for _, u := range urls {
+		if strings.HasPrefix(u, "s3://") {
+			c.Packages = append(c.Packages, "awscli")
+			break
+		}
+	}
+	if sources.SecretSettingsURL != "" {
+		c.Packages = append(c.Packages, "jq")
+	}
+	mountedDisk := sources.TFE.OperationalMode == types.MountedDiskOperationalMode
+	if mountedDisk {
+		c.Packages = append(c.Packages, "nvme-cli")
+	}
+
+	if p := sources.Proxy; p != nil {
+		// Only apt takes the proxy from the cloud-config; other package
+		// managers must reach their repositories directly.
+		c.Apt = &aptConfig{HTTPProxy: p.HTTPProxy, HTTPSProxy: p.HTTPSProxy}
+		c.WriteFiles = append(c.WriteFiles,
+			writeFile{Path: proxyPath, Content: proxyProfile(p), Permissions: "0644"},
+			writeFile{Path: dockerProxyPath, Content: dockerProxyDropIn(p), Permissions: "0644"},
+		)
+	}
+
+	var trusted []string
+	for _, bundle := range []string{sources.AdditionalTrustBundle, string(sources.CABundle)} {
+		if bundle = strings.TrimSpace(bundle); bundle != "" {
+			trusted = append(trusted, bundle+"\n")
+		}
+	}
+	if len(trusted) > 0 {
+		c.CACerts = &caCerts{Trusted: trusted}
+	}
+
+	if len(sources.ImageMirrors) > 0 {
+		c.WriteFiles = append(c.WriteFiles, writeFile{Path: registriesPath, Content: registriesConf(sources.ImageMirrors), Permissions: "0644"})
+		if daemon, err := dockerDaemonConfig(sources.ImageMirrors); err != nil {
+			return nil, err
+		} else if daemon != "" {
+			c.WriteFiles = append(c.WriteFiles, writeFile{Path: dockerDaemonPath, Content: daemon, Permissions: "0644"})
+		}
+	}
+
+	c.WriteFiles = append(c.WriteFiles, writeFile{Path: settingsPath, Content: string(sources.Settings), Permissions: "0600"})
+	if len(sources.TLSCert) > 0 {
+		c.WriteFiles = append(c.WriteFiles, writeFile{Path: tlsCertPath, Content: string(sources.TLSCert), Permissions: "0644"})
+	}
+
+	// The data volume is mounted before anything else runs.
+	if mountedDisk {
+		c.WriteFiles = append(c.WriteFiles, writeFile{Path: dataDiskPath, Content: dataDiskScript(sources.TFE.DiskPath), Permissions: "0755"})
+		c.RunCmd = append(c.RunCmd, []string{dataDiskPath})
+	}
+
+	if sources.LicenceURL != "" {
+		c.RunCmd = append(c.RunCmd, fetch(sources, sources.LicenceURL, licencePath), []string{"chmod", "0600", licencePath})
+	}
+
+	if sources.TLSKeyURL != "" {
+		c.RunCmd = append(c.RunCmd, fetch(sources, sources.TLSKeyURL, tlsKeyPath), []string{"chmod", "0600", tlsKeyPath})
+	}
+
+	if sources.SecretSettingsURL != "" {
+		// The secret settings are merged into the settings, overriding
+		// them, and never left on the disk apart from them.
+		merge := fmt.Sprintf("umask 077 && jq -s '.[0] * .[1]' %[1]s %[2]s > %[1]s.new && mv %[1]s.new %[1]s && rm %[2]s", settingsPath, secretSettingsPath)
+		c.RunCmd = append(c.RunCmd, fetch(sources, sources.SecretSettingsURL, secretSettingsPath), []string{"sh", "-c", merge})
+	}
+
+	if a := sources.Airgap; a != nil {
+		c.RunCmd = append(c.RunCmd,
+			[]string{"mkdir", "-p", airgapDir},
+			fetch(sources, a.PackageURL, airgapPackagePath),
+			fetch(sources, a.BootstrapperURL, airgapBootstrapperPath),
+			[]string{"tar", "-xzf", airgapBootstrapperPath, "-C", airgapDir},
+		)
+	}
+
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the user data")
+	}
+	data = append([]byte("#cloud-config\n"), data...)
+	if len(data) > MaxUserDataSize {
+		return nil, errors.Errorf("the user data is %d bytes, more than the %d bytes EC2 accepts; shorten the TLS certificate chain or the additional trust bundle", len(data), MaxUserDataSize)
+	}
+	return data, nil
+}
+
+// fetch returns the command that downloads the s3:// or https:// URL to path,
+// through the proxy if there is one.
+func fetch(sources *userDataSources, url string, path string) []string {
+	command := []string{"curl", "-fsSL", "-o", path, url}
+	if strings.HasPrefix(url, "s3://") {
+		command = []string{"aws", "s3", "cp", "--region", sources.Region, url, path}
+	}
+	if sources.Proxy == nil {
+		return command
+	}
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	return []string{"sh", "-c", fmt.Sprintf(". %s && exec %s", proxyPath, strings.Join(quoted, " "))}
+}
+
+// dataDiskScript returns the script that mounts the data volume at diskPath,
+// and formats it on the first boot. Nitro instances expose the volume as an
+// NVMe device, whose controller holds the device name it is mapped to, and Xen
+// instances as the xvd device of that name. The script fails if the volume is
+// missing, and the mount has no nofail option, so that later boots stop
+// rather than write the data to the root volume.
+func dataDiskScript(diskPath string) string {
+	xenDevice := "/dev/xvd" + strings.TrimPrefix(DataVolumeDevice, "/dev/sd")
+	// fstab separates its fields with white space, which is escaped in octal.
+	fstabEntry := fmt.Sprintf("LABEL=%s %s ext4 defaults 0 2", dataLabel, strings.ReplaceAll(diskPath, " ", `\040`))
+	return fmt.Sprintf(`#!/bin/sh
+set -eu
+device=
+for d in /dev/nvme*n1; do
+  [ -e "$d" ] || continue
+  name=$(nvme id-ctrl --raw-binary "$d" | dd bs=1 skip=3072 count=32 2>/dev/null | tr -d ' \000')
+  if [ "/dev/${name#/dev/}" = %[1]s ]; then
+    device=$d
+  fi
+done
+if [ -z "$device" ] && [ -e %[2]s ]; then
+  device=%[2]s
+fi
+if [ -z "$device" ]; then
+  echo "the data volume %[1]s is not attached" >&2
+  exit 1
+fi
+blkid "$device" >/dev/null || mkfs.ext4 -L %[3]s "$device"
+mkdir -p %[4]s
+grep -q '^LABEL=%[3]s ' /etc/fstab || echo %[5]s >>/etc/fstab
+mountpoint -q %[4]s || mount %[4]s
+`, DataVolumeDevice, xenDevice, dataLabel, shellQuote(diskPath), shellQuote(fstabEntry))
+}
+
+// registriesConf returns a containers-registries.conf(5) file that pulls the
+// images of each source from its mirrors first.
+func registriesConf(mirrors []types.ImageMirror) string {
+	var b strings.Builder
+	for i, m := range mirrors {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[[registry]]\nprefix = %q\nlocation = %q\n", m.Source, m.Source)
+		for _, mirror := range m.Mirrors {
+			fmt.Fprintf(&b, "\n[[registry.mirror]]\nlocation = %q\n", mirror)
+		}
+	}
+	return b.String()
+}
+
+// dockerDaemonConfig returns the configuration of the Docker daemon with the
+// mirrors of Docker Hub, or an empty string if there are none. The Docker
+// daemon cannot mirror other registries.
+func dockerDaemonConfig(mirrors []types.ImageMirror) (string, error) {
+	var registryMirrors []string
+	for _, m := range mirrors {
+		if m.Source != "docker.io" {
+			continue
+		}
+		for _, mirror := range m.Mirrors {
+			registryMirrors = append(registryMirrors, "https://"+mirror)
+		}
+	}
+	if len(registryMirrors) == 0 {
+		return "", nil
+	}
+	data, err := json.MarshalIndent(map[string][]string{"registry-mirrors": registryMirrors}, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the Docker daemon configuration")
+	}
+	return string(data) + "\n", nil
+}
+
+// proxyProfile returns a shell profile script that exports the proxy.
+func proxyProfile(p *types.Proxy) string {
+	var b strings.Builder
+	for _, v := range [][2]string{{"HTTP_PROXY", p.HTTPProxy}, {"HTTPS_PROXY", p.HTTPSProxy}, {"NO_PROXY", p.NoProxy}} {
+		if v[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", v[0], shellQuote(v[1]))
+		fmt.Fprintf(&b, "export %s=%s\n", strings.ToLower(v[0]), shellQuote(v[1]))
+	}
+	return b.String()
+}
+
+// dockerProxyDropIn returns a systemd drop-in that sets the proxy in the
+// environment of the Docker daemon.
+func dockerProxyDropIn(p *types.Proxy) string {
+	var b strings.Builder
+	b.WriteString("[Service]\n")
+	for _, v := range [][2]string{{"HTTP_PROXY", p.HTTPProxy}, {"HTTPS_PROXY", p.HTTPSProxy}, {"NO_PROXY", p.NoProxy}} {
+		if v[1] != "" {
+			fmt.Fprintf(&b, "Environment=%q\n", v[0]+"="+v[1])
+		}
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
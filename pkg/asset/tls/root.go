@@ -0,0 +1,166 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+)
+
+var (
+	// RootCACertPath is the path of the root CA certificate.
+	RootCACertPath = filepath.Join("tls", "root-ca.crt")
+
+	// rootCAKeyPath is the path of the root CA private key.
+	rootCAKeyPath = filepath.Join("tls", "root-ca.key")
+)
+
+// RootCA is the CA that issues the server certificate of Terraform
+// Enterprise. A CA provided in tls/root-ca.crt and tls/root-ca.key is used
+// instead of generating one.
+type RootCA struct {
+	CertRaw []byte
+	KeyRaw  []byte
+}
+
+var _ asset.WritableAsset = (*RootCA)(nil)
+var _ asset.FileWriter = (*RootCA)(nil)
+
+// Dependencies returns no dependencies.
+func (a *RootCA) Dependencies() []asset.Asset {
+	return []asset.Asset{}
+}
+
+// Generate generates a self-signed root CA.
+func (a *RootCA) Generate(asset.Parents) error {
+	key, err := privateKey()
+	if err != nil {
+		return err
+	}
+	cert, err := selfSignedCertificate(&certConfig{
+		Subject:   pkix.Name{CommonName: "tfe-root-ca", OrganizationalUnit: []string{"terraform-install"}},
+		KeyUsages: x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		Validity:  validityTenYears,
+		IsCA:      true,
+	}, key)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate the root CA")
+	}
+	a.CertRaw = certToPem(cert)
+	a.KeyRaw = privateKeyToPem(key)
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *RootCA) Name() string {
+	return "Root CA"
+}
+
+// Files returns the certificate and key files of the CA.
+func (a *RootCA) Files() []*asset.File {
+	if a.CertRaw == nil {
+		return []*asset.File{}
+	}
+	return []*asset.File{
+		{Filename: RootCACertPath, Data: a.CertRaw},
+		{Filename: rootCAKeyPath, Data: a.KeyRaw},
+	}
+}
+
+// Load loads the CA from tls/root-ca.crt and tls/root-ca.key. Both must be
+// provided, the key must match the certificate, and the certificate must be a
+// valid CA.
+func (a *RootCA) Load(f asset.FileFetcher) (bool, error) {
+	certFile, err := f.FetchByName(RootCACertPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if _, err := f.FetchByName(rootCAKeyPath); err == nil {
+				return false, errors.Errorf("%s is provided without %s", rootCAKeyPath, RootCACertPath)
+			}
+			return false, nil
+		}
+		return false, err
+	}
+	keyFile, err := f.FetchByName(rootCAKeyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, errors.Errorf("%s is provided without %s", RootCACertPath, rootCAKeyPath)
+		}
+		return false, err
+	}
+
+	certs, key, err := parseCertKey(certFile.Data, keyFile.Data)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid root CA in %s", RootCACertPath)
+	}
+	if !certs[0].IsCA {
+		return false, errors.Errorf("invalid root CA in %s: the certificate is not a CA", RootCACertPath)
+	}
+	roots := x509.NewCertPool()
+	for _, cert := range certs {
+		roots.AddCert(cert)
+	}
+	if err := validateCertKey(certs, key, roots, time.Now()); err != nil {
+		return false, errors.Wrapf(err, "invalid root CA in %s", RootCACertPath)
+	}
+
+	a.CertRaw, a.KeyRaw = certFile.Data, keyFile.Data
+	return true, nil
+}
+
+// PersistToFile writes the certificate, and the key readable only by its
+// owner.
+func (a *RootCA) PersistToFile(directory string) error {
+	if a.CertRaw == nil {
+		return nil
+	}
+	return persistCertKey(directory, RootCACertPath, a.CertRaw, rootCAKeyPath, a.KeyRaw)
+}
+
+// Cert returns the certificate of the CA.
+func (a *RootCA) Cert() (*x509.Certificate, error) {
+	certs, err := parseCertificates(a.CertRaw)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+// Key returns the private key of the CA.
+func (a *RootCA) Key() (crypto.Signer, error) {
+	return parsePrivateKey(a.KeyRaw)
+}
+
+// parseCertKey decodes a PEM certificate chain and its private key.
+func parseCertKey(certData, keyData []byte) ([]*x509.Certificate, crypto.Signer, error) {
+	certs, err := parseCertificates(certData)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKey(keyData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certs, key, nil
+}
+
+// persistCertKey writes the certificate with the default permissions and the
+// key readable only by its owner.
+func persistCertKey(directory, certPath string, cert []byte, keyPath string, key []byte) error {
+	if err := os.MkdirAll(filepath.Join(directory, filepath.Dir(certPath)), 0750); err != nil {
+		return errors.Wrap(err, "failed to create dir")
+	}
+	if err := asset.WriteFileAtomic(filepath.Join(directory, certPath), cert, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", certPath)
+	}
+	if err := asset.WriteFileAtomic(filepath.Join(directory, keyPath), key, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write %s", keyPath)
+	}
+	return nil
+}
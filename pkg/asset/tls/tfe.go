@@ -0,0 +1,223 @@
+package tls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/asset/installconfig"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+var (
+	// providedCertPath is the path of the provided certificate chain, the
+	// server certificate first.
+	providedCertPath = filepath.Join("tls", "tfe.crt")
+
+	// providedKeyPath is the path of the private key of the provided
+	// certificate.
+	providedKeyPath = filepath.Join("tls", "tfe.key")
+
+	// providedCAPath is the path of the optional CA bundle that the provided
+	// certificate chain verifies against. The system roots are used when it
+	// is not provided.
+	providedCAPath = filepath.Join("tls", "tfe-ca.crt")
+)
+
+// providedCertKey is the server certificate the user provides in tls/. It has
+// no dependencies, so that it is not discarded when the install config
+// changes.
+type providedCertKey struct {
+	CertRaw []byte
+	KeyRaw  []byte
+	CARaw   []byte
+}
+
+var _ asset.WritableAsset = (*providedCertKey)(nil)
+
+// Dependencies returns no dependencies.
+func (a *providedCertKey) Dependencies() []asset.Asset {
+	return []asset.Asset{}
+}
+
+// Generate leaves the certificate empty, as none was provided.
+func (a *providedCertKey) Generate(asset.Parents) error {
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *providedCertKey) Name() string {
+	return "Provided TFE Certificate"
+}
+
+// Files returns the provided files.
+func (a *providedCertKey) Files() []*asset.File {
+	if a.CertRaw == nil {
+		return []*asset.File{}
+	}
+	files := []*asset.File{
+		{Filename: providedCertPath, Data: a.CertRaw},
+		{Filename: providedKeyPath, Data: a.KeyRaw},
+	}
+	if a.CARaw != nil {
+		files = append(files, &asset.File{Filename: providedCAPath, Data: a.CARaw})
+	}
+	return files
+}
+
+// Load loads the certificate from tls/tfe.crt, tls/tfe.key and optionally
+// tls/tfe-ca.crt. The key must match the certificate, the certificate must not
+// have expired, and the chain must verify against the CA bundle.
+func (a *providedCertKey) Load(f asset.FileFetcher) (bool, error) {
+	certFile, err := f.FetchByName(providedCertPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if _, err := f.FetchByName(providedKeyPath); err == nil {
+				return false, errors.Errorf("%s is provided without %s", providedKeyPath, providedCertPath)
+			}
+			return false, nil
+		}
+		return false, err
+	}
+	keyFile, err := f.FetchByName(providedKeyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, errors.Errorf("%s is provided without %s", providedCertPath, providedKeyPath)
+		}
+		return false, err
+	}
+	var caData []byte
+	var roots *x509.CertPool
+	caFile, err := f.FetchByName(providedCAPath)
+	switch {
+	case err == nil:
+		caData = caFile.Data
+		cas, err := parseCertificates(caData)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid CA bundle in %s", providedCAPath)
+		}
+		roots = x509.NewCertPool()
+		for _, ca := range cas {
+			roots.AddCert(ca)
+		}
+	case !os.IsNotExist(err):
+		return false, err
+	}
+
+	certs, key, err := parseCertKey(certFile.Data, keyFile.Data)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid certificate in %s", providedCertPath)
+	}
+	if err := validateCertKey(certs, key, roots, time.Now()); err != nil {
+		return false, errors.Wrapf(err, "invalid certificate in %s", providedCertPath)
+	}
+
+	a.CertRaw, a.KeyRaw, a.CARaw = certFile.Data, keyFile.Data, caData
+	return true, nil
+}
+
+// TFECertKey is the server certificate of Terraform Enterprise. Depending on
+// the TLS source of the install config, it is issued by the root CA, taken
+// from the files the user provides, or left empty for Terraform Enterprise to
+// generate its own.
+type TFECertKey struct {
+	// CertRaw is the PEM certificate chain, the server certificate first.
+	CertRaw []byte
+
+	// KeyRaw is the PEM private key of the server certificate.
+	KeyRaw []byte
+
+	// CABundle is the PEM bundle of the CAs that clients must trust to
+	// verify the certificate. It is empty if the certificate is trusted
+	// through the system roots.
+	CABundle []byte
+}
+
+var _ asset.Asset = (*TFECertKey)(nil)
+
+// Dependencies returns the dependencies of the certificate.
+func (a *TFECertKey) Dependencies() []asset.Asset {
+	return []asset.Asset{
+		&installconfig.InstallConfig{},
+		&RootCA{},
+		&providedCertKey{},
+	}
+}
+
+// Generate issues the server certificate from the root CA, or takes the one
+// provided, according to the TLS source of the install config.
+func (a *TFECertKey) Generate(parents asset.Parents) error {
+	installConfig := &installconfig.InstallConfig{}
+	rootCA := &RootCA{}
+	provided := &providedCertKey{}
+	parents.Get(installConfig, rootCA, provided)
+
+	*a = TFECertKey{}
+	switch source := installConfig.Config.TFE.TLSSource; source {
+	case types.ProvidedTLSSource:
+		if provided.CertRaw == nil {
+			return errors.Errorf("%s and %s are required when tfe.tlsSource is %s", providedCertPath, providedKeyPath, source)
+		}
+		a.CertRaw, a.KeyRaw, a.CABundle = provided.CertRaw, provided.KeyRaw, provided.CARaw
+		return nil
+	case types.GeneratedTLSSource:
+		if provided.CertRaw != nil {
+			return errors.Errorf("%s is only used when tfe.tlsSource is %s", providedCertPath, types.ProvidedTLSSource)
+		}
+		return a.generate(installConfig.Config, rootCA)
+	default:
+		if provided.CertRaw != nil {
+			return errors.Errorf("%s is only used when tfe.tlsSource is %s", providedCertPath, types.ProvidedTLSSource)
+		}
+		return nil
+	}
+}
+
+// generate issues the server certificate for the TFE hostname and the cluster
+// domain from the root CA.
+func (a *TFECertKey) generate(config *types.InstallConfig, rootCA *RootCA) error {
+	caCert, err := rootCA.Cert()
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the root CA certificate")
+	}
+	caKey, err := rootCA.Key()
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the root CA key")
+	}
+	key, err := privateKey()
+	if err != nil {
+		return err
+	}
+
+	hostname := strings.TrimSuffix(config.TFE.Hostname, ".")
+	dnsNames := []string{hostname}
+	if clusterDomain := config.ClusterDomain(); clusterDomain != hostname {
+		dnsNames = append(dnsNames, clusterDomain)
+	}
+	cert, err := signedCertificate(&certConfig{
+		Subject:      pkix.Name{CommonName: hostname, OrganizationalUnit: []string{"terraform-install"}},
+		DNSNames:     dnsNames,
+		KeyUsages:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		Validity:     validityOneYear,
+	}, key, caCert, caKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate the TFE certificate")
+	}
+
+	a.CertRaw = certToPem(cert)
+	a.KeyRaw = privateKeyToPem(key)
+	a.CABundle = rootCA.CertRaw
+	return nil
+}
+
+// Name returns the human-friendly name of the asset.
+func (a *TFECertKey) Name() string {
+	return "TFE Certificate"
+}
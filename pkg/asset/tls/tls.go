@@ -0,0 +1,191 @@
+// Package tls generates the certificates of Terraform Enterprise, or checks
+// the ones the user provides.
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	keySize = 2048
+
+	// validityTenYears is the validity of the root CA.
+	validityTenYears = 10 * 365 * 24 * time.Hour
+
+	// validityOneYear is the validity of the server certificate.
+	validityOneYear = 365 * 24 * time.Hour
+)
+
+// certConfig is the configuration of a certificate.
+type certConfig struct {
+	Subject      pkix.Name
+	DNSNames     []string
+	KeyUsages    x509.KeyUsage
+	ExtKeyUsages []x509.ExtKeyUsage
+	Validity     time.Duration
+	IsCA         bool
+}
+
+// privateKey generates an RSA private key.
+func privateKey() (*rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate private key")
+	}
+	return key, nil
+}
+
+// selfSignedCertificate creates a self-signed certificate for the key.
+func selfSignedCertificate(cfg *certConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	template, err := certificateTemplate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return createCertificate(template, template, &key.PublicKey, key)
+}
+
+// signedCertificate creates a certificate for the key, signed by the CA.
+func signedCertificate(cfg *certConfig, key *rsa.PrivateKey, caCert *x509.Certificate, caKey crypto.Signer) (*x509.Certificate, error) {
+	template, err := certificateTemplate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return createCertificate(template, caCert, &key.PublicKey, caKey)
+}
+
+func certificateTemplate(cfg *certConfig) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate serial number")
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               cfg.Subject,
+		DNSNames:              cfg.DNSNames,
+		KeyUsage:              cfg.KeyUsages,
+		ExtKeyUsage:           cfg.ExtKeyUsages,
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(cfg.Validity),
+		BasicConstraintsValid: true,
+		IsCA:                  cfg.IsCA,
+	}, nil
+}
+
+func createCertificate(template, parent *x509.Certificate, pub crypto.PublicKey, priv crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse certificate")
+	}
+	return cert, nil
+}
+
+// certToPem encodes the certificate in PEM.
+func certToPem(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+// privateKeyToPem encodes the RSA private key in PEM.
+func privateKeyToPem(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// parseCertificates decodes the PEM certificates in data, in order.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return certs, nil
+}
+
+// parsePrivateKey decodes a PEM private key in the PKCS #1, PKCS #8 or SEC 1
+// format.
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM private key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// validateCertKey checks that the key belongs to the first certificate, that
+// the certificate is valid at the given time, and that it verifies against
+// the roots with the other certificates as intermediates. The system roots
+// are used when roots is nil.
+func validateCertKey(certs []*x509.Certificate, key crypto.Signer, roots *x509.CertPool, now time.Time) error {
+	cert := certs[0]
+	public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return errors.New("the private key does not match the certificate")
+	}
+	if now.Before(cert.NotBefore) {
+		return errors.Errorf("the certificate is not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return errors.Errorf("the certificate expired on %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return errors.Wrap(err, "the certificate chain does not verify")
+	}
+	return nil
+}
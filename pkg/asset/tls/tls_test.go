@@ -0,0 +1,272 @@
+package tls
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+type fileFetcher map[string][]byte
+
+func (f fileFetcher) FetchByName(name string) (*asset.File, error) {
+	data, ok := f[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &asset.File{Filename: name, Data: data}, nil
+}
+
+func (f fileFetcher) FetchByPattern(string) ([]*asset.File, error) {
+	return nil, nil
+}
+
+type certKey struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+func newCA(t *testing.T) certKey {
+	key, err := privateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := selfSignedCertificate(&certConfig{
+		Subject:   pkix.Name{CommonName: "test-ca"},
+		KeyUsages: x509.KeyUsageCertSign,
+		Validity:  time.Hour,
+		IsCA:      true,
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certKey{cert: cert, key: key}
+}
+
+func newServer(t *testing.T, ca certKey) certKey {
+	key, err := privateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := signedCertificate(&certConfig{
+		Subject:      pkix.Name{CommonName: "tfe.test.example.com"},
+		DNSNames:     []string{"tfe.test.example.com"},
+		KeyUsages:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		Validity:     time.Hour,
+	}, key, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certKey{cert: cert, key: key}
+}
+
+func TestProvidedCertKeyLoad(t *testing.T) {
+	ca := newCA(t)
+	otherCA := newCA(t)
+	server := newServer(t, ca)
+	other := newServer(t, ca)
+
+	cases := []struct {
+		name          string
+		files         fileFetcher
+		expectedFound bool
+		expectedError string
+	}{
+		{
+			name:  "not provided",
+			files: fileFetcher{},
+		},
+		{
+			name: "valid",
+			files: fileFetcher{
+				"tls/tfe.crt":    certToPem(server.cert),
+				"tls/tfe.key":    privateKeyToPem(server.key),
+				"tls/tfe-ca.crt": certToPem(ca.cert),
+			},
+			expectedFound: true,
+		},
+		{
+			name: "key without certificate",
+			files: fileFetcher{
+				"tls/tfe.key": privateKeyToPem(server.key),
+			},
+			expectedError: `^tls/tfe.key is provided without tls/tfe.crt$`,
+		},
+		{
+			name: "certificate without key",
+			files: fileFetcher{
+				"tls/tfe.crt": certToPem(server.cert),
+			},
+			expectedError: `^tls/tfe.crt is provided without tls/tfe.key$`,
+		},
+		{
+			name: "key of another certificate",
+			files: fileFetcher{
+				"tls/tfe.crt":    certToPem(server.cert),
+				"tls/tfe.key":    privateKeyToPem(other.key),
+				"tls/tfe-ca.crt": certToPem(ca.cert),
+			},
+			expectedError: `^invalid certificate in tls/tfe.crt: the private key does not match the certificate$`,
+		},
+		{
+			name: "chain of another CA",
+			files: fileFetcher{
+				"tls/tfe.crt":    certToPem(server.cert),
+				"tls/tfe.key":    privateKeyToPem(server.key),
+				"tls/tfe-ca.crt": certToPem(otherCA.cert),
+			},
+			expectedError: `^invalid certificate in tls/tfe.crt: the certificate chain does not verify: x509: `,
+		},
+		{
+			name: "invalid CA bundle",
+			files: fileFetcher{
+				"tls/tfe.crt":    certToPem(server.cert),
+				"tls/tfe.key":    privateKeyToPem(server.key),
+				"tls/tfe-ca.crt": []byte("not a certificate"),
+			},
+			expectedError: `^invalid CA bundle in tls/tfe-ca.crt: no PEM certificate found$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &providedCertKey{}
+			found, err := a.Load(tc.files)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+			assert.Equal(t, tc.expectedFound, found)
+		})
+	}
+}
+
+func TestValidateCertKeyExpiry(t *testing.T) {
+	ca := newCA(t)
+	server := newServer(t, ca)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	cases := []struct {
+		name          string
+		now           time.Time
+		expectedError string
+	}{
+		{
+			name: "valid",
+			now:  time.Now(),
+		},
+		{
+			name:          "expired",
+			now:           time.Now().Add(2 * time.Hour),
+			expectedError: `^the certificate expired on `,
+		},
+		{
+			name:          "not yet valid",
+			now:           time.Now().Add(-time.Hour),
+			expectedError: `^the certificate is not valid before `,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCertKey([]*x509.Certificate{server.cert}, server.key, roots, tc.now)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestRootCALoad(t *testing.T) {
+	ca := newCA(t)
+	server := newServer(t, ca)
+
+	cases := []struct {
+		name          string
+		files         fileFetcher
+		expectedFound bool
+		expectedError string
+	}{
+		{
+			name:  "not provided",
+			files: fileFetcher{},
+		},
+		{
+			name: "valid",
+			files: fileFetcher{
+				"tls/root-ca.crt": certToPem(ca.cert),
+				"tls/root-ca.key": privateKeyToPem(ca.key),
+			},
+			expectedFound: true,
+		},
+		{
+			name: "not a CA",
+			files: fileFetcher{
+				"tls/root-ca.crt": certToPem(server.cert),
+				"tls/root-ca.key": privateKeyToPem(server.key),
+			},
+			expectedError: `^invalid root CA in tls/root-ca.crt: the certificate is not a CA$`,
+		},
+		{
+			name: "key of another certificate",
+			files: fileFetcher{
+				"tls/root-ca.crt": certToPem(ca.cert),
+				"tls/root-ca.key": privateKeyToPem(server.key),
+			},
+			expectedError: `^invalid root CA in tls/root-ca.crt: the private key does not match the certificate$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &RootCA{}
+			found, err := a.Load(tc.files)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+			assert.Equal(t, tc.expectedFound, found)
+		})
+	}
+}
+
+func TestTFECertKeyGenerate(t *testing.T) {
+	rootCA := &RootCA{}
+	if err := rootCA.Generate(nil); !assert.NoError(t, err) {
+		return
+	}
+	a := &TFECertKey{}
+	config := &types.InstallConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		BaseDomain: "example.com",
+		TFE:        &types.TFE{Hostname: "tfe.test.example.com"},
+	}
+	if err := a.generate(config, rootCA); !assert.NoError(t, err) {
+		return
+	}
+
+	certs, key, err := parseCertKey(a.CertRaw, a.KeyRaw)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"tfe.test.example.com", "test.example.com"}, certs[0].DNSNames)
+
+	roots, err := parseCertificates(a.CABundle)
+	if !assert.NoError(t, err) {
+		return
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(roots[0])
+	assert.NoError(t, validateCertKey(certs, key, pool, time.Now()))
+}
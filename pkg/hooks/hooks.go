@@ -0,0 +1,220 @@
+// Package hooks runs user-provided executables around asset generation and
+// terraform stage applies.
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bailey84j/terraform_installer/pkg/diagnostics"
+	"github.com/bailey84j/terraform_installer/pkg/lineprinter"
+)
+
+// ConfigFileName is the name of the hooks configuration file in the install
+// directory.
+const ConfigFileName = "hooks.yaml"
+
+// Event is a point in the install lifecycle at which hooks run.
+type Event string
+
+const (
+	// PreGenerate runs before an asset is generated.
+	PreGenerate Event = "preGenerate"
+	// PostGenerate runs after an asset is generated.
+	PostGenerate Event = "postGenerate"
+	// PreApply runs before a terraform stage is applied.
+	PreApply Event = "preApply"
+	// PostApply runs after a terraform stage is applied successfully.
+	PostApply Event = "postApply"
+)
+
+// Hook is an executable that runs on a lifecycle event.
+type Hook struct {
+	// Name identifies the hook in logs and errors.
+	Name string `json:"name"`
+
+	// Event is the lifecycle event on which the hook runs.
+	Event Event `json:"event"`
+
+	// Assets are the names of the assets for which a generate hook runs,
+	// e.g. "Cluster". When empty, the hook runs for every asset.
+	// +optional
+	Assets []string `json:"assets,omitempty"`
+
+	// Stages are the names of the terraform stages for which an apply hook
+	// runs, e.g. "cluster". When empty, the hook runs for every stage.
+	// +optional
+	Stages []string `json:"stages,omitempty"`
+
+	// Command is the executable to run followed by its arguments. It is run
+	// from the install directory.
+	Command []string `json:"command"`
+}
+
+// Config is the hooks configuration of an install directory.
+type Config struct {
+	// Hooks are run in the order in which they are listed.
+	Hooks []Hook `json:"hooks"`
+
+	directory string
+}
+
+// Payload is the JSON document written to the standard input of a hook.
+type Payload struct {
+	// Event is the lifecycle event on which the hook runs.
+	Event Event `json:"event"`
+
+	// Asset is the name of the asset being generated.
+	Asset string `json:"asset,omitempty"`
+
+	// Stage is the name of the terraform stage being applied.
+	Stage string `json:"stage,omitempty"`
+
+	// Directory is the absolute path of the install directory.
+	Directory string `json:"directory"`
+
+	// Inputs are the paths of the terraform variable files for the stage.
+	// The files with the licence and the other secrets are left out.
+	Inputs []string `json:"inputs,omitempty"`
+
+	// Outputs are the absolute paths of the files produced by the stage or
+	// by an asset that is written to disk. The postGenerate hooks of such an
+	// asset run once its files have been written.
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// Load reads the hooks configuration from the given install directory. An
+// empty configuration is returned if the directory has no hooks file.
+func Load(directory string) (*Config, error) {
+	absDir, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{directory: absDir}
+
+	path := filepath.Join(directory, ConfigFileName)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %s", path)
+	}
+	if err := config.validate().ToAggregate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", path)
+	}
+	return config, nil
+}
+
+func (c *Config) validate() field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, h := range c.Hooks {
+		fldPath := field.NewPath("hooks").Index(i)
+		switch {
+		case h.Name == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("name"), "hook must have a name"))
+		case names[h.Name]:
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("name"), h.Name))
+		}
+		names[h.Name] = true
+
+		switch h.Event {
+		case PreGenerate, PostGenerate:
+			if len(h.Stages) > 0 {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("stages"), "stages may only be set for apply hooks"))
+			}
+		case PreApply, PostApply:
+			if len(h.Assets) > 0 {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("assets"), "assets may only be set for generate hooks"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("event"), h.Event, []string{string(PreGenerate), string(PostGenerate), string(PreApply), string(PostApply)}))
+		}
+
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("command"), "hook must have a command"))
+		}
+	}
+	return allErrs
+}
+
+// Run runs every hook configured for the event whose asset or stage matches
+// the payload. The first hook that fails aborts the run. Run does nothing on a
+// nil Config.
+func (c *Config) Run(event Event, payload Payload) error {
+	if c == nil {
+		return nil
+	}
+	payload.Event = event
+	payload.Directory = c.directory
+	for _, h := range c.Hooks {
+		if h.Event != event || !matches(h.Assets, payload.Asset) || !matches(h.Stages, payload.Stage) {
+			continue
+		}
+		if err := c.run(h, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) run(h Hook, payload Payload) error {
+	target := payload.Asset
+	if payload.Stage != "" {
+		target = fmt.Sprintf("stage %q", payload.Stage)
+	}
+	logrus.Debugf("Running %s hook %q for %s", h.Event, h.Name, target)
+
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal hook payload")
+	}
+
+	lpDebug := &lineprinter.LinePrinter{Print: (&lineprinter.Trimmer{WrappedPrint: logrus.Debug}).Print}
+	lpError := &lineprinter.LinePrinter{Print: (&lineprinter.Trimmer{WrappedPrint: logrus.Error}).Print}
+	defer lpDebug.Close()
+	defer lpError.Close()
+
+	cmd := exec.Command(h.Command[0], h.Command[1:]...) // #nosec G204
+	cmd.Dir = c.directory
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = lpDebug
+	cmd.Stderr = lpError
+	if err := cmd.Run(); err != nil {
+		return &diagnostics.Err{
+			Orig:    err,
+			Source:  "Lifecycle Hook",
+			Reason:  "HookFailed",
+			Message: fmt.Sprintf("The %s hook %q failed for %s.", h.Event, h.Name, target),
+		}
+	}
+	return nil
+}
+
+// matches returns whether name is one of names. An empty list matches
+// everything.
+func matches(names []string, name string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
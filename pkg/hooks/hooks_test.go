@@ -0,0 +1,148 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/diagnostics"
+)
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name          string
+		config        string
+		expectedHooks int
+		expectedError string
+	}{
+		{
+			name: "no hooks file",
+		},
+		{
+			name: "valid",
+			config: `hooks:
+- name: cmdb
+  event: postGenerate
+  assets: [Cluster]
+  command: [./register.sh]
+- name: scan
+  event: preApply
+  command: [tfsec, --tfvars]
+`,
+			expectedHooks: 2,
+		},
+		{
+			name: "unknown event",
+			config: `hooks:
+- name: cmdb
+  event: afterEverything
+  command: [./register.sh]
+`,
+			expectedError: `hooks\[0\]\.event: Unsupported value: "afterEverything"`,
+		},
+		{
+			name: "stages on generate hook",
+			config: `hooks:
+- name: cmdb
+  event: preGenerate
+  stages: [cluster]
+  command: [./register.sh]
+`,
+			expectedError: `hooks\[0\]\.stages: Forbidden`,
+		},
+		{
+			name: "missing command",
+			config: `hooks:
+- name: cmdb
+  event: postApply
+`,
+			expectedError: `hooks\[0\]\.command: Required value`,
+		},
+		{
+			name: "duplicate name",
+			config: `hooks:
+- name: cmdb
+  event: postApply
+  command: [true]
+- name: cmdb
+  event: preApply
+  command: [true]
+`,
+			expectedError: `hooks\[1\]\.name: Duplicate value: "cmdb"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.config != "" {
+				err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(tc.config), 0640)
+				assert.NoError(t, err, "unexpected error writing hooks file")
+			}
+			config, err := Load(dir)
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, config.Hooks, tc.expectedHooks)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{
+		directory: dir,
+		Hooks: []Hook{{
+			Name:    "record",
+			Event:   PostGenerate,
+			Assets:  []string{"Cluster"},
+			Command: []string{"sh", "-c", "cat > payload.json"},
+		}, {
+			Name:    "fail",
+			Event:   PreApply,
+			Stages:  []string{"bootstrap"},
+			Command: []string{"sh", "-c", "exit 3"},
+		}},
+	}
+
+	t.Run("payload is written to stdin", func(t *testing.T) {
+		err := config.Run(PostGenerate, Payload{Asset: "Cluster", Outputs: []string{"terraform.tfstate"}})
+		assert.NoError(t, err)
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "payload.json"))
+		if assert.NoError(t, err, "hook did not run") {
+			payload := Payload{}
+			assert.NoError(t, json.Unmarshal(data, &payload))
+			assert.Equal(t, Payload{
+				Event:     PostGenerate,
+				Asset:     "Cluster",
+				Directory: dir,
+				Outputs:   []string{"terraform.tfstate"},
+			}, payload)
+		}
+	})
+
+	t.Run("hooks for other assets are skipped", func(t *testing.T) {
+		err := config.Run(PostGenerate, Payload{Asset: "Install Config"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("failing hook returns diagnostic error", func(t *testing.T) {
+		err := config.Run(PreApply, Payload{Stage: "bootstrap"})
+		var diagErr *diagnostics.Err
+		if assert.True(t, errors.As(err, &diagErr), "expected a diagnostics error, got %v", err) {
+			assert.Equal(t, "HookFailed", diagErr.Reason)
+			assert.Contains(t, diagErr.Message, `"fail"`)
+		}
+	})
+
+	t.Run("nil config runs nothing", func(t *testing.T) {
+		var c *Config
+		assert.NoError(t, c.Run(PreApply, Payload{Stage: "bootstrap"}))
+	})
+}
@@ -0,0 +1,145 @@
+// Package licence parses and checks Terraform Enterprise licences.
+package licence
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExpiryWarningPeriod is how long before its expiry a licence is reported as
+// expiring soon.
+const ExpiryWarningPeriod = 30 * 24 * time.Hour
+
+// Licence is a Terraform Enterprise licence.
+type Licence struct {
+	// ID identifies the licence. It is the fingerprint of the licence when
+	// the licence is opaque.
+	ID string `json:"license_id"`
+
+	// Customer is the name of the licensee.
+	Customer string `json:"customer,omitempty"`
+
+	// IssuedAt is when the licence was issued.
+	IssuedAt time.Time `json:"issued_at"`
+
+	// ExpiresAt is when the licence expires.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Capacity is the number of workspaces the licence allows. Zero means
+	// unlimited.
+	Capacity int64 `json:"capacity,omitempty"`
+
+	// Opaque is set when the installer cannot read the metadata of the
+	// licence. Only Terraform Enterprise checks such licences.
+	Opaque bool `json:"opaque,omitempty"`
+}
+
+// Parse parses a licence. There is no published schema for Terraform
+// Enterprise licences, so the installer only reads the metadata of licences
+// that are a JSON document with the fields of Licence, as is or
+// base64-encoded. Those are checked for expiry. Any other licence, such as a
+// signed licence, must be a single base64 or base32 token, possibly wrapped
+// over several lines. It is treated as opaque, identified by its fingerprint,
+// and left for Terraform Enterprise to check when it starts.
+func Parse(data []byte) (*Licence, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("licence is empty")
+	}
+
+	document := data
+	if data[0] != '{' {
+		if bytes.ContainsAny(data, " \t") || !tokenPattern.Match(data) {
+			return nil, errors.New("licence is neither a JSON document nor a base64 or base32 token")
+		}
+		token := strings.Join(strings.Fields(string(data)), "")
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil || len(bytes.TrimSpace(decoded)) == 0 || bytes.TrimSpace(decoded)[0] != '{' {
+			return opaque(token), nil
+		}
+		document = decoded
+	}
+
+	l := &Licence{}
+	if err := json.Unmarshal(document, l); err != nil {
+		return nil, errors.Wrap(err, "failed to decode licence")
+	}
+	l.Opaque = false
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// tokenPattern matches the characters of base64 and base32 tokens, including
+// their URL-safe variants, and line breaks.
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9+/=_\-\r\n]+$`)
+
+// opaque returns the licence for a token whose metadata cannot be read.
+func opaque(token string) *Licence {
+	sum := sha256.Sum256([]byte(token))
+	return &Licence{
+		ID:     "sha256:" + hex.EncodeToString(sum[:8]),
+		Opaque: true,
+	}
+}
+
+// ParseFile reads and parses the licence in the given file.
+func ParseFile(path string) (*Licence, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid licence file %s", path)
+	}
+	return l, nil
+}
+
+func (l *Licence) validate() error {
+	switch {
+	case l.ID == "":
+		return errors.New("licence has no license_id")
+	case l.ExpiresAt.IsZero():
+		return errors.New("licence has no expires_at")
+	case !l.IssuedAt.IsZero() && !l.ExpiresAt.After(l.IssuedAt):
+		return errors.New("licence expires before it is issued")
+	case l.Capacity < 0:
+		return errors.New("licence capacity must not be negative")
+	}
+	return nil
+}
+
+// Expired returns whether the licence has expired at the given time. Opaque
+// licences are never reported as expired.
+func (l *Licence) Expired(now time.Time) bool {
+	return !l.Opaque && !now.Before(l.ExpiresAt)
+}
+
+// ExpiresSoon returns whether the licence expires within ExpiryWarningPeriod
+// of the given time. Opaque licences are never reported as expiring soon.
+func (l *Licence) ExpiresSoon(now time.Time) bool {
+	return !l.Opaque && l.ExpiresAt.Sub(now) < ExpiryWarningPeriod
+}
+
+// CapacityString returns the capacity of the licence for display.
+func (l *Licence) CapacityString() string {
+	if l.Capacity == 0 {
+		return "unlimited workspaces"
+	}
+	if l.Capacity == 1 {
+		return "1 workspace"
+	}
+	return strconv.FormatInt(l.Capacity, 10) + " workspaces"
+}
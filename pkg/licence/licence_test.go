@@ -0,0 +1,176 @@
+package licence
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validLicence = `{"license_id":"lic-123","customer":"Example Corp","issued_at":"2026-01-01T00:00:00Z","expires_at":"2027-01-01T00:00:00Z","capacity":50}`
+
+func TestParse(t *testing.T) {
+	expected := &Licence{
+		ID:        "lic-123",
+		Customer:  "Example Corp",
+		IssuedAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		Capacity:  50,
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(validLicence))
+
+	cases := []struct {
+		name          string
+		data          string
+		expected      *Licence
+		expectedError string
+	}{
+		{
+			name:     "json",
+			data:     validLicence,
+			expected: expected,
+		},
+		{
+			name:     "base64 rli",
+			data:     encoded,
+			expected: expected,
+		},
+		{
+			name:     "wrapped base64 rli",
+			data:     encoded[:40] + "\n" + encoded[40:] + "\n",
+			expected: expected,
+		},
+		{
+			name:          "empty",
+			data:          " \n",
+			expectedError: `^licence is empty$`,
+		},
+		{
+			name:     "unknown fields",
+			data:     `{"license_id":"lic-123","customer":"Example Corp","issued_at":"2026-01-01T00:00:00Z","expires_at":"2027-01-01T00:00:00Z","capacity":50,"installation_id":"install-1"}`,
+			expected: expected,
+		},
+		{
+			name: "opaque token",
+			data: "NR3COIBHRQ5J2XC2\nCAU7YDLPNE26LTEB\n",
+			expected: &Licence{
+				ID:     "sha256:e26ba155692c95c7",
+				Opaque: true,
+			},
+		},
+		{
+			name:          "docker pull secret",
+			data:          `{"auths":{"quay.io":{"auth":"Zm9vOmJhcg=="}}}`,
+			expectedError: `^licence has no license_id$`,
+		},
+		{
+			name:          "not a token",
+			data:          "not a licence",
+			expectedError: `^licence is neither a JSON document nor a base64 or base32 token$`,
+		},
+		{
+			name:          "missing id",
+			data:          `{"expires_at":"2027-01-01T00:00:00Z"}`,
+			expectedError: `^licence has no license_id$`,
+		},
+		{
+			name:          "missing expiry",
+			data:          `{"license_id":"lic-123"}`,
+			expectedError: `^licence has no expires_at$`,
+		},
+		{
+			name:          "expires before issued",
+			data:          `{"license_id":"lic-123","issued_at":"2027-01-01T00:00:00Z","expires_at":"2026-01-01T00:00:00Z"}`,
+			expectedError: `^licence expires before it is issued$`,
+		},
+		{
+			name:          "negative capacity",
+			data:          `{"license_id":"lic-123","expires_at":"2027-01-01T00:00:00Z","capacity":-1}`,
+			expectedError: `^licence capacity must not be negative$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := Parse([]byte(tc.data))
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, l)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		expected *Licence
+	}{
+		{
+			name: "licence with metadata",
+			path: "testdata/metadata.rli",
+			expected: &Licence{
+				ID:        "lic-123",
+				Customer:  "Example Corp",
+				IssuedAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+				Capacity:  50,
+			},
+		},
+		{
+			name: "signed licence",
+			path: "testdata/signed.rli",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := ParseFile(tc.path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if tc.expected != nil {
+				assert.Equal(t, tc.expected, l)
+			} else {
+				assert.True(t, l.Opaque)
+				assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, l.ID)
+				assert.False(t, l.Expired(time.Now()))
+			}
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	l := &Licence{ExpiresAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	cases := []struct {
+		name        string
+		now         time.Time
+		expired     bool
+		expiresSoon bool
+	}{
+		{
+			name: "far from expiry",
+			now:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "within warning period",
+			now:         time.Date(2026, 12, 10, 0, 0, 0, 0, time.UTC),
+			expiresSoon: true,
+		},
+		{
+			name:        "expired",
+			now:         time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			expired:     true,
+			expiresSoon: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expired, l.Expired(tc.now))
+			assert.Equal(t, tc.expiresSoon, l.ExpiresSoon(tc.now))
+		})
+	}
+}
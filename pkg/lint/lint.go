@@ -0,0 +1,175 @@
+// Package lint reports findings about the install config that, unlike
+// validation errors, do not all block an install. Every finding comes from a
+// rule with a stable ID and a severity, and the findings of a rule can be
+// suppressed by its ID.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Severity is how serious the findings of a rule are.
+type Severity string
+
+const (
+	// SeverityError findings block the install.
+	SeverityError Severity = "error"
+
+	// SeverityWarning findings are likely mistakes, but do not block the
+	// install.
+	SeverityWarning Severity = "warning"
+
+	// SeverityInfo findings are for information only.
+	SeverityInfo Severity = "info"
+)
+
+var severityOrder = map[Severity]int{
+	SeverityError:   0,
+	SeverityWarning: 1,
+	SeverityInfo:    2,
+}
+
+// Rule is a lint rule.
+type Rule struct {
+	// ID is the stable identifier of the rule, used to suppress it.
+	ID string
+
+	// Severity is the severity of the findings of the rule.
+	Severity Severity
+
+	// Description describes what the rule checks.
+	Description string
+}
+
+var rules = map[string]*Rule{}
+
+// Register registers a rule. It panics if a rule with the same ID is already
+// registered, so it is meant to be called when initializing package
+// variables.
+func Register(id string, severity Severity, description string) *Rule {
+	if _, ok := rules[id]; ok {
+		panic(fmt.Sprintf("lint rule %q is already registered", id))
+	}
+	if _, ok := severityOrder[severity]; !ok {
+		panic(fmt.Sprintf("lint rule %q has an invalid severity %q", id, severity))
+	}
+	rule := &Rule{ID: id, Severity: severity, Description: description}
+	rules[id] = rule
+	return rule
+}
+
+// Lookup returns the registered rule with the given ID.
+func Lookup(id string) (*Rule, bool) {
+	rule, ok := rules[id]
+	return rule, ok
+}
+
+// Rules returns the registered rules, sorted by ID.
+func Rules() []*Rule {
+	list := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, rule)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Finding is something a rule found about a field.
+type Finding struct {
+	Rule    *Rule
+	Field   *field.Path
+	Message string
+}
+
+// Found returns a finding of the rule about the field.
+func (r *Rule) Found(fldPath *field.Path, format string, args ...interface{}) Finding {
+	return Finding{Rule: r, Field: fldPath, Message: fmt.Sprintf(format, args...)}
+}
+
+// String returns the finding as one line of a report.
+func (f Finding) String() string {
+	if f.Field == nil {
+		return fmt.Sprintf("[%s] %s: %s", f.Rule.Severity, f.Rule.ID, f.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s: %s", f.Rule.Severity, f.Rule.ID, f.Field, f.Message)
+}
+
+// Findings is a list of findings.
+type Findings []Finding
+
+// Suppress returns the findings whose rule is not one of the given IDs.
+func (f Findings) Suppress(ids []string) Findings {
+	suppressed := map[string]bool{}
+	for _, id := range ids {
+		suppressed[id] = true
+	}
+	var result Findings
+	for _, finding := range f {
+		if !suppressed[finding.Rule.ID] {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+// Errors returns the findings with the error severity.
+func (f Findings) Errors() Findings {
+	var result Findings
+	for _, finding := range f {
+		if finding.Rule.Severity == SeverityError {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+// Report returns a consolidated report of the findings, most severe first.
+func (f Findings) Report() string {
+	if len(f) == 0 {
+		return ""
+	}
+
+	sorted := append(Findings{}, f...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Rule.Severity != b.Rule.Severity {
+			return severityOrder[a.Rule.Severity] < severityOrder[b.Rule.Severity]
+		}
+		if a.Rule.ID != b.Rule.ID {
+			return a.Rule.ID < b.Rule.ID
+		}
+		return fieldString(a.Field) < fieldString(b.Field)
+	})
+
+	counts := map[Severity]int{}
+	for _, finding := range f {
+		counts[finding.Rule.Severity]++
+	}
+	var summary []string
+	for _, s := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		switch n := counts[s]; {
+		case n == 1 || n > 1 && s == SeverityInfo:
+			summary = append(summary, fmt.Sprintf("%d %s", n, s))
+		case n > 1:
+			summary = append(summary, fmt.Sprintf("%d %ss", n, s))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Install config lint findings (%s):\n", strings.Join(summary, ", "))
+	for _, finding := range sorted {
+		fmt.Fprintf(&b, "  %s\n", finding)
+	}
+	return b.String()
+}
+
+func fieldString(p *field.Path) string {
+	if p == nil {
+		return ""
+	}
+	return p.String()
+}
@@ -0,0 +1,73 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var (
+	testErrorRule   = Register("test-error", SeverityError, "An error.")
+	testWarningRule = Register("test-warning", SeverityWarning, "A warning.")
+	testInfoRule    = Register("test-info", SeverityInfo, "Some information.")
+)
+
+func TestRegister(t *testing.T) {
+	rule, ok := Lookup("test-warning")
+	assert.True(t, ok)
+	assert.Equal(t, testWarningRule, rule)
+
+	assert.PanicsWithValue(t, `lint rule "test-warning" is already registered`, func() {
+		Register("test-warning", SeverityWarning, "Again.")
+	})
+	assert.PanicsWithValue(t, `lint rule "test-fatal" has an invalid severity "fatal"`, func() {
+		Register("test-fatal", Severity("fatal"), "Unknown severity.")
+	})
+}
+
+func TestFindings(t *testing.T) {
+	findings := Findings{
+		testInfoRule.Found(nil, "for information"),
+		testWarningRule.Found(field.NewPath("b"), "second warning"),
+		testErrorRule.Found(field.NewPath("a"), "an error"),
+		testWarningRule.Found(field.NewPath("a"), "first warning"),
+	}
+
+	cases := []struct {
+		name           string
+		suppress       []string
+		expectedErrors int
+		expectedReport string
+	}{
+		{
+			name:           "all findings",
+			expectedErrors: 1,
+			expectedReport: `Install config lint findings (1 error, 2 warnings, 1 info):
+  [error] test-error: a: an error
+  [warning] test-warning: a: first warning
+  [warning] test-warning: b: second warning
+  [info] test-info: for information
+`,
+		},
+		{
+			name:     "suppressed error",
+			suppress: []string{"test-error", "test-info"},
+			expectedReport: `Install config lint findings (2 warnings):
+  [warning] test-warning: a: first warning
+  [warning] test-warning: b: second warning
+`,
+		},
+		{
+			name:     "everything suppressed",
+			suppress: []string{"test-error", "test-warning", "test-info"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := findings.Suppress(tc.suppress)
+			assert.Len(t, actual.Errors(), tc.expectedErrors)
+			assert.Equal(t, tc.expectedReport, actual.Report())
+		})
+	}
+}
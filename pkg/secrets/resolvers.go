@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileResolver resolves fromFile references.
+type FileResolver struct{}
+
+// Resolve returns the content of the referenced file without its trailing
+// newline.
+func (FileResolver) Resolve(_ context.Context, ref *Reference) (string, error) {
+	data, err := ioutil.ReadFile(ref.FromFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvResolver resolves fromEnv references.
+type EnvResolver struct{}
+
+// Resolve returns the value of the referenced environment variable, which
+// must be set.
+func (EnvResolver) Resolve(_ context.Context, ref *Reference) (string, error) {
+	value, ok := os.LookupEnv(ref.FromEnv)
+	if !ok {
+		return "", errors.Errorf("environment variable %s is not set", ref.FromEnv)
+	}
+	return value, nil
+}
+
+// Resolvers resolves each kind of reference with its own resolver.
+type Resolvers struct {
+	File  Resolver
+	Env   Resolver
+	Vault Resolver
+}
+
+// DefaultResolver returns the resolver for every kind of reference. Vault is
+// reached with the VAULT_ADDR and VAULT_TOKEN environment variables.
+func DefaultResolver() *Resolvers {
+	return &Resolvers{
+		File:  FileResolver{},
+		Env:   EnvResolver{},
+		Vault: &VaultResolver{},
+	}
+}
+
+// Resolve resolves the reference with the resolver for its kind.
+func (r *Resolvers) Resolve(ctx context.Context, ref *Reference) (string, error) {
+	var resolver Resolver
+	switch {
+	case ref.FromFile != "":
+		resolver = r.File
+	case ref.FromEnv != "":
+		resolver = r.Env
+	case ref.FromVault != nil:
+		resolver = r.Vault
+	}
+	if resolver == nil {
+		return "", errors.New("no resolver for the secret reference")
+	}
+	return resolver.Resolve(ctx, ref)
+}
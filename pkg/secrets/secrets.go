@@ -0,0 +1,272 @@
+// Package secrets resolves references to secrets that are held outside the
+// install config.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Reference refers to a secret held outside the install config. Exactly one
+// of its fields must be set.
+type Reference struct {
+	// FromFile is the path of a file holding the secret. A trailing newline
+	// is not part of the secret.
+	FromFile string `json:"fromFile,omitempty"`
+
+	// FromEnv is the name of an environment variable holding the secret.
+	FromEnv string `json:"fromEnv,omitempty"`
+
+	// FromVault is a key of a secret in Vault.
+	FromVault *VaultReference `json:"fromVault,omitempty"`
+}
+
+// VaultReference refers to a key of a secret in Vault.
+type VaultReference struct {
+	// Path is the API path of the secret, without the leading /v1/, e.g.
+	// secret/data/tfe for a secret in the KV version 2 engine mounted at
+	// secret/.
+	Path string `json:"path"`
+
+	// Key is the key of the value in the secret.
+	Key string `json:"key"`
+}
+
+// Resolver resolves secret references to the values of the secrets.
+type Resolver interface {
+	Resolve(ctx context.Context, ref *Reference) (string, error)
+}
+
+// ResolvedField is a field that held a reference and now holds the value of
+// the secret.
+type ResolvedField struct {
+	// Path is the JSON path of the field.
+	Path []string `json:"path"`
+
+	// Reference is the reference the field held.
+	Reference Reference `json:"reference"`
+}
+
+// Resolve replaces the references in the sensitive fields of the JSON
+// document in data, which is decoded into a value of type t, with the values
+// of the secrets. A field is sensitive if it is a string tagged
+// `sensitive:"true"`. It returns the resolved document and the fields that
+// were resolved, so that the references can be restored with Restore.
+func Resolve(ctx context.Context, resolver Resolver, t reflect.Type, data []byte) ([]byte, []ResolvedField, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+
+	var resolved []ResolvedField
+	document, err := walk(document, t, nil, func(path []string, node interface{}) (interface{}, error) {
+		ref, err := parseReference(node)
+		if err != nil {
+			return nil, errors.Wrap(err, strings.Join(path, "."))
+		}
+		value, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %s", strings.Join(path, "."))
+		}
+		resolved = append(resolved, ResolvedField{Path: append([]string{}, path...), Reference: *ref})
+		return value, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = json.Marshal(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resolved, nil
+}
+
+// Restore puts the references of the resolved fields back into the JSON
+// document in data, replacing the values of the secrets or adding the fields
+// if they were omitted.
+func Restore(data []byte, fields []ResolvedField) ([]byte, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		ref, err := toGeneric(f.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if err := replace(document, f.Path, ref); err != nil {
+			return nil, errors.Wrapf(err, "failed to restore the reference of %s", strings.Join(f.Path, "."))
+		}
+	}
+	return json.Marshal(document)
+}
+
+// walk calls resolve for every object found in a sensitive string field of
+// node, which is decoded into a value of type t, and replaces the object with
+// the result.
+func walk(node interface{}, t reflect.Type, path []string, resolve func([]string, interface{}) (interface{}, error)) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return node, nil
+		}
+		for _, f := range fields(t) {
+			value, ok := object[f.name]
+			if !ok {
+				continue
+			}
+			var err error
+			if _, isObject := value.(map[string]interface{}); isObject && f.sensitive {
+				value, err = resolve(append(path, f.name), value)
+			} else {
+				value, err = walk(value, f.typ, append(path, f.name), resolve)
+			}
+			if err != nil {
+				return nil, err
+			}
+			object[f.name] = value
+		}
+	case reflect.Map:
+		if object, ok := node.(map[string]interface{}); ok {
+			for k, v := range object {
+				value, err := walk(v, t.Elem(), append(path, k), resolve)
+				if err != nil {
+					return nil, err
+				}
+				object[k] = value
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := node.([]interface{}); ok {
+			for i, v := range list {
+				value, err := walk(v, t.Elem(), append(path, strconv.Itoa(i)), resolve)
+				if err != nil {
+					return nil, err
+				}
+				list[i] = value
+			}
+		}
+	}
+	return node, nil
+}
+
+type structField struct {
+	name      string
+	typ       reflect.Type
+	sensitive bool
+}
+
+// fields returns the JSON fields of the struct t, including the fields of
+// embedded and inlined structs.
+func fields(t reflect.Type) []structField {
+	var result []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if tag[0] == "" && f.Type.Kind() == reflect.Struct && (f.Anonymous || strings.Contains(f.Tag.Get("json"), "inline")) {
+			result = append(result, fields(f.Type)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = f.Name
+		}
+		result = append(result, structField{
+			name:      name,
+			typ:       f.Type,
+			sensitive: f.Type.Kind() == reflect.String && f.Tag.Get("sensitive") == "true",
+		})
+	}
+	return result
+}
+
+func parseReference(node interface{}) (*Reference, error) {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	ref := &Reference{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(ref); err != nil {
+		return nil, errors.Wrap(err, "invalid secret reference")
+	}
+
+	set := 0
+	for _, isSet := range []bool{ref.FromFile != "", ref.FromEnv != "", ref.FromVault != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("secret reference must set exactly one of fromFile, fromEnv and fromVault")
+	}
+	if ref.FromVault != nil && (ref.FromVault.Path == "" || ref.FromVault.Key == "") {
+		return nil, errors.New("fromVault requires a path and a key")
+	}
+	return ref, nil
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
+
+func replace(node interface{}, path []string, value interface{}) error {
+	for i, segment := range path {
+		last := i == len(path)-1
+		switch n := node.(type) {
+		case map[string]interface{}:
+			// The field itself is missing when it was left empty in place
+			// of the secret and omitted.
+			if last {
+				n[segment] = value
+				return nil
+			}
+			if _, ok := n[segment]; !ok {
+				return errors.Errorf("%q not found", segment)
+			}
+			node = n[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(n) {
+				return errors.Errorf("index %q out of range", segment)
+			}
+			if last {
+				n[index] = value
+				return nil
+			}
+			node = n[index]
+		default:
+			return errors.Errorf("%q not found", segment)
+		}
+	}
+	return nil
+}
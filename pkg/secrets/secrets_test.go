@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDatabase struct {
+	User     string `json:"user"`
+	Password string `json:"password" sensitive:"true"`
+}
+
+type testConfig struct {
+	Name      string                  `json:"name"`
+	Licence   string                  `json:"licence" sensitive:"true"`
+	Database  *testDatabase           `json:"database,omitempty"`
+	Replicas  []testDatabase          `json:"replicas,omitempty"`
+	Databases map[string]testDatabase `json:"databases,omitempty"`
+}
+
+type fakeResolver map[string]string
+
+func (r fakeResolver) Resolve(_ context.Context, ref *Reference) (string, error) {
+	key := ref.FromFile + ref.FromEnv
+	if ref.FromVault != nil {
+		key = ref.FromVault.Path + "#" + ref.FromVault.Key
+	}
+	value, ok := r[key]
+	if !ok {
+		return "", errors.Errorf("%s not found", key)
+	}
+	return value, nil
+}
+
+func TestResolve(t *testing.T) {
+	resolver := fakeResolver{
+		"/etc/tfe/licence.rli":  "licence-value",
+		"DB_PASSWORD":           "db-password",
+		"secret/data/tfe#admin": "vault-password",
+	}
+
+	cases := []struct {
+		name           string
+		data           string
+		expected       string
+		expectedFields []ResolvedField
+		expectedError  string
+	}{
+		{
+			name:     "no references",
+			data:     `{"name":"test","licence":"inline"}`,
+			expected: `{"name":"test","licence":"inline"}`,
+		},
+		{
+			name:     "nested references",
+			data:     `{"name":"test","licence":{"fromFile":"/etc/tfe/licence.rli"},"database":{"user":"tfe","password":{"fromEnv":"DB_PASSWORD"}},"replicas":[{"user":"a","password":"plain"},{"user":"b","password":{"fromVault":{"path":"secret/data/tfe","key":"admin"}}}]}`,
+			expected: `{"name":"test","licence":"licence-value","database":{"user":"tfe","password":"db-password"},"replicas":[{"user":"a","password":"plain"},{"user":"b","password":"vault-password"}]}`,
+			expectedFields: []ResolvedField{
+				{Path: []string{"licence"}, Reference: Reference{FromFile: "/etc/tfe/licence.rli"}},
+				{Path: []string{"database", "password"}, Reference: Reference{FromEnv: "DB_PASSWORD"}},
+				{Path: []string{"replicas", "1", "password"}, Reference: Reference{FromVault: &VaultReference{Path: "secret/data/tfe", Key: "admin"}}},
+			},
+		},
+		{
+			name:     "map values",
+			data:     `{"databases":{"main":{"password":{"fromEnv":"DB_PASSWORD"}}}}`,
+			expected: `{"databases":{"main":{"password":"db-password"}}}`,
+			expectedFields: []ResolvedField{
+				{Path: []string{"databases", "main", "password"}, Reference: Reference{FromEnv: "DB_PASSWORD"}},
+			},
+		},
+		{
+			name:          "objects in fields that are not sensitive are left alone",
+			data:          `{"name":{"fromEnv":"DB_PASSWORD"}}`,
+			expected:      `{"name":{"fromEnv":"DB_PASSWORD"}}`,
+			expectedError: "",
+		},
+		{
+			name:          "several sources",
+			data:          `{"licence":{"fromEnv":"DB_PASSWORD","fromFile":"/etc/tfe/licence.rli"}}`,
+			expectedError: `^licence: secret reference must set exactly one of fromFile, fromEnv and fromVault$`,
+		},
+		{
+			name:          "unknown source",
+			data:          `{"licence":{"fromAWS":"x"}}`,
+			expectedError: `^licence: invalid secret reference: json: unknown field "fromAWS"$`,
+		},
+		{
+			name:          "incomplete vault reference",
+			data:          `{"licence":{"fromVault":{"path":"secret/data/tfe"}}}`,
+			expectedError: `^licence: fromVault requires a path and a key$`,
+		},
+		{
+			name:          "unresolvable",
+			data:          `{"database":{"password":{"fromEnv":"MISSING"}}}`,
+			expectedError: `^failed to resolve database\.password: MISSING not found$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, fields, err := Resolve(context.Background(), resolver, reflect.TypeOf(testConfig{}), []byte(tc.data))
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.JSONEq(t, tc.expected, string(data))
+			assert.Equal(t, tc.expectedFields, fields)
+
+			restored, err := Restore(data, fields)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.data, string(restored))
+		})
+	}
+}
+
+func TestRestoreOmittedField(t *testing.T) {
+	fields := []ResolvedField{
+		{Path: []string{"database", "password"}, Reference: Reference{FromEnv: "DB_PASSWORD"}},
+	}
+	restored, err := Restore([]byte(`{"database":{"user":"tfe"}}`), fields)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"database":{"user":"tfe","password":{"fromEnv":"DB_PASSWORD"}}}`, string(restored))
+
+	_, err = Restore([]byte(`{"name":"test"}`), fields)
+	assert.Regexp(t, `^failed to restore the reference of database\.password: "database" not found$`, err)
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VaultResolver resolves fromVault references by reading secrets with the
+// Vault HTTP API. Both the KV version 1 and version 2 secret engines are
+// supported.
+type VaultResolver struct {
+	// Address is the address of the Vault server, e.g.
+	// https://vault.example.com:8200. VAULT_ADDR is used if it is empty.
+	Address string
+
+	// Token is the token to authenticate with. VAULT_TOKEN is used if it is
+	// empty.
+	Token string
+
+	// Client is the HTTP client to use. http.DefaultClient is used if it is
+	// nil.
+	Client *http.Client
+}
+
+type vaultSecret struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// Resolve reads the referenced key of the secret from Vault.
+func (r *VaultResolver) Resolve(ctx context.Context, ref *Reference) (string, error) {
+	address, token := r.Address, r.Token
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if address == "" {
+		return "", errors.New("the Vault address is not set; set VAULT_ADDR")
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(address, "/"), strings.TrimPrefix(ref.FromVault.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s from Vault", ref.FromVault.Path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("failed to read %s from Vault: %s", ref.FromVault.Path, resp.Status)
+	}
+
+	secret := &vaultSecret{}
+	if err := json.NewDecoder(resp.Body).Decode(secret); err != nil {
+		return "", errors.Wrapf(err, "failed to decode %s from Vault", ref.FromVault.Path)
+	}
+
+	data := secret.Data
+	// The KV version 2 engine nests the secret in data.data, next to its
+	// metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+	value, ok := data[ref.FromVault.Key]
+	if !ok {
+		return "", errors.Errorf("secret %s in Vault has no key %q", ref.FromVault.Path, ref.FromVault.Key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", errors.Errorf("key %q of secret %s in Vault is not a string", ref.FromVault.Key, ref.FromVault.Path)
+	}
+	return s, nil
+}
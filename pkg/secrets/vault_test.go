@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newVaultDevServer returns a stand-in for a Vault dev server with a KV
+// version 2 engine mounted at secret/ and a KV version 1 engine mounted at
+// kv/.
+func newVaultDevServer(token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/tfe":
+			fmt.Fprint(w, `{"data":{"data":{"licence":"kv2-licence","count":3},"metadata":{"version":1}}}`)
+		case "/v1/kv/tfe":
+			fmt.Fprint(w, `{"data":{"licence":"kv1-licence"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+}
+
+func TestVaultResolver(t *testing.T) {
+	server := newVaultDevServer("root")
+	defer server.Close()
+
+	cases := []struct {
+		name          string
+		token         string
+		ref           VaultReference
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "kv version 2",
+			token:    "root",
+			ref:      VaultReference{Path: "secret/data/tfe", Key: "licence"},
+			expected: "kv2-licence",
+		},
+		{
+			name:     "kv version 1",
+			token:    "root",
+			ref:      VaultReference{Path: "/kv/tfe", Key: "licence"},
+			expected: "kv1-licence",
+		},
+		{
+			name:          "missing key",
+			token:         "root",
+			ref:           VaultReference{Path: "secret/data/tfe", Key: "password"},
+			expectedError: `^secret secret/data/tfe in Vault has no key "password"$`,
+		},
+		{
+			name:          "not a string",
+			token:         "root",
+			ref:           VaultReference{Path: "secret/data/tfe", Key: "count"},
+			expectedError: `^key "count" of secret secret/data/tfe in Vault is not a string$`,
+		},
+		{
+			name:          "missing secret",
+			token:         "root",
+			ref:           VaultReference{Path: "secret/data/other", Key: "licence"},
+			expectedError: `^failed to read secret/data/other from Vault: 404 Not Found$`,
+		},
+		{
+			name:          "wrong token",
+			token:         "wrong",
+			ref:           VaultReference{Path: "secret/data/tfe", Key: "licence"},
+			expectedError: `^failed to read secret/data/tfe from Vault: 403 Forbidden$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &VaultResolver{Address: server.URL + "/", Token: tc.token, Client: server.Client()}
+			ref := tc.ref
+			value, err := resolver.Resolve(context.Background(), &Reference{FromVault: &ref})
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, value)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -66,6 +67,16 @@ func provider(name string) Provider {
 //go:embed mirror/*
 var mirror embed.FS
 
+// Mirror returns the embedded mirror: the terraform binary in the terraform
+// directory and the providers in the directories of their sources.
+func Mirror() fs.FS {
+	sub, err := fs.Sub(mirror, "mirror")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
 // Extract extracts the provider from the embedded data into the specified directory.
 func (p Provider) Extract(dir string) error {
 	providerDir := filepath.Join(strings.Split(p.Source, "/")...)
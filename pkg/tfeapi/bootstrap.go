@@ -0,0 +1,73 @@
+package tfeapi
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/bailey84j/terraform_installer/pkg/asset"
+)
+
+var (
+	// TokenPath is the path, relative to the install directory, where the
+	// API token of the initial admin user is stored.
+	TokenPath = filepath.Join("auth", "tfe-api-token")
+)
+
+// Bootstrap creates the initial admin user and the organizations that do not
+// exist yet. The API token of the admin user is stored in TokenPath under
+// directory. When that file already exists, the admin user is taken to exist
+// and its token is reused, so that Bootstrap can run again after a failure.
+func Bootstrap(ctx context.Context, c *Client, directory string, user *AdminUser, organizations []string) error {
+	tokenFile := filepath.Join(directory, TokenPath)
+	token, err := ioutil.ReadFile(tokenFile)
+	switch {
+	case err == nil:
+		logrus.Debugf("Using the API token in %s", tokenFile)
+		c.Token = strings.TrimSpace(string(token))
+	case os.IsNotExist(err):
+		if c.Token, err = createAdminUser(ctx, c, user); err != nil {
+			return errors.Wrapf(err, "failed to create the initial admin user %s; if it already exists, store an API token of it in %s", user.Username, tokenFile)
+		}
+		if err := os.MkdirAll(filepath.Dir(tokenFile), 0750); err != nil {
+			return errors.Wrap(err, "failed to create dir")
+		}
+		if err := asset.WriteFileAtomic(tokenFile, []byte(c.Token), 0600); err != nil {
+			return errors.Wrap(err, "failed to write the API token")
+		}
+		logrus.Infof("Created the initial admin user %s", user.Username)
+	default:
+		return errors.Wrap(err, "failed to read the API token")
+	}
+
+	for _, name := range organizations {
+		exists, err := c.OrganizationExists(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to look up organization %s", name)
+		}
+		if exists {
+			logrus.Debugf("Organization %s already exists", name)
+			continue
+		}
+		if err := c.CreateOrganization(ctx, name, user.Email); err != nil {
+			return errors.Wrapf(err, "failed to create organization %s", name)
+		}
+		logrus.Infof("Created organization %s", name)
+	}
+	return nil
+}
+
+// createAdminUser retrieves the initial admin creation token and creates the
+// admin user with it.
+func createAdminUser(ctx context.Context, c *Client, user *AdminUser) (string, error) {
+	iact, err := c.RetrieveIACT(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve the initial admin creation token, the address of the installer may be missing from tfe.iactSubnets")
+	}
+	return c.CreateInitialAdminUser(ctx, iact, user)
+}
@@ -0,0 +1,221 @@
+package tfeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIACT     = "test-iact"
+	testAPIToken = "test-api-token"
+)
+
+// fakeTFE is a stand-in for the API of Terraform Enterprise. It only accepts
+// one initial admin user, like the real API.
+type fakeTFE struct {
+	mu            sync.Mutex
+	unhealthy     int
+	adminUser     *AdminUser
+	organizations map[string]string
+	adminCreates  int
+	orgCreates    int
+}
+
+func newFakeTFE(t *testing.T, f *fakeTFE) *httptest.Server {
+	if f.organizations == nil {
+		f.organizations = map[string]string{}
+	}
+	server := httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeTFE) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/_health_check":
+		if f.unhealthy > 0 {
+			f.unhealthy--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "OK")
+	case r.URL.Path == "/admin/retrieve-iact" && r.Method == http.MethodGet:
+		fmt.Fprintln(w, testIACT)
+	case r.URL.Path == "/admin/initial-admin-user" && r.Method == http.MethodPost:
+		f.adminCreates++
+		if r.URL.Query().Get("token") != testIACT {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if f.adminUser != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"status":"error","message":"an initial admin user already exists"}`)
+			return
+		}
+		user := &AdminUser{}
+		if err := json.NewDecoder(r.Body).Decode(user); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.adminUser = user
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"status":"created","token":%q}`, testAPIToken)
+	case strings.HasPrefix(r.URL.Path, "/api/v2/"):
+		if r.Header.Get("Authorization") != "Bearer "+testAPIToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.serveOrganizations(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeTFE) serveOrganizations(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/v2/organizations" && r.Method == http.MethodPost:
+		if r.Header.Get("Content-Type") != "application/vnd.api+json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		org := &organization{}
+		if err := json.NewDecoder(r.Body).Decode(org); err != nil || org.Data.Type != "organizations" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, ok := f.organizations[org.Data.Attributes.Name]; ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"errors":[{"detail":"Name has already been taken"}]}`)
+			return
+		}
+		f.orgCreates++
+		f.organizations[org.Data.Attributes.Name] = org.Data.Attributes.Email
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(r.URL.Path, "/api/v2/organizations/") && r.Method == http.MethodGet:
+		if _, ok := f.organizations[strings.TrimPrefix(r.URL.Path, "/api/v2/organizations/")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var testAdminUser = &AdminUser{
+	Username: "admin",
+	Email:    "admin@example.com",
+	Password: "secret",
+}
+
+func TestBootstrap(t *testing.T) {
+	f := &fakeTFE{}
+	server := newFakeTFE(t, f)
+	directory := t.TempDir()
+
+	err := Bootstrap(context.Background(), &Client{Address: server.URL}, directory, testAdminUser, []string{"platform", "apps"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, testAdminUser, f.adminUser)
+	assert.Equal(t, map[string]string{"platform": "admin@example.com", "apps": "admin@example.com"}, f.organizations)
+
+	tokenFile := filepath.Join(directory, TokenPath)
+	token, err := ioutil.ReadFile(tokenFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, testAPIToken, string(token))
+	}
+	if info, err := os.Stat(tokenFile); assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// A re-run reuses the stored token and only creates the new organization.
+	err = Bootstrap(context.Background(), &Client{Address: server.URL}, directory, testAdminUser, []string{"platform", "apps", "security"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, f.adminCreates)
+	assert.Equal(t, 3, f.orgCreates)
+	assert.Contains(t, f.organizations, "security")
+}
+
+func TestBootstrapErrors(t *testing.T) {
+	cases := []struct {
+		name          string
+		tfe           *fakeTFE
+		token         string
+		expectedError string
+	}{
+		{
+			name:          "admin user exists without a stored token",
+			tfe:           &fakeTFE{adminUser: &AdminUser{Username: "admin"}},
+			expectedError: `^failed to create the initial admin user admin; if it already exists, store an API token of it in .*/auth/tfe-api-token: 422 Unprocessable Entity: {"status":"error","message":"an initial admin user already exists"}$`,
+		},
+		{
+			name:          "stored token is rejected",
+			tfe:           &fakeTFE{adminUser: &AdminUser{Username: "admin"}},
+			token:         "stale-token",
+			expectedError: `^failed to look up organization platform: 401 Unauthorized$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeTFE(t, tc.tfe)
+			directory := t.TempDir()
+			if tc.token != "" {
+				if err := os.MkdirAll(filepath.Join(directory, "auth"), 0750); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(directory, TokenPath), []byte(tc.token+"\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := Bootstrap(context.Background(), &Client{Address: server.URL}, directory, testAdminUser, []string{"platform"})
+			assert.Regexp(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestWaitUntilHealthy(t *testing.T) {
+	cases := []struct {
+		name          string
+		unhealthy     int
+		expectedError string
+	}{
+		{
+			name:      "healthy after a few checks",
+			unhealthy: 2,
+		},
+		{
+			name:          "never healthy",
+			unhealthy:     1000,
+			expectedError: `^the health check did not pass: 502 Bad Gateway$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeTFE(t, &fakeTFE{unhealthy: tc.unhealthy})
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := WaitUntilHealthy(ctx, &Client{Address: server.URL}, 10*time.Millisecond)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
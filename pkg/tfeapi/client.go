@@ -0,0 +1,281 @@
+// Package tfeapi finishes the setup of a running Terraform Enterprise through
+// its API.
+package tfeapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxErrorBodyLen is how much of the body of a failed response is included in
+// the error.
+const maxErrorBodyLen = 512
+
+// Client calls the API of Terraform Enterprise.
+type Client struct {
+	// Address is the URL of Terraform Enterprise, e.g.
+	// https://tfe.example.com.
+	Address string
+
+	// Token is the API token to authenticate with.
+	Token string
+
+	// Client is the HTTP client to use. http.DefaultClient is used if it is
+	// nil.
+	Client *http.Client
+}
+
+// AdminUser is the initial admin user of Terraform Enterprise.
+type AdminUser struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// CertificatePin pins the certificate that a server presents first. It stands
+// in for CA verification of a self-signed certificate: the first connection is
+// trusted, and every later connection must present the same certificate.
+type CertificatePin struct {
+	mutex       sync.Mutex
+	fingerprint []byte
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the pinned certificate in
+// hex, or "" if no certificate was pinned yet.
+func (p *CertificatePin) Fingerprint() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return hex.EncodeToString(p.fingerprint)
+}
+
+func (p *CertificatePin) verify(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("the server presented no certificate")
+	}
+	sum := sha256.Sum256(rawCerts[0])
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.fingerprint == nil {
+		p.fingerprint = sum[:]
+		return nil
+	}
+	if !bytes.Equal(p.fingerprint, sum[:]) {
+		return errors.Errorf("the server certificate %x does not match the pinned certificate %x", sum[:], p.fingerprint)
+	}
+	return nil
+}
+
+// NewHTTPClient returns an HTTP client that trusts the CAs in caBundle, or the
+// system roots if caBundle is empty. If pin is set, the certificate of the
+// server is checked against pin instead.
+func NewHTTPClient(caBundle []byte, pin *CertificatePin) (*http.Client, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case pin != nil:
+		// The chain is not verified, VerifyPeerCertificate checks the pin.
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = pin.verify
+	case len(caBundle) > 0:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("no PEM certificate found in the CA bundle")
+		}
+		config.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport, Timeout: time.Minute}, nil
+}
+
+// Healthy returns nil if the health check of Terraform Enterprise passes.
+func (c *Client) Healthy(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/_health_check", "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// WaitUntilHealthy polls the health check every interval until it passes or
+// ctx is done. In the latter case, the last failure is returned.
+func WaitUntilHealthy(ctx context.Context, c *Client, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		err := c.Healthy(ctx)
+		if err == nil {
+			return nil
+		}
+		// A check cut short by ctx says nothing about the health.
+		if ctx.Err() == nil {
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return errors.Wrap(lastErr, "the health check did not pass")
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetrieveIACT retrieves the initial admin creation token. Terraform
+// Enterprise only hands it out to the addresses in its IACT subnet list.
+func (c *Client) RetrieveIACT(ctx context.Context) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/admin/retrieve-iact", "", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	iact := strings.TrimSpace(string(data))
+	if iact == "" {
+		return "", errors.New("the response is empty")
+	}
+	return iact, nil
+}
+
+type initialAdminUserResponse struct {
+	Status string `json:"status"`
+	Token  string `json:"token"`
+}
+
+// CreateInitialAdminUser creates the initial admin user with the initial admin
+// creation token iact and returns the API token of the user.
+func (c *Client) CreateInitialAdminUser(ctx context.Context, iact string, user *AdminUser) (string, error) {
+	body, err := json.Marshal(user)
+	if err != nil {
+		return "", err
+	}
+	path := "/admin/initial-admin-user?token=" + url.QueryEscape(iact)
+	resp, err := c.do(ctx, http.MethodPost, path, "application/json", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", responseError(resp)
+	}
+	created := &initialAdminUserResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(created); err != nil {
+		return "", errors.Wrap(err, "failed to decode the response")
+	}
+	if created.Token == "" {
+		return "", errors.Errorf("the response has no token (status %q)", created.Status)
+	}
+	return created.Token, nil
+}
+
+// OrganizationExists returns whether the organization exists.
+func (c *Client) OrganizationExists(ctx context.Context, name string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/api/v2/organizations/"+url.PathEscape(name), "", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, responseError(resp)
+	}
+}
+
+type organization struct {
+	Data organizationData `json:"data"`
+}
+
+type organizationData struct {
+	Type       string                 `json:"type"`
+	Attributes organizationAttributes `json:"attributes"`
+}
+
+type organizationAttributes struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CreateOrganization creates an organization with the email address of its
+// owners.
+func (c *Client) CreateOrganization(ctx context.Context, name, email string) error {
+	body, err := json.Marshal(&organization{
+		Data: organizationData{
+			Type:       "organizations",
+			Attributes: organizationAttributes{Name: name, Email: email},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/api/v2/organizations", "application/vnd.api+json", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.Address, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// responseError returns an error with the status and the start of the body of
+// a failed response.
+func responseError(resp *http.Response) error {
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen))
+	if body := strings.TrimSpace(string(data)); body != "" {
+		return errors.Errorf("%s: %s", resp.Status, body)
+	}
+	return errors.New(resp.Status)
+}
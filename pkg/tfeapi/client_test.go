@@ -0,0 +1,97 @@
+package tfeapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTLSServer starts a server with a new self-signed certificate, unlike
+// httptest.NewTLSServer, which always serves the same one.
+func newTLSServer(t *testing.T) *httptest.Server {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tfe.example.com"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{raw}, PrivateKey: key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCertificatePin(t *testing.T) {
+	first := newTLSServer(t)
+	second := newTLSServer(t)
+
+	cases := []struct {
+		name          string
+		addresses     []string
+		expectedError string
+	}{
+		{
+			name:      "same certificate",
+			addresses: []string{first.URL, first.URL},
+		},
+		{
+			name:          "changed certificate",
+			addresses:     []string{first.URL, second.URL},
+			expectedError: `the server certificate [0-9a-f]{64} does not match the pinned certificate [0-9a-f]{64}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pin := &CertificatePin{}
+			httpClient, err := NewHTTPClient(nil, pin)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "", pin.Fingerprint())
+
+			for i, address := range tc.addresses {
+				// A new connection for each request, so that each is verified.
+				httpClient.Transport.(*http.Transport).CloseIdleConnections()
+				err = (&Client{Address: address, Client: httpClient}).Healthy(context.Background())
+				if i < len(tc.addresses)-1 {
+					if !assert.NoError(t, err) {
+						return
+					}
+				}
+			}
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+
+			sum := sha256.Sum256(first.Certificate().Raw)
+			assert.Equal(t, hex.EncodeToString(sum[:]), pin.Fingerprint())
+		})
+	}
+}
@@ -1,39 +1,206 @@
 // Package aws contains AWS-specific Terraform-variable logic.
 package aws
 
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
 type config struct {
 	AMI                          string            `json:"aws_ami"`
 	AMIRegion                    string            `json:"aws_ami_region"`
 	CustomEndpoints              map[string]string `json:"custom_endpoints,omitempty"`
 	ExtraTags                    map[string]string `json:"aws_extra_tags,omitempty"`
-	BootstrapInstanceType        string            `json:"aws_bootstrap_instance_type,omitempty"`
 	MasterInstanceType           string            `json:"aws_master_instance_type,omitempty"`
+	WorkerInstanceType           string            `json:"aws_worker_instance_type,omitempty"`
 	MasterAvailabilityZones      []string          `json:"aws_master_availability_zones"`
 	WorkerAvailabilityZones      []string          `json:"aws_worker_availability_zones"`
+	WorkerCount                  int64             `json:"aws_worker_count"`
 	IOPS                         int64             `json:"aws_master_root_volume_iops"`
 	Size                         int64             `json:"aws_master_root_volume_size,omitempty"`
 	Type                         string            `json:"aws_master_root_volume_type,omitempty"`
 	Encrypted                    bool              `json:"aws_master_root_volume_encrypted"`
 	KMSKeyID                     string            `json:"aws_master_root_volume_kms_key_id,omitempty"`
+	DataVolumeDevice             string            `json:"aws_master_data_volume_device,omitempty"`
+	WorkerIOPS                   int64             `json:"aws_worker_root_volume_iops,omitempty"`
+	WorkerSize                   int64             `json:"aws_worker_root_volume_size,omitempty"`
+	WorkerType                   string            `json:"aws_worker_root_volume_type,omitempty"`
+	WorkerKMSKeyID               string            `json:"aws_worker_root_volume_kms_key_id,omitempty"`
 	Region                       string            `json:"aws_region,omitempty"`
 	VPC                          string            `json:"aws_vpc,omitempty"`
 	PrivateSubnets               []string          `json:"aws_private_subnets,omitempty"`
 	PublicSubnets                *[]string         `json:"aws_public_subnets,omitempty"`
 	InternalZone                 string            `json:"aws_internal_zone,omitempty"`
 	PublishStrategy              string            `json:"aws_publish_strategy,omitempty"`
-	IgnitionBucket               string            `json:"aws_ignition_bucket"`
-	BootstrapIgnitionStub        string            `json:"aws_bootstrap_stub_ignition"`
+	UserData                     string            `json:"aws_user_data"`
 	MasterIAMRoleName            string            `json:"aws_master_iam_role_name,omitempty"`
 	WorkerIAMRoleName            string            `json:"aws_worker_iam_role_name,omitempty"`
 	MasterMetadataAuthentication string            `json:"aws_master_instance_metadata_authentication,omitempty"`
+	WorkerMetadataAuthentication string            `json:"aws_worker_instance_metadata_authentication,omitempty"`
+	CredentialsMode              string            `json:"aws_credentials_mode"`
+	ObjectStorageRoleARN         string            `json:"aws_object_storage_role_arn,omitempty"`
+	AgentsRoleARN                string            `json:"aws_agents_role_arn,omitempty"`
+	ObjectStorageBucket          string            `json:"aws_object_storage_bucket,omitempty"`
+	ObjectStorageKMSKeyARN       string            `json:"aws_object_storage_kms_key_arn,omitempty"`
 }
 
 // TFVarsSources contains the parameters to be converted into Terraform variables
 type TFVarsSources struct {
-	VPC string
+	VPC            string
+	PrivateSubnets []string
+	PublicSubnets  []string
+	InternalZone   string
+	Services       []aws.ServiceEndpoint
+	Publish        types.PublishingStrategy
+	Region         string
+	UserTags       map[string]string
+
+	// CredentialsMode is how the TFE components get their credentials, and
+	// ComponentRoles are the existing roles used when it is Manual.
+	CredentialsMode types.CredentialsMode
+	ComponentRoles  *aws.ComponentRoles
+
+	// ObjectStorage is the existing bucket of the external services, if
+	// any, that the object storage role is given access to.
+	ObjectStorage *types.ObjectStorage
+
+	// UserData is the cloud-init user data of the instances.
+	UserData string
+
+	// AMIID and AMIRegion are the AMI used when neither machine pool sets
+	// its own, and the region it belongs to.
+	AMIID     string
+	AMIRegion string
+
+	// MasterPool and WorkerPool are the fully-defaulted AWS configuration
+	// of the control plane and compute pools.
+	MasterPool     *aws.MachinePool
+	WorkerPool     *aws.MachinePool
+	MasterReplicas int64
+	WorkerReplicas int64
+
+	// DataVolumeDevice is the device name of the data volume of the
+	// control plane machines, if they have one.
+	DataVolumeDevice string
 }
 
 // TFVars generates AWS-specific Terraform variables launching the cluster.
 func TFVars(sources TFVarsSources) ([]byte, error) {
-	masterConfig := sources.MasterConfigs[0]
+	masterPool := sources.MasterPool
+	if masterPool == nil {
+		return nil, errors.New("control plane machine pool must be configured")
+	}
+	if len(masterPool.Zones) == 0 {
+		return nil, errors.New("control plane availability zones cannot be empty")
+	}
+	if masterPool.EC2RootVolume.Type == "" {
+		return nil, errors.New("EBS volume type must be configured for the root volume")
+	}
+	if masterPool.EC2RootVolume.Size == 0 {
+		return nil, errors.New("EBS volume size must be configured for the root volume")
+	}
+	if (masterPool.EC2RootVolume.Type == "io1" || masterPool.EC2RootVolume.Type == "io2") && masterPool.EC2RootVolume.IOPS == 0 {
+		return nil, errors.Errorf("EBS IOPS must be configured for the %s root volume", masterPool.EC2RootVolume.Type)
+	}
+
+	endpoints := make(map[string]string)
+	for _, ep := range sources.Services {
+		ep.URL = strings.TrimPrefix(ep.URL, "https://")
+		ep.URL = strings.TrimPrefix(ep.URL, "http://")
+		endpoints[ep.Name] = ep.URL
+	}
+
+	// Spread the control plane machines across the zones of the pool in
+	// order, in the same way as machine sets would.
+	masterAvailabilityZones := make([]string, sources.MasterReplicas)
+	for i := range masterAvailabilityZones {
+		masterAvailabilityZones[i] = masterPool.Zones[i%len(masterPool.Zones)]
+	}
+
+	cfg := &config{
+		CustomEndpoints:              endpoints,
+		Region:                       sources.Region,
+		ExtraTags:                    sources.UserTags,
+		MasterInstanceType:           masterPool.InstanceType,
+		MasterAvailabilityZones:      masterAvailabilityZones,
+		WorkerAvailabilityZones:      []string{},
+		IOPS:                         int64(masterPool.EC2RootVolume.IOPS),
+		Size:                         int64(masterPool.EC2RootVolume.Size),
+		Type:                         masterPool.EC2RootVolume.Type,
+		Encrypted:                    true,
+		KMSKeyID:                     masterPool.EC2RootVolume.KMSKeyARN,
+		DataVolumeDevice:             sources.DataVolumeDevice,
+		VPC:                          sources.VPC,
+		PrivateSubnets:               sources.PrivateSubnets,
+		InternalZone:                 sources.InternalZone,
+		PublishStrategy:              string(sources.Publish),
+		MasterIAMRoleName:            masterPool.IAMRole,
+		MasterMetadataAuthentication: masterPool.EC2Metadata.Authentication,
+		AMI:                          sources.AMIID,
+		AMIRegion:                    sources.AMIRegion,
+		CredentialsMode:              string(sources.CredentialsMode),
+		UserData:                     sources.UserData,
+	}
+
+	if sources.CredentialsMode == types.ManualCredentialsMode {
+		if sources.ComponentRoles == nil {
+			return nil, errors.New("component roles must be configured when the credentials mode is Manual")
+		}
+		cfg.ObjectStorageRoleARN = sources.ComponentRoles.ObjectStorage
+		cfg.AgentsRoleARN = sources.ComponentRoles.Agents
+	}
+
+	if sources.ObjectStorage != nil {
+		cfg.ObjectStorageBucket = sources.ObjectStorage.Bucket
+		cfg.ObjectStorageKMSKeyARN = sources.ObjectStorage.KMSKeyARN
+	}
+
+	if masterPool.AMIID != "" {
+		cfg.AMI = masterPool.AMIID
+		cfg.AMIRegion = sources.Region
+	}
+
+	if workerPool := sources.WorkerPool; workerPool != nil {
+		cfg.WorkerInstanceType = workerPool.InstanceType
+		cfg.WorkerCount = sources.WorkerReplicas
+		cfg.WorkerIAMRoleName = workerPool.IAMRole
+		cfg.WorkerIOPS = int64(workerPool.EC2RootVolume.IOPS)
+		cfg.WorkerSize = int64(workerPool.EC2RootVolume.Size)
+		cfg.WorkerType = workerPool.EC2RootVolume.Type
+		cfg.WorkerKMSKeyID = workerPool.EC2RootVolume.KMSKeyARN
+		cfg.WorkerMetadataAuthentication = workerPool.EC2Metadata.Authentication
+		cfg.WorkerAvailabilityZones = append(cfg.WorkerAvailabilityZones, workerPool.Zones...)
+		sort.Strings(cfg.WorkerAvailabilityZones)
+	}
+
+	// Internal installs put the load balancer on the private subnets and
+	// never use public subnets, even if the VPC has some.
+	if len(sources.PublicSubnets) == 0 || sources.Publish == types.InternalPublishingStrategy {
+		if cfg.VPC != "" {
+			cfg.PublicSubnets = &[]string{}
+		}
+	} else {
+		cfg.PublicSubnets = &sources.PublicSubnets
+	}
+
+	return json.MarshalIndent(cfg, "", "  ")
+}
+
+type passthroughConfig struct {
+	AccessKeyID     string `json:"aws_passthrough_access_key_id"`
+	SecretAccessKey string `json:"aws_passthrough_secret_access_key"`
+}
+
+// PassthroughTFVars generates the Terraform variables with the installer's
+// credentials, which TFE uses when the credentials mode is Passthrough. They
+// are kept apart from the other variables so that the credentials are only
+// written to the temporary directory Terraform runs in.
+func PassthroughTFVars(accessKeyID, secretAccessKey string) ([]byte, error) {
+	return json.MarshalIndent(&passthroughConfig{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey}, "", "  ")
 }
@@ -0,0 +1,227 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+func validMasterPool() *aws.MachinePool {
+	return &aws.MachinePool{
+		Zones:        []string{"us-east-1a", "us-east-1b"},
+		InstanceType: "m6i.xlarge",
+		EC2RootVolume: aws.EC2RootVolume{
+			Type: "gp3",
+			Size: 120,
+		},
+	}
+}
+
+func validSources() TFVarsSources {
+	return TFVarsSources{
+		Region:          "us-east-1",
+		Publish:         types.ExternalPublishingStrategy,
+		CredentialsMode: types.MintCredentialsMode,
+		AMIID:           "ami-0123456789abcdef0",
+		AMIRegion:       "us-east-1",
+		MasterPool:      validMasterPool(),
+		MasterReplicas:  3,
+	}
+}
+
+func TestTFVars(t *testing.T) {
+	cases := []struct {
+		name          string
+		sources       func(*TFVarsSources)
+		expectedError string
+		expected      func(*testing.T, *config)
+	}{
+		{
+			name: "control plane spread across zones",
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, []string{"us-east-1a", "us-east-1b", "us-east-1a"}, c.MasterAvailabilityZones)
+				assert.Equal(t, "m6i.xlarge", c.MasterInstanceType)
+				assert.Equal(t, "gp3", c.Type)
+				assert.Equal(t, int64(120), c.Size)
+				assert.True(t, c.Encrypted)
+				assert.Equal(t, []string{}, c.WorkerAvailabilityZones)
+				assert.Equal(t, int64(0), c.WorkerCount)
+				assert.Nil(t, c.PublicSubnets)
+			},
+		},
+		{
+			name:          "missing control plane pool",
+			sources:       func(s *TFVarsSources) { s.MasterPool = nil },
+			expectedError: `^control plane machine pool must be configured$`,
+		},
+		{
+			name:          "missing control plane zones",
+			sources:       func(s *TFVarsSources) { s.MasterPool.Zones = nil },
+			expectedError: `^control plane availability zones cannot be empty$`,
+		},
+		{
+			name:          "missing root volume type",
+			sources:       func(s *TFVarsSources) { s.MasterPool.EC2RootVolume.Type = "" },
+			expectedError: `^EBS volume type must be configured for the root volume$`,
+		},
+		{
+			name:          "missing root volume size",
+			sources:       func(s *TFVarsSources) { s.MasterPool.EC2RootVolume.Size = 0 },
+			expectedError: `^EBS volume size must be configured for the root volume$`,
+		},
+		{
+			name:          "io1 root volume without IOPS",
+			sources:       func(s *TFVarsSources) { s.MasterPool.EC2RootVolume.Type = "io1" },
+			expectedError: `^EBS IOPS must be configured for the io1 root volume$`,
+		},
+		{
+			name: "io2 root volume with IOPS",
+			sources: func(s *TFVarsSources) {
+				s.MasterPool.EC2RootVolume.Type = "io2"
+				s.MasterPool.EC2RootVolume.IOPS = 4000
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, "io2", c.Type)
+				assert.Equal(t, int64(4000), c.IOPS)
+			},
+		},
+		{
+			name: "control plane AMI",
+			sources: func(s *TFVarsSources) {
+				s.AMIRegion = "eu-west-1"
+				s.MasterPool.AMIID = "ami-0fedcba9876543210"
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, "ami-0fedcba9876543210", c.AMI)
+				assert.Equal(t, "us-east-1", c.AMIRegion)
+			},
+		},
+		{
+			name: "compute pool",
+			sources: func(s *TFVarsSources) {
+				s.WorkerPool = &aws.MachinePool{
+					Zones:        []string{"us-east-1c", "us-east-1a"},
+					InstanceType: "m6i.large",
+					EC2RootVolume: aws.EC2RootVolume{
+						Type:      "io1",
+						Size:      200,
+						IOPS:      3000,
+						KMSKeyARN: "arn:aws:kms:us-east-1:123456789012:key/abcd",
+					},
+					EC2Metadata: aws.EC2Metadata{Authentication: "Required"},
+					IAMRole:     "tfe-worker",
+				}
+				s.WorkerReplicas = 2
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, "m6i.large", c.WorkerInstanceType)
+				assert.Equal(t, int64(2), c.WorkerCount)
+				assert.Equal(t, []string{"us-east-1a", "us-east-1c"}, c.WorkerAvailabilityZones)
+				assert.Equal(t, "io1", c.WorkerType)
+				assert.Equal(t, int64(200), c.WorkerSize)
+				assert.Equal(t, int64(3000), c.WorkerIOPS)
+				assert.Equal(t, "arn:aws:kms:us-east-1:123456789012:key/abcd", c.WorkerKMSKeyID)
+				assert.Equal(t, "Required", c.WorkerMetadataAuthentication)
+				assert.Equal(t, "tfe-worker", c.WorkerIAMRoleName)
+			},
+		},
+		{
+			name: "service endpoints",
+			sources: func(s *TFVarsSources) {
+				s.Services = []aws.ServiceEndpoint{
+					{Name: "ec2", URL: "https://ec2.example.com"},
+					{Name: "s3", URL: "http://s3.example.com"},
+				}
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, map[string]string{"ec2": "ec2.example.com", "s3": "s3.example.com"}, c.CustomEndpoints)
+			},
+		},
+		{
+			name: "manual credentials",
+			sources: func(s *TFVarsSources) {
+				s.CredentialsMode = types.ManualCredentialsMode
+				s.ComponentRoles = &aws.ComponentRoles{
+					ObjectStorage: "arn:aws:iam::123456789012:role/tfe-object-storage",
+					Agents:        "arn:aws:iam::123456789012:role/tfe-agents",
+				}
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, "Manual", c.CredentialsMode)
+				assert.Equal(t, "arn:aws:iam::123456789012:role/tfe-object-storage", c.ObjectStorageRoleARN)
+				assert.Equal(t, "arn:aws:iam::123456789012:role/tfe-agents", c.AgentsRoleARN)
+			},
+		},
+		{
+			name:          "manual credentials without roles",
+			sources:       func(s *TFVarsSources) { s.CredentialsMode = types.ManualCredentialsMode },
+			expectedError: `^component roles must be configured when the credentials mode is Manual$`,
+		},
+		{
+			name: "existing VPC with public subnets",
+			sources: func(s *TFVarsSources) {
+				s.VPC = "vpc-1"
+				s.PrivateSubnets = []string{"subnet-private"}
+				s.PublicSubnets = []string{"subnet-public"}
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, []string{"subnet-private"}, c.PrivateSubnets)
+				assert.Equal(t, &[]string{"subnet-public"}, c.PublicSubnets)
+			},
+		},
+		{
+			name: "existing VPC without public subnets",
+			sources: func(s *TFVarsSources) {
+				s.VPC = "vpc-1"
+				s.PrivateSubnets = []string{"subnet-private"}
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, &[]string{}, c.PublicSubnets)
+			},
+		},
+		{
+			name:    "data volume",
+			sources: func(s *TFVarsSources) { s.DataVolumeDevice = "/dev/sdf" },
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, "/dev/sdf", c.DataVolumeDevice)
+			},
+		},
+		{
+			name: "internal install ignores public subnets",
+			sources: func(s *TFVarsSources) {
+				s.Publish = types.InternalPublishingStrategy
+				s.VPC = "vpc-1"
+				s.PrivateSubnets = []string{"subnet-private"}
+				s.PublicSubnets = []string{"subnet-public"}
+			},
+			expected: func(t *testing.T, c *config) {
+				assert.Equal(t, "Internal", c.PublishStrategy)
+				assert.Equal(t, &[]string{}, c.PublicSubnets)
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sources := validSources()
+			if tc.sources != nil {
+				tc.sources(&sources)
+			}
+			data, err := TFVars(sources)
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			c := &config{}
+			if assert.NoError(t, json.Unmarshal(data, c)) && tc.expected != nil {
+				tc.expected(t, c)
+			}
+		})
+	}
+}
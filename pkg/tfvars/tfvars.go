@@ -3,51 +3,101 @@ package tfvars
 
 import (
 	"encoding/json"
-	"io/ioutil"
 	"strings"
 
-	"github.com/pkg/errors"
+	"github.com/bailey84j/terraform_installer/pkg/types"
 )
 
 type config struct {
 	ClusterID          string   `json:"cluster_id,omitempty"`
 	ClusterDomain      string   `json:"cluster_domain,omitempty"`
 	BaseDomain         string   `json:"base_domain,omitempty"`
-	Masters            int      `json:"master_count,omitempty"`
 	MastersSchedulable bool     `json:"masters_schedulable,omitempty"`
 	MachineV4CIDRs     []string `json:"machine_v4_cidrs"`
 	MachineV6CIDRs     []string `json:"machine_v6_cidrs"`
 
 	UseIPv4 bool `json:"use_ipv4"`
 	UseIPv6 bool `json:"use_ipv6"`
+
+	AirgapPackageURL      string `json:"airgap_package_url,omitempty"`
+	AirgapBootstrapperURL string `json:"airgap_bootstrapper_url,omitempty"`
+
+	TFEHostname string `json:"tfe_hostname,omitempty"`
+}
+
+// TFVarsSources contains the parameters to be converted into Terraform variables
+type TFVarsSources struct {
+	ClusterID          string
+	ClusterDomain      string
+	BaseDomain         string
+	MachineV4CIDRs     []string
+	MachineV6CIDRs     []string
+	UseIPv4            bool
+	UseIPv6            bool
+	MastersSchedulable bool
+
+	// Airgap is where the instances install Terraform Enterprise from when
+	// they have no internet access, if set. The instances are allowed to
+	// fetch it when it is in S3.
+	Airgap *types.Airgap
+
+	// TFEHostname is the hostname users reach Terraform Enterprise at.
+	TFEHostname string
 }
 
 // TFVars generates terraform.tfvar JSON for launching the cluster.
-func TFVars(clusterID string, clusterDomain string, baseDomain string, machineV4CIDRs []string, machineV6CIDRs []string, useIPv4, useIPv6 bool, bootstrapIgn string, masterIgn string, masterCount int, mastersSchedulable bool) ([]byte, error) {
-	f, err := ioutil.TempFile("", "openshift-install-bootstrap-*.ign")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create tmp file for bootstrap ignition")
-	}
-	defer f.Close()
+func TFVars(sources TFVarsSources) ([]byte, error) {
+	config := &config{
+		ClusterID:          sources.ClusterID,
+		ClusterDomain:      strings.TrimSuffix(sources.ClusterDomain, "."),
+		BaseDomain:         strings.TrimSuffix(sources.BaseDomain, "."),
+		MachineV4CIDRs:     sources.MachineV4CIDRs,
+		MachineV6CIDRs:     sources.MachineV6CIDRs,
+		UseIPv4:            sources.UseIPv4,
+		UseIPv6:            sources.UseIPv6,
+		MastersSchedulable: sources.MastersSchedulable,
 
-	if _, err := f.WriteString(bootstrapIgn); err != nil {
-		return nil, errors.Wrap(err, "failed to write bootstrap ignition")
+		TFEHostname: strings.TrimSuffix(sources.TFEHostname, "."),
 	}
 
-	config := &config{
-		ClusterID:          clusterID,
-		ClusterDomain:      strings.TrimSuffix(clusterDomain, "."),
-		BaseDomain:         strings.TrimSuffix(baseDomain, "."),
-		MachineV4CIDRs:     machineV4CIDRs,
-		MachineV6CIDRs:     machineV6CIDRs,
-		UseIPv4:            useIPv4,
-		UseIPv6:            useIPv6,
-		Masters:            masterCount,
-		MastersSchedulable: mastersSchedulable,
-		//IgnitionBootstrap:     bootstrapIgn,
-		//IgnitionBootstrapFile: f.Name(),
-		//IgnitionMaster:        masterIgn,
+	if sources.Airgap != nil {
+		config.AirgapPackageURL = sources.Airgap.PackageURL
+		config.AirgapBootstrapperURL = sources.Airgap.BootstrapperURL
 	}
 
 	return json.MarshalIndent(config, "", "  ")
 }
+
+type secretConfig struct {
+	TFELicence        string `json:"tfe_licence"`
+	TFETLSKey         string `json:"tfe_tls_key,omitempty"`
+	TFESecretSettings string `json:"tfe_secret_settings"`
+}
+
+// SecretTFVarsSources contains the secrets to be converted into Terraform
+// variables.
+type SecretTFVarsSources struct {
+	// Licence is the licence of Terraform Enterprise.
+	Licence []byte
+
+	// TLSKey is the PEM private key of the certificate of Terraform
+	// Enterprise. It is empty when Terraform Enterprise generates its own
+	// self-signed certificate.
+	TLSKey []byte
+
+	// Settings are the rendered secret application settings of Terraform
+	// Enterprise.
+	Settings []byte
+}
+
+// SecretTFVars generates the Terraform variables with the secrets of
+// Terraform Enterprise, which are stored where the instances fetch them from.
+// They are kept apart from the other variables so that the secrets are only
+// written to the temporary directory Terraform runs in.
+func SecretTFVars(sources SecretTFVarsSources) ([]byte, error) {
+	return json.MarshalIndent(&secretConfig{
+		TFELicence:        string(sources.Licence),
+		TFETLSKey:         string(sources.TLSKey),
+		TFESecretSettings: string(sources.Settings),
+	}, "", "  ")
+}
@@ -1,6 +1,8 @@
 package defaults
 
 import (
+	"fmt"
+
 	"github.com/bailey84j/terraform_installer/pkg/types"
 	"github.com/bailey84j/terraform_installer/pkg/types/aws"
 )
@@ -18,6 +20,11 @@ var (
 	}
 )
 
+const (
+	defaultRootVolumeType = "gp3"
+	defaultRootVolumeSize = 120
+)
+
 // SetPlatformDefaults sets the defaults for the platform.
 func SetPlatformDefaults(p *aws.Platform) {
 }
@@ -38,3 +45,22 @@ func InstanceClasses(region string, arch types.Architecture) []string {
 		return []string{"m6i", "m5"}
 	}
 }
+
+// SetMachinePoolDefaults sets the defaults for an AWS machine pool with the
+// given role. The control plane gets an xlarge instance of the preferred class
+// for the region and compute machines get a large one.
+func SetMachinePoolDefaults(p *aws.MachinePool, region string, arch types.Architecture, role string) {
+	if p.InstanceType == "" {
+		size := "large"
+		if role == types.MachinePoolControlPlaneRoleName {
+			size = "xlarge"
+		}
+		p.InstanceType = fmt.Sprintf("%s.%s", InstanceClasses(region, arch)[0], size)
+	}
+	if p.EC2RootVolume.Type == "" {
+		p.EC2RootVolume.Type = defaultRootVolumeType
+	}
+	if p.EC2RootVolume.Size == 0 {
+		p.EC2RootVolume.Size = defaultRootVolumeSize
+	}
+}
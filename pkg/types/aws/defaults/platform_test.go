@@ -0,0 +1,94 @@
+package defaults
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+func TestSetMachinePoolDefaults(t *testing.T) {
+	cases := []struct {
+		name     string
+		pool     *aws.MachinePool
+		arch     types.Architecture
+		role     string
+		expected *aws.MachinePool
+	}{
+		{
+			name: "control plane",
+			pool: &aws.MachinePool{},
+			arch: types.ArchitectureAMD64,
+			role: types.MachinePoolControlPlaneRoleName,
+			expected: &aws.MachinePool{
+				InstanceType:  "m6i.xlarge",
+				EC2RootVolume: aws.EC2RootVolume{Type: "gp3", Size: 120},
+			},
+		},
+		{
+			name: "compute",
+			pool: &aws.MachinePool{},
+			arch: types.ArchitectureAMD64,
+			role: types.MachinePoolComputeRoleName,
+			expected: &aws.MachinePool{
+				InstanceType:  "m6i.large",
+				EC2RootVolume: aws.EC2RootVolume{Type: "gp3", Size: 120},
+			},
+		},
+		{
+			name: "arm64 control plane",
+			pool: &aws.MachinePool{},
+			arch: types.ArchitectureARM64,
+			role: types.MachinePoolControlPlaneRoleName,
+			expected: &aws.MachinePool{
+				InstanceType:  "m6g.xlarge",
+				EC2RootVolume: aws.EC2RootVolume{Type: "gp3", Size: 120},
+			},
+		},
+		{
+			name: "configured pool",
+			pool: &aws.MachinePool{
+				InstanceType:  "r6i.2xlarge",
+				EC2RootVolume: aws.EC2RootVolume{Type: "io1", Size: 500, IOPS: 3000},
+			},
+			arch: types.ArchitectureAMD64,
+			role: types.MachinePoolControlPlaneRoleName,
+			expected: &aws.MachinePool{
+				InstanceType:  "r6i.2xlarge",
+				EC2RootVolume: aws.EC2RootVolume{Type: "io1", Size: 500, IOPS: 3000},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetMachinePoolDefaults(tc.pool, "us-east-1", tc.arch, tc.role)
+			assert.Equal(t, tc.expected, tc.pool)
+		})
+	}
+}
+
+func TestInstanceClasses(t *testing.T) {
+	cases := []struct {
+		name     string
+		arch     types.Architecture
+		expected []string
+	}{
+		{
+			name:     "amd64",
+			arch:     types.ArchitectureAMD64,
+			expected: []string{"m6i", "m5"},
+		},
+		{
+			name:     "arm64",
+			arch:     types.ArchitectureARM64,
+			expected: []string{"m6g"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, InstanceClasses("us-east-1", tc.arch))
+		})
+	}
+}
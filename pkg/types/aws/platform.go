@@ -32,6 +32,12 @@ type Platform struct {
 	// +optional
 	HostedZone string `json:"hostedZone,omitempty"`
 
+	// DefaultMachinePlatform is the default configuration used when
+	// installing on AWS for machine pools which do not define their own
+	// platform configuration.
+	// +optional
+	DefaultMachinePlatform *MachinePool `json:"defaultMachinePlatform,omitempty"`
+
 	// UserTags additional keys and values that the installer will add
 	// as tags to all resources that it creates. Resources created by the
 	// cluster itself may not include these tags.
@@ -44,6 +50,11 @@ type Platform struct {
 	// +optional
 	ServiceEndpoints []ServiceEndpoint `json:"serviceEndpoints,omitempty"`
 
+	// ComponentRoles are the IAM roles of the TFE components. They must be
+	// set when the credentials mode is Manual, and may not be set otherwise.
+	// +optional
+	ComponentRoles *ComponentRoles `json:"componentRoles,omitempty"`
+
 	// PropagateUserTags is a flag that directs in-cluster operators
 	// to include the specified user tags in the tags of the
 	// AWS resources that the operators create.
@@ -88,6 +99,17 @@ type ServiceEndpoint struct {
 	URL string `json:"url"`
 }
 
+// ComponentRoles are the ARNs of existing IAM roles assumed by the TFE
+// components.
+type ComponentRoles struct {
+	// ObjectStorage is the role TFE uses to access its object storage.
+	ObjectStorage string `json:"objectStorage"`
+
+	// Agents is the role assumed by TFE agents.
+	// +optional
+	Agents string `json:"agents,omitempty"`
+}
+
 // IsSecretRegion returns true if the region is part of either the ISO or ISOB partitions.
 func IsSecretRegion(region string) bool {
 	partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
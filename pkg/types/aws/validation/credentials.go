@@ -0,0 +1,54 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+// ValidateCredentialsMode checks that the AWS platform provides what the
+// credentials mode needs. Manual requires the roles of the TFE components,
+// which may not be set in the other modes.
+func ValidateCredentialsMode(p *aws.Platform, mode types.CredentialsMode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	rolesPath := fldPath.Child("componentRoles")
+
+	if mode != types.ManualCredentialsMode {
+		if p.ComponentRoles != nil {
+			allErrs = append(allErrs, field.Forbidden(rolesPath, "may only be set when credentialsMode is Manual"))
+		}
+		return allErrs
+	}
+
+	if p.ComponentRoles == nil {
+		return append(allErrs, field.Required(rolesPath, "must be set when credentialsMode is Manual"))
+	}
+	if p.ComponentRoles.ObjectStorage == "" {
+		allErrs = append(allErrs, field.Required(rolesPath.Child("objectStorage"), "must be set when credentialsMode is Manual"))
+	} else if err := validateRoleARN(p.ComponentRoles.ObjectStorage); err != "" {
+		allErrs = append(allErrs, field.Invalid(rolesPath.Child("objectStorage"), p.ComponentRoles.ObjectStorage, err))
+	}
+	if p.ComponentRoles.Agents != "" {
+		if err := validateRoleARN(p.ComponentRoles.Agents); err != "" {
+			allErrs = append(allErrs, field.Invalid(rolesPath.Child("agents"), p.ComponentRoles.Agents, err))
+		}
+	}
+	return allErrs
+}
+
+// validateRoleARN returns why s is not the ARN of an IAM role, or an empty
+// string if it is.
+func validateRoleARN(s string) string {
+	parsed, err := arn.Parse(s)
+	if err != nil {
+		return err.Error()
+	}
+	if parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+		return "must be the ARN of an IAM role"
+	}
+	return ""
+}
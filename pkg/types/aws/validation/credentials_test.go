@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+func TestValidateCredentialsMode(t *testing.T) {
+	cases := []struct {
+		name          string
+		mode          types.CredentialsMode
+		roles         *aws.ComponentRoles
+		expectedError string
+	}{
+		{
+			name: "mint",
+			mode: types.MintCredentialsMode,
+		},
+		{
+			name:          "mint with roles",
+			mode:          types.MintCredentialsMode,
+			roles:         &aws.ComponentRoles{ObjectStorage: "arn:aws:iam::123456789012:role/tfe"},
+			expectedError: `^platform.aws.componentRoles: Forbidden: may only be set when credentialsMode is Manual$`,
+		},
+		{
+			name: "passthrough",
+			mode: types.PassthroughCredentialsMode,
+		},
+		{
+			name: "manual",
+			mode: types.ManualCredentialsMode,
+			roles: &aws.ComponentRoles{
+				ObjectStorage: "arn:aws:iam::123456789012:role/tfe-object-storage",
+				Agents:        "arn:aws-us-gov:iam::123456789012:role/path/tfe-agents",
+			},
+		},
+		{
+			name:          "manual without roles",
+			mode:          types.ManualCredentialsMode,
+			expectedError: `^platform.aws.componentRoles: Required value: must be set when credentialsMode is Manual$`,
+		},
+		{
+			name:          "manual without object storage role",
+			mode:          types.ManualCredentialsMode,
+			roles:         &aws.ComponentRoles{Agents: "arn:aws:iam::123456789012:role/tfe-agents"},
+			expectedError: `^platform.aws.componentRoles.objectStorage: Required value: must be set when credentialsMode is Manual$`,
+		},
+		{
+			name:          "manual with invalid ARN",
+			mode:          types.ManualCredentialsMode,
+			roles:         &aws.ComponentRoles{ObjectStorage: "tfe-object-storage"},
+			expectedError: `^platform.aws.componentRoles.objectStorage: Invalid value: "tfe-object-storage": arn: invalid prefix$`,
+		},
+		{
+			name: "manual with a user ARN",
+			mode: types.ManualCredentialsMode,
+			roles: &aws.ComponentRoles{
+				ObjectStorage: "arn:aws:iam::123456789012:role/tfe-object-storage",
+				Agents:        "arn:aws:iam::123456789012:user/tfe-agents",
+			},
+			expectedError: `^platform.aws.componentRoles.agents: Invalid value: "arn:aws:iam::123456789012:user/tfe-agents": must be the ARN of an IAM role$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			platform := &aws.Platform{Region: "us-east-1", ComponentRoles: tc.roles}
+			err := ValidateCredentialsMode(platform, tc.mode, field.NewPath("platform", "aws"))
+			if tc.expectedError == "" {
+				assert.Empty(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err.ToAggregate().Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+var (
+	validMetadataAuthValues = []string{"Required", "Optional"}
+
+	// provisionedIOPSVolumeTypes are the volume types whose IOPS must be
+	// set explicitly.
+	provisionedIOPSVolumeTypes = map[string]bool{"io1": true, "io2": true}
+)
+
+// ValidateMachinePool checks that the specified machine pool is valid.
+func ValidateMachinePool(platform *aws.Platform, p *aws.MachinePool, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, zone := range p.Zones {
+		if !strings.HasPrefix(zone, platform.Region) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("zones").Index(i), zone, fmt.Sprintf("Zone not in configured region (%s)", platform.Region)))
+		}
+	}
+
+	if p.IOPS < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("iops"), p.IOPS, "Storage IOPS must be positive"))
+	}
+	if p.Size < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("size"), p.Size, "Storage size must be positive"))
+	}
+	if provisionedIOPSVolumeTypes[p.Type] && p.IOPS == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("iops"), fmt.Sprintf("IOPS must be set for %s volumes", p.Type)))
+	}
+
+	if p.EC2Metadata.Authentication != "" {
+		valid := false
+		for _, v := range validMetadataAuthValues {
+			if p.EC2Metadata.Authentication == v {
+				valid = true
+			}
+		}
+		if !valid {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("authentication"), p.EC2Metadata.Authentication, validMetadataAuthValues))
+		}
+	}
+
+	return allErrs
+}
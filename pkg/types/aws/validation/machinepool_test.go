@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+func TestValidateMachinePool(t *testing.T) {
+	cases := []struct {
+		name          string
+		pool          *aws.MachinePool
+		expectedError string
+	}{
+		{
+			name: "empty",
+			pool: &aws.MachinePool{},
+		},
+		{
+			name: "valid zones",
+			pool: &aws.MachinePool{Zones: []string{"us-east-1a", "us-east-1b"}},
+		},
+		{
+			name:          "zone in another region",
+			pool:          &aws.MachinePool{Zones: []string{"us-east-1a", "us-west-2a"}},
+			expectedError: `^test-path.zones\[1\]: Invalid value: "us-west-2a": Zone not in configured region \(us-east-1\)$`,
+		},
+		{
+			name: "valid root volume",
+			pool: &aws.MachinePool{EC2RootVolume: aws.EC2RootVolume{Type: "gp3", Size: 200, IOPS: 4000}},
+		},
+		{
+			name:          "negative IOPS",
+			pool:          &aws.MachinePool{EC2RootVolume: aws.EC2RootVolume{Type: "gp3", IOPS: -1}},
+			expectedError: `^test-path.iops: Invalid value: -1: Storage IOPS must be positive$`,
+		},
+		{
+			name:          "negative size",
+			pool:          &aws.MachinePool{EC2RootVolume: aws.EC2RootVolume{Type: "gp3", Size: -1}},
+			expectedError: `^test-path.size: Invalid value: -1: Storage size must be positive$`,
+		},
+		{
+			name:          "io1 without IOPS",
+			pool:          &aws.MachinePool{EC2RootVolume: aws.EC2RootVolume{Type: "io1", Size: 200}},
+			expectedError: `^test-path.iops: Required value: IOPS must be set for io1 volumes$`,
+		},
+		{
+			name: "io2 with IOPS",
+			pool: &aws.MachinePool{EC2RootVolume: aws.EC2RootVolume{Type: "io2", Size: 200, IOPS: 4000}},
+		},
+		{
+			name: "required metadata authentication",
+			pool: &aws.MachinePool{EC2Metadata: aws.EC2Metadata{Authentication: "Required"}},
+		},
+		{
+			name: "optional metadata authentication",
+			pool: &aws.MachinePool{EC2Metadata: aws.EC2Metadata{Authentication: "Optional"}},
+		},
+		{
+			name:          "unsupported metadata authentication",
+			pool:          &aws.MachinePool{EC2Metadata: aws.EC2Metadata{Authentication: "required"}},
+			expectedError: `^test-path.authentication: Unsupported value: "required": supported values: "Required", "Optional"$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			platform := &aws.Platform{Region: "us-east-1"}
+			err := ValidateMachinePool(platform, tc.pool, field.NewPath("test-path")).ToAggregate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -1,29 +1,20 @@
 package conversion
 
 import (
-	"fmt"
-
-	"k8s.io/apimachinery/pkg/util/validation/field"
-
+	"github.com/bailey84j/terraform_installer/pkg/ipnet"
 	"github.com/bailey84j/terraform_installer/pkg/types"
 	"github.com/bailey84j/terraform_installer/pkg/types/aws"
 )
 
 // ConvertInstallConfig is modeled after the k8s conversion schemes, which is
 // how deprecated values are upconverted.
-// This updates the APIVersion to reflect the fact that we've internally
-// upconverted.
+// This converts the config to the current API version with the registered
+// conversions and updates the APIVersion to reflect that.
 func ConvertInstallConfig(config *types.InstallConfig) error {
-	// check that the version is convertible
-	switch config.APIVersion {
-	case types.InstallConfigVersion, "v1beta3", "v1beta4":
-		// works
-	case "":
-		return field.Required(field.NewPath("apiVersion"), "no version was provided")
-	default:
-		return field.Invalid(field.NewPath("apiVersion"), config.APIVersion, fmt.Sprintf("cannot upconvert from version %s", config.APIVersion))
+	if err := Convert(config, types.InstallConfigVersion); err != nil {
+		return err
 	}
-	//convertNetworking(config)
+	convertNetworking(config)
 
 	switch config.Platform.Name() {
 	/*
@@ -54,12 +45,10 @@ func ConvertInstallConfig(config *types.InstallConfig) error {
 				}*/
 	}
 
-	config.APIVersion = types.InstallConfigVersion
 	return nil
 }
 
 // convertNetworking upconverts deprecated fields in networking
-/*
 func convertNetworking(config *types.InstallConfig) {
 	if config.Networking == nil {
 		return
@@ -86,12 +75,6 @@ func convertNetworking(config *types.InstallConfig) {
 		netconf.NetworkType = netconf.DeprecatedType
 	}
 
-	// Recognize the OpenShiftSDN network plugin name regardless of capitalization, for
-	// backward compatibility
-	if strings.ToLower(netconf.NetworkType) == strings.ToLower(string(operv1.NetworkTypeOpenShiftSDN)) {
-		netconf.NetworkType = string(operv1.NetworkTypeOpenShiftSDN)
-	}
-
 	// Convert hostSubnetLength to hostPrefix
 	for i, entry := range netconf.ClusterNetwork {
 		if entry.HostPrefix == 0 && entry.DeprecatedHostSubnetLength != 0 {
@@ -101,6 +84,7 @@ func convertNetworking(config *types.InstallConfig) {
 	}
 }
 
+/*
 // convertBaremetal upconverts deprecated fields in the baremetal platform.
 // ProvisioningDHCPExternal has been replaced by setting the ProvisioningNetwork
 // field to "Unmanaged", ProvisioningHostIP has been replaced by
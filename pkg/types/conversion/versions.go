@@ -0,0 +1,121 @@
+package conversion
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+// Func converts an install config in place from one API version to another.
+// It must not set the APIVersion, which is updated by Convert.
+type Func func(config *types.InstallConfig) error
+
+type versionPair struct {
+	from, to string
+}
+
+var conversions = map[versionPair]Func{}
+
+func init() {
+	// v1beta3 and v1beta4 only differ from v1 in fields that were removed
+	// from this installer.
+	Register("v1beta3", "v1", noop)
+	Register("v1beta4", "v1", noop)
+	Register("v1", "v2", convertV1ToV2)
+	Register("v2", "v1", convertV2ToV1)
+}
+
+// Register registers the function that converts install configs from the
+// `from` API version to the `to` API version. It panics if a conversion is
+// already registered for the pair.
+func Register(from, to string, f Func) {
+	key := versionPair{from: from, to: to}
+	if _, ok := conversions[key]; ok {
+		panic(fmt.Sprintf("conversion from %s to %s is already registered", from, to))
+	}
+	conversions[key] = f
+}
+
+// Convert converts the install config to the given API version, chaining the
+// registered conversions along the shortest path between the versions.
+func Convert(config *types.InstallConfig, to string) error {
+	fldPath := field.NewPath("apiVersion")
+	if config.APIVersion == "" {
+		return field.Required(fldPath, "no version was provided")
+	}
+	if config.APIVersion == to {
+		return nil
+	}
+
+	path := conversionPath(config.APIVersion, to)
+	if path == nil {
+		return field.Invalid(fldPath, config.APIVersion, fmt.Sprintf("cannot convert from version %s to %s", config.APIVersion, to))
+	}
+	for _, step := range path {
+		logrus.Debugf("Converting install config from %s to %s", step.from, step.to)
+		if err := conversions[step](config); err != nil {
+			return err
+		}
+		config.APIVersion = step.to
+	}
+	return nil
+}
+
+// conversionPath returns the registered conversions leading from one version
+// to the other, or nil if there are none.
+func conversionPath(from, to string) []versionPair {
+	previous := map[string]versionPair{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			var path []versionPair
+			for v := to; v != from; v = previous[v].from {
+				path = append([]versionPair{previous[v]}, path...)
+			}
+			return path
+		}
+		for pair := range conversions {
+			if pair.from == current && !visited[pair.to] {
+				visited[pair.to] = true
+				previous[pair.to] = pair
+				queue = append(queue, pair.to)
+			}
+		}
+	}
+	return nil
+}
+
+func noop(*types.InstallConfig) error {
+	return nil
+}
+
+// convertV1ToV2 moves the fields that were renamed in v2 to their new names.
+func convertV1ToV2(config *types.InstallConfig) error {
+	if len(config.DeprecatedImageContentSources) > 0 {
+		if len(config.ImageMirrors) > 0 {
+			return field.Forbidden(field.NewPath("imageContentSources"), "cannot specify imageContentSources and imageMirrors together")
+		}
+		logrus.Warnf("imageContentSources is deprecated; use imageMirrors instead")
+		for _, source := range config.DeprecatedImageContentSources {
+			config.ImageMirrors = append(config.ImageMirrors, types.ImageMirror(source))
+		}
+		config.DeprecatedImageContentSources = nil
+	}
+	return nil
+}
+
+// convertV2ToV1 moves the fields that were renamed in v2 back to their v1
+// names.
+func convertV2ToV1(config *types.InstallConfig) error {
+	for _, mirror := range config.ImageMirrors {
+		config.DeprecatedImageContentSources = append(config.DeprecatedImageContentSources, types.ImageContentSource(mirror))
+	}
+	config.ImageMirrors = nil
+	return nil
+}
@@ -0,0 +1,113 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+func TestConvert(t *testing.T) {
+	mirrors := []types.ImageMirror{{
+		Source:  "quay.io/hashicorp",
+		Mirrors: []string{"registry.example.com/hashicorp"},
+	}}
+	contentSources := []types.ImageContentSource{{
+		Source:  "quay.io/hashicorp",
+		Mirrors: []string{"registry.example.com/hashicorp"},
+	}}
+
+	cases := []struct {
+		name          string
+		config        *types.InstallConfig
+		to            string
+		expected      *types.InstallConfig
+		expectedError string
+	}{
+		{
+			name: "v1 image content sources become image mirrors",
+			config: &types.InstallConfig{
+				TypeMeta:                      metav1.TypeMeta{APIVersion: "v1"},
+				DeprecatedImageContentSources: contentSources,
+			},
+			to: "v2",
+			expected: &types.InstallConfig{
+				TypeMeta:     metav1.TypeMeta{APIVersion: "v2"},
+				ImageMirrors: mirrors,
+			},
+		},
+		{
+			name: "v2 image mirrors become image content sources",
+			config: &types.InstallConfig{
+				TypeMeta:     metav1.TypeMeta{APIVersion: "v2"},
+				ImageMirrors: mirrors,
+			},
+			to: "v1",
+			expected: &types.InstallConfig{
+				TypeMeta:                      metav1.TypeMeta{APIVersion: "v1"},
+				DeprecatedImageContentSources: contentSources,
+			},
+		},
+		{
+			name: "conversions are chained",
+			config: &types.InstallConfig{
+				TypeMeta:                      metav1.TypeMeta{APIVersion: "v1beta4"},
+				DeprecatedImageContentSources: contentSources,
+			},
+			to: "v2",
+			expected: &types.InstallConfig{
+				TypeMeta:     metav1.TypeMeta{APIVersion: "v2"},
+				ImageMirrors: mirrors,
+			},
+		},
+		{
+			name: "same version is unchanged",
+			config: &types.InstallConfig{
+				TypeMeta:     metav1.TypeMeta{APIVersion: "v2"},
+				ImageMirrors: mirrors,
+			},
+			to: "v2",
+			expected: &types.InstallConfig{
+				TypeMeta:     metav1.TypeMeta{APIVersion: "v2"},
+				ImageMirrors: mirrors,
+			},
+		},
+		{
+			name: "both deprecated and new fields",
+			config: &types.InstallConfig{
+				TypeMeta:                      metav1.TypeMeta{APIVersion: "v1"},
+				DeprecatedImageContentSources: contentSources,
+				ImageMirrors:                  mirrors,
+			},
+			to:            "v2",
+			expectedError: `^imageContentSources: Forbidden: cannot specify imageContentSources and imageMirrors together$`,
+		},
+		{
+			name:          "missing version",
+			config:        &types.InstallConfig{},
+			to:            "v2",
+			expectedError: `^apiVersion: Required value: no version was provided$`,
+		},
+		{
+			name: "unknown version",
+			config: &types.InstallConfig{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v0"},
+			},
+			to:            "v2",
+			expectedError: `^apiVersion: Invalid value: "v0": cannot convert from version v0 to v2$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Convert(tc.config, tc.to)
+			if tc.expectedError != "" {
+				assert.Regexp(t, tc.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, tc.config)
+		})
+	}
+}
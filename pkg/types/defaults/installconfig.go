@@ -7,66 +7,72 @@ import (
 )
 
 var (
-	defaultMachineCIDR    = ipnet.MustParseCIDR("10.0.0.0/16")
+	// DefaultMachineCIDR is the default IP address pool for machines.
+	DefaultMachineCIDR = ipnet.MustParseCIDR("10.0.0.0/16")
+
 	defaultServiceNetwork = ipnet.MustParseCIDR("172.30.0.0/16")
 	defaultClusterNetwork = ipnet.MustParseCIDR("10.128.0.0/14")
 	defaultHostPrefix     = 23
 	//defaultNetworkType    = string(operv1.NetworkTypeOVNKubernetes)
+
+	// defaultComputeReplicaCount is zero because Terraform Enterprise runs
+	// entirely on the control plane unless compute machines are requested.
+	defaultComputeReplicaCount = int64(0)
+
+	defaultTFEDiskPath            = "/opt/tfe/data"
+	defaultTFECapacityConcurrency = 10
+	defaultTFEAdminUsername       = "admin"
+	defaultPasswordLength         = 23
 )
 
 // SetInstallConfigDefaults sets the defaults for the install config.
 func SetInstallConfigDefaults(c *types.InstallConfig) {
-	/*
-		if c.Networking == nil {
-			c.Networking = &types.Networking{}
-		}
-		if len(c.Networking.MachineNetwork) == 0 {
-			c.Networking.MachineNetwork = []types.MachineNetworkEntry{
-				{CIDR: *defaultMachineCIDR},
-			}
-			if c.Platform.Libvirt != nil {
-				c.Networking.MachineNetwork = []types.MachineNetworkEntry{
-					{CIDR: *libvirtdefaults.DefaultMachineCIDR},
-				}
-			}
-			if c.Platform.PowerVS != nil {
-				c.Networking.MachineNetwork = []types.MachineNetworkEntry{
-					{CIDR: *powervsdefaults.DefaultMachineCIDR},
-				}
-			}
-		}
-		if c.Networking.NetworkType == "" {
-			c.Networking.NetworkType = defaultNetworkType
-		}
-		if len(c.Networking.ServiceNetwork) == 0 {
-			c.Networking.ServiceNetwork = []ipnet.IPNet{*defaultServiceNetwork}
+	if c.Networking == nil {
+		c.Networking = &types.Networking{}
+	}
+	if len(c.Networking.MachineNetwork) == 0 {
+		c.Networking.MachineNetwork = []types.MachineNetworkEntry{
+			{CIDR: *DefaultMachineCIDR},
 		}
-		if len(c.Networking.ClusterNetwork) == 0 {
-			c.Networking.ClusterNetwork = []types.ClusterNetworkEntry{
-				{
-					CIDR:       *defaultClusterNetwork,
-					HostPrefix: int32(defaultHostPrefix),
-				},
-			}
+	}
+	if len(c.Networking.ServiceNetwork) == 0 {
+		c.Networking.ServiceNetwork = []ipnet.IPNet{*defaultServiceNetwork}
+	}
+	if len(c.Networking.ClusterNetwork) == 0 {
+		c.Networking.ClusterNetwork = []types.ClusterNetworkEntry{
+			{
+				CIDR:       *defaultClusterNetwork,
+				HostPrefix: int32(defaultHostPrefix),
+			},
 		}
-	*/
+	}
 
 	if c.Publish == "" {
 		c.Publish = types.ExternalPublishingStrategy
 	}
 
-	/*if c.ControlPlane == nil {
+	if c.PurgePolicy == "" {
+		c.PurgePolicy = types.DeletePurgePolicy
+	}
+
+	if c.ControlPlane == nil {
 		c.ControlPlane = &types.MachinePool{}
 	}
-	c.ControlPlane.Name = "master"
+	c.ControlPlane.Name = types.MachinePoolControlPlaneRoleName
 	SetMachinePoolDefaults(c.ControlPlane, c.Platform.Name())
 	if len(c.Compute) == 0 {
-		c.Compute = []types.MachinePool{{Name: "worker"}}
+		replicas := defaultComputeReplicaCount
+		c.Compute = []types.MachinePool{{
+			Name:     types.MachinePoolComputeRoleName,
+			Replicas: &replicas,
+		}}
 	}
 	for i := range c.Compute {
 		SetMachinePoolDefaults(&c.Compute[i], c.Platform.Name())
 	}
-	*/
+	if c.CredentialsMode == "" && c.Platform.AWS != nil {
+		c.CredentialsMode = types.MintCredentialsMode
+	}
 	/*if c.CredentialsMode == "" {
 		if c.Platform.Azure != nil && c.Platform.Azure.CloudName == azure.StackCloud {
 			c.CredentialsMode = types.ManualCredentialsMode
@@ -109,8 +115,54 @@ func SetInstallConfigDefaults(c *types.InstallConfig) {
 				nutanixdefaults.SetPlatformDefaults(c.Platform.Nutanix)
 		*/
 	}
-	/*
-		if c.AdditionalTrustBundlePolicy == "" {
-			c.AdditionalTrustBundlePolicy = types.PolicyProxyOnly
-		}*/
+
+	if c.AdditionalTrustBundlePolicy == "" {
+		c.AdditionalTrustBundlePolicy = types.PolicyProxyOnly
+	}
+
+	if c.TFE == nil {
+		c.TFE = &types.TFE{}
+	}
+	setTFEDefaults(c.TFE, c.ClusterDomain())
+	if es := c.TFE.ExternalServices; es != nil && es.ObjectStorage.Region == "" && c.Platform.AWS != nil {
+		es.ObjectStorage.Region = c.Platform.AWS.Region
+	}
+}
+
+// setTFEDefaults sets the defaults for the Terraform Enterprise application.
+func setTFEDefaults(t *types.TFE, clusterDomain string) {
+	if t.Hostname == "" {
+		t.Hostname = "tfe." + clusterDomain
+	}
+	if t.OperationalMode == "" {
+		t.OperationalMode = types.DemoOperationalMode
+	}
+	if t.DiskPath == "" && t.OperationalMode == types.MountedDiskOperationalMode {
+		t.DiskPath = defaultTFEDiskPath
+	}
+	if t.ExternalServices != nil && t.ExternalServices.Postgres.SSLMode == "" {
+		t.ExternalServices.Postgres.SSLMode = types.PostgresSSLModeRequire
+	}
+	if t.CapacityConcurrency == 0 {
+		t.CapacityConcurrency = defaultTFECapacityConcurrency
+	}
+	if t.TLSSource == "" {
+		t.TLSSource = types.GeneratedTLSSource
+	}
+	if t.AdminUsername == "" {
+		t.AdminUsername = defaultTFEAdminUsername
+	}
+	if t.AdminPasswordPolicy == nil {
+		t.AdminPasswordPolicy = &types.PasswordPolicy{}
+	}
+	if t.AdminPasswordPolicy.Length == 0 {
+		t.AdminPasswordPolicy.Length = defaultPasswordLength
+	}
+	if len(t.AdminPasswordPolicy.CharacterClasses) == 0 {
+		t.AdminPasswordPolicy.CharacterClasses = []types.CharacterClass{
+			types.LowercaseCharacterClass,
+			types.UppercaseCharacterClass,
+			types.DigitsCharacterClass,
+		}
+	}
 }
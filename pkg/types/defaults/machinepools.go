@@ -0,0 +1,17 @@
+package defaults
+
+import (
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/version"
+)
+
+// SetMachinePoolDefaults sets the defaults for the machine pool.
+func SetMachinePoolDefaults(p *types.MachinePool, platform string) {
+	defaultReplicaCount := int64(1)
+	if p.Replicas == nil {
+		p.Replicas = &defaultReplicaCount
+	}
+	if p.Architecture == "" {
+		p.Architecture = version.DefaultArch()
+	}
+}
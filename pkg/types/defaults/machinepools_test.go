@@ -0,0 +1,100 @@
+package defaults
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+	"github.com/bailey84j/terraform_installer/pkg/version"
+)
+
+func TestSetMachinePoolDefaults(t *testing.T) {
+	defaultReplicas := int64(1)
+	replicas := int64(3)
+	cases := []struct {
+		name     string
+		pool     *types.MachinePool
+		expected *types.MachinePool
+	}{
+		{
+			name: "empty",
+			pool: &types.MachinePool{},
+			expected: &types.MachinePool{
+				Replicas:     &defaultReplicas,
+				Architecture: version.DefaultArch(),
+			},
+		},
+		{
+			name: "configured",
+			pool: &types.MachinePool{
+				Replicas:     &replicas,
+				Architecture: types.ArchitectureARM64,
+			},
+			expected: &types.MachinePool{
+				Replicas:     &replicas,
+				Architecture: types.ArchitectureARM64,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetMachinePoolDefaults(tc.pool, aws.Name)
+			assert.Equal(t, tc.expected, tc.pool)
+		})
+	}
+}
+
+func TestSetInstallConfigMachinePoolDefaults(t *testing.T) {
+	controlPlaneReplicas := int64(1)
+	computeReplicas := int64(0)
+	cases := []struct {
+		name                 string
+		installConfig        *types.InstallConfig
+		expectedControlPlane *types.MachinePool
+		expectedCompute      []types.MachinePool
+	}{
+		{
+			name:          "no pools",
+			installConfig: &types.InstallConfig{},
+			expectedControlPlane: &types.MachinePool{
+				Name:         types.MachinePoolControlPlaneRoleName,
+				Replicas:     &controlPlaneReplicas,
+				Architecture: version.DefaultArch(),
+			},
+			expectedCompute: []types.MachinePool{{
+				Name:         types.MachinePoolComputeRoleName,
+				Replicas:     &computeReplicas,
+				Architecture: version.DefaultArch(),
+			}},
+		},
+		{
+			name: "unnamed control plane",
+			installConfig: &types.InstallConfig{
+				ControlPlane: &types.MachinePool{Architecture: types.ArchitectureARM64},
+				Compute: []types.MachinePool{{
+					Name:         types.MachinePoolComputeRoleName,
+					Architecture: types.ArchitectureARM64,
+				}},
+			},
+			expectedControlPlane: &types.MachinePool{
+				Name:         types.MachinePoolControlPlaneRoleName,
+				Replicas:     &controlPlaneReplicas,
+				Architecture: types.ArchitectureARM64,
+			},
+			expectedCompute: []types.MachinePool{{
+				Name:         types.MachinePoolComputeRoleName,
+				Replicas:     &controlPlaneReplicas,
+				Architecture: types.ArchitectureARM64,
+			}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetInstallConfigDefaults(tc.installConfig)
+			assert.Equal(t, tc.expectedControlPlane, tc.installConfig.ControlPlane)
+			assert.Equal(t, tc.expectedCompute, tc.installConfig.Compute)
+		})
+	}
+}
@@ -1,5 +1,5 @@
 //+groupName="install.terraform.io"
-//+versionName="v1"
+//+versionName="v2"
 
 // Package types defines structures for installer configuration and
 // management.
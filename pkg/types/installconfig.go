@@ -12,9 +12,9 @@ import (
 
 const (
 	// InstallConfigVersion is the version supported by this package.
-	// If you bump this, you must also update the list of convertable values in
-	// pkg/types/conversion/installconfig.go
-	InstallConfigVersion = "v1"
+	// If you bump this, you must also register conversions to and from the
+	// previous version in pkg/types/conversion/versions.go
+	InstallConfigVersion = "v2"
 )
 
 var (
@@ -46,6 +46,13 @@ const (
 // +kubebuilder:validation:Enum="";Proxyonly;Always
 type PolicyType string
 
+const (
+	// PolicyProxyOnly enables use of AdditionalTrustBundle when http/https proxy is configured.
+	PolicyProxyOnly PolicyType = "Proxyonly"
+	// PolicyAlways ignores all conditions and uses AdditionalTrustBundle.
+	PolicyAlways PolicyType = "Always"
+)
+
 //go:generate go run ../../vendor/sigs.k8s.io/controller-tools/cmd/controller-gen crd:crdVersions=v1 paths=. output:dir=../../data/data/
 
 // InstallConfig is the configuration for an OpenShift install.
@@ -66,12 +73,69 @@ type InstallConfig struct {
 	// perform the installation.
 	Platform `json:"platform"`
 
-	// Licence is the secret to use building Terraform Enterprise.
-	Licence string `json:"licence"`
+	// ControlPlane is the configuration for the machines that comprise the
+	// control plane.
+	// +optional
+	ControlPlane *MachinePool `json:"controlPlane,omitempty"`
+
+	// Compute is the configuration for the machines that comprise the
+	// compute nodes. Overlays merge compute pools by name.
+	// +optional
+	Compute []MachinePool `json:"compute,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+
+	// Networking is the configuration for the pod network provider in
+	// the cluster.
+	// +optional
+	Networking *Networking `json:"networking,omitempty"`
+
+	// LicencePath is the path of the Terraform Enterprise licence file.
+	// Only the path is stored, so the licence is read from it whenever it
+	// is needed. Exactly one of LicencePath and Licence must be set.
+	// +optional
+	LicencePath string `json:"licencePath,omitempty"`
+
+	// Licence is a secret reference to the Terraform Enterprise licence,
+	// e.g. {fromFile: path}, {fromEnv: name} or {fromVault: {path, key}}.
+	// It is resolved whenever the licence is needed, and only the reference
+	// is stored. A licence given inline is rejected.
+	// +optional
+	Licence string `json:"licence,omitempty" sensitive:"true"`
+
+	// TFE is the configuration of the Terraform Enterprise application.
+	// +optional
+	TFE *TFE `json:"tfe,omitempty"`
+
+	// AdditionalTrustBundle is a PEM-encoded X.509 certificate bundle
+	// that will be added to the instances' trusted certificate store.
+	//
+	// +optional
+	AdditionalTrustBundle string `json:"additionalTrustBundle,omitempty"`
+
+	// AdditionalTrustBundlePolicy determines when to add the AdditionalTrustBundle
+	// to the instances' trusted certificate store. "Proxyonly" is the default.
+	// The field can be set to following specified values.
+	// "Proxyonly" : adds the AdditionalTrustBundle to instances when http/https proxy is configured.
+	// "Always" : always adds AdditionalTrustBundle.
+	//
+	// +optional
+	AdditionalTrustBundlePolicy PolicyType `json:"additionalTrustBundlePolicy,omitempty"`
+
+	// Proxy defines the proxy settings for the cluster.
+	// If unset, the cluster will not be configured to use a proxy.
+	// +optional
+	Proxy *Proxy `json:"proxy,omitempty"`
 
-	// ImageContentSources lists sources/repositories for the release-image content.
+	// ImageMirrors lists registries and the repositories that mirror them,
+	// for installs that cannot pull images from their original source.
+	// Overlays merge image mirrors by source.
 	// +optional
-	ImageContentSources []ImageContentSource `json:"imageContentSources,omitempty"`
+	ImageMirrors []ImageMirror `json:"imageMirrors,omitempty" patchStrategy:"merge" patchMergeKey:"source"`
+
+	// Airgap configures an install without internet access, in which the
+	// instances install Terraform Enterprise from packages in the given
+	// locations instead of downloading them.
+	// +optional
+	Airgap *Airgap `json:"airgap,omitempty"`
 
 	// Publish controls how the user facing endpoints of the cluster like the Kubernetes API, OpenShift routes etc. are exposed.
 	// When no strategy is specified, the strategy is "External".
@@ -80,22 +144,38 @@ type InstallConfig struct {
 	// +optional
 	Publish PublishingStrategy `json:"publish,omitempty"`
 
-	// CredentialsMode is used to explicitly set the mode with which CredentialRequests are satisfied.
-	//
-	// If this field is set, then the installer will not attempt to query the cloud permissions before attempting
-	// installation. If the field is not set or empty, then the installer will perform its normal verification that the
-	// credentials provided are sufficient to perform an installation.
+	// CredentialsMode is used to explicitly set how the instances obtain their cloud credentials.
 	//
 	// There are three possible values for this field, but the valid values are dependent upon the platform being used.
-	// "Mint": create new credentials with a subset of the overall permissions for each CredentialsRequest
-	// "Passthrough": copy the credentials with all of the overall permissions for each CredentialsRequest
-	// "Manual": CredentialsRequests must be handled manually by the user
+	// "Mint": create new credentials with a subset of the overall permissions for the instances
+	// "Passthrough": give the instances the credentials used to run the installer
+	// "Manual": the credentials for the instances are provided by the user
 	//
 	// For each of the following platforms, the field can set to the specified values. For all other platforms, the
 	// field must not be set.
-	// AWS: "Mint", "Passthrough", "Manual"
+	// AWS: "Mint", "Passthrough", "Manual". Defaults to "Mint". Passthrough requires static credentials, and Manual
+	// requires platform.aws.componentRoles.
 	// Azure: "Passthrough", "Manual"
 	CredentialsMode CredentialsMode `json:"credentialsMode,omitempty"`
+
+	// PurgePolicy controls what happens to input files in the install directory, like this
+	// install-config.yaml, once they have been consumed by a later step.
+	// When no policy is specified, the policy is "Delete".
+	//
+	// +kubebuilder:default=Delete
+	// +optional
+	PurgePolicy PurgePolicy `json:"purgePolicy,omitempty"`
+
+	// Lint configures the lint findings reported about the install config.
+	// +optional
+	Lint *Lint `json:"lint,omitempty"`
+
+	// Deprecated types, scheduled to be removed
+
+	// Deprecated way to configure image mirrors, from v1 install configs.
+	// Replaced by ImageMirrors.
+	// +optional
+	DeprecatedImageContentSources []ImageContentSource `json:"imageContentSources,omitempty"`
 }
 
 // ClusterDomain returns the DNS domain that all records for a cluster must belong to.
@@ -103,6 +183,17 @@ func (c *InstallConfig) ClusterDomain() string {
 	return fmt.Sprintf("%s.%s", c.ObjectMeta.Name, strings.TrimSuffix(c.BaseDomain, "."))
 }
 
+// WorkerMachinePool retrieves the worker MachinePool from InstallConfig.Compute
+func (c *InstallConfig) WorkerMachinePool() *MachinePool {
+	for i, m := range c.Compute {
+		if m.Name == MachinePoolComputeRoleName {
+			return &c.Compute[i]
+		}
+	}
+
+	return nil
+}
+
 // Platform is the configuration for the specific platform upon which to perform
 // the installation. Only one of the platform configuration should be set.
 type Platform struct {
@@ -292,7 +383,45 @@ type Proxy struct {
 	NoProxy string `json:"noProxy,omitempty"`
 }
 
+// ImageMirror defines a registry or repository and the repositories that
+// mirror its content.
+type ImageMirror struct {
+	// Source is the repository that users refer to, e.g. in image pull specifications.
+	Source string `json:"source"`
+
+	// Mirrors is one or more repositories that may also contain the same images.
+	// +optional
+	Mirrors []string `json:"mirrors,omitempty"`
+}
+
+// Lint configures the lint findings reported about the install config.
+type Lint struct {
+	// Suppress are the IDs of the lint rules whose findings are not reported.
+	// Findings of suppressed rules with the error severity no longer block
+	// the install.
+	// +optional
+	Suppress []string `json:"suppress,omitempty"`
+}
+
+// Airgap is the configuration of an install without internet access.
+type Airgap struct {
+	// PackageURL is the location of the Terraform Enterprise airgap package
+	// that the instances install from, as an s3:// or https:// URL.
+	PackageURL string `json:"packageURL"`
+
+	// BootstrapperURL is the location of the installer bootstrapper
+	// archive that the instances install from, as an s3:// or https:// URL.
+	BootstrapperURL string `json:"bootstrapperURL"`
+
+	// Artifacts are local files, like the airgap package and the
+	// bootstrapper archive, that are added to the mirror bundle so that
+	// they can be carried into the disconnected environment.
+	// +optional
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
 // ImageContentSource defines a list of sources/repositories that can be used to pull content.
+// It is the v1 form of ImageMirror.
 type ImageContentSource struct {
 	// Source is the repository that users refer to, e.g. in image pull specifications.
 	Source string `json:"source"`
@@ -307,18 +436,33 @@ type ImageContentSource struct {
 type CredentialsMode string
 
 const (
-	// ManualCredentialsMode indicates that cloud-credential-operator should not process any CredentialsRequests.
+	// ManualCredentialsMode indicates that the user provides the credentials of the instances.
 	ManualCredentialsMode CredentialsMode = "Manual"
 
-	// MintCredentialsMode indicates that cloud-credential-operator should be creating users for each
-	// CredentialsRequest.
+	// MintCredentialsMode indicates that the installer should create credentials for the instances
+	// with only the permissions they need.
 	MintCredentialsMode CredentialsMode = "Mint"
 
-	// PassthroughCredentialsMode indicates that cloud-credential-operator should just copy over the cluster's
-	// cloud credentials for each CredentialsRequest.
+	// PassthroughCredentialsMode indicates that the instances should use the credentials the installer
+	// was run with.
 	PassthroughCredentialsMode CredentialsMode = "Passthrough"
 )
 
+// PurgePolicy is what happens to input files in the install directory once they have been consumed.
+// +kubebuilder:validation:Enum="";Delete;Archive;Keep
+type PurgePolicy string
+
+const (
+	// DeletePurgePolicy removes consumed files from the install directory.
+	DeletePurgePolicy PurgePolicy = "Delete"
+
+	// ArchivePurgePolicy moves consumed files into a timestamped directory under .consumed/.
+	ArchivePurgePolicy PurgePolicy = "Archive"
+
+	// KeepPurgePolicy leaves consumed files in the install directory.
+	KeepPurgePolicy PurgePolicy = "Keep"
+)
+
 // BootstrapInPlace defines the configuration for bootstrap-in-place installation
 type BootstrapInPlace struct {
 	// InstallationDisk is the target disk drive for coreos-installer
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+const (
+	// MachinePoolControlPlaneRoleName is the name of the control plane pool.
+	MachinePoolControlPlaneRoleName = "master"
+
+	// MachinePoolComputeRoleName is the name of the compute pool.
+	MachinePoolComputeRoleName = "worker"
+)
+
+// MachinePool is a pool of machines to be installed.
+type MachinePool struct {
+	// Name is the name of the machine pool.
+	// For the control plane machine pool, the name will always be "master".
+	// For the compute machine pools, the only valid name is "worker".
+	Name string `json:"name"`
+
+	// Replicas is the machine count for the machine pool.
+	// +optional
+	Replicas *int64 `json:"replicas,omitempty"`
+
+	// Platform is configuration for machine pool specific to the platform.
+	Platform MachinePoolPlatform `json:"platform"`
+
+	// Architecture is the CPU architecture of the machines in the pool.
+	// The default is amd64.
+	//
+	// +kubebuilder:default=amd64
+	// +optional
+	Architecture Architecture `json:"architecture,omitempty"`
+}
+
+// MachinePoolPlatform is the platform-specific configuration for a machine
+// pool. Only one of the platforms should be set.
+type MachinePoolPlatform struct {
+	// AWS is the configuration used when installing on AWS.
+	// +optional
+	AWS *aws.MachinePool `json:"aws,omitempty"`
+}
+
+// Name returns a string representation of the platform (e.g. "aws" if
+// AWS is non-nil).  It returns an empty string if no platform is
+// configured.
+func (p *MachinePoolPlatform) Name() string {
+	switch {
+	case p == nil:
+		return ""
+	case p.AWS != nil:
+		return aws.Name
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,50 @@
+package overrides
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+// Overlay is a partial install config that is merged over a base install
+// config.
+type Overlay struct {
+	// Filename is the name of the overlay file, used in error messages.
+	Filename string
+
+	// Data is the YAML or JSON content of the overlay.
+	Data []byte
+}
+
+// Merge merges the overlays, in order, over the YAML or JSON install config
+// in base and returns the result as JSON. The overlays are applied with
+// strategic-merge semantics: objects are merged field by field, a null value
+// removes the field, and lists replace the list of the base unless the field
+// of types.InstallConfig declares the merge patchStrategy, in which case the
+// elements are merged by its patchMergeKey, or added to the base list if the
+// elements are not objects.
+func Merge(base []byte, overlays []Overlay) ([]byte, error) {
+	merged, err := yaml.YAMLToJSON(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the base install config")
+	}
+	if string(merged) == "null" {
+		merged = []byte("{}")
+	}
+
+	for _, overlay := range overlays {
+		patch, err := yaml.YAMLToJSON(overlay.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse overlay %s", overlay.Filename)
+		}
+		if string(patch) == "null" {
+			continue
+		}
+		if merged, err = strategicpatch.StrategicMergePatch(merged, patch, types.InstallConfig{}); err != nil {
+			return nil, errors.Wrapf(err, "failed to merge overlay %s", overlay.Filename)
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,110 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	const base = `
+apiVersion: v2
+platform:
+  aws:
+    region: us-east-1
+    subnets: [subnet-a, subnet-b]
+    userTags:
+      owner: platform
+compute:
+- name: worker
+  replicas: 3
+  platform:
+    aws:
+      type: m5.large
+- name: batch
+  replicas: 1
+imageMirrors:
+- source: registry.example.com/tfe
+  mirrors: [mirror-a.example.com/tfe]
+tfe:
+  organizations: [platform]
+`
+
+	cases := []struct {
+		name          string
+		overlays      []Overlay
+		expected      string
+		expectedError string
+	}{
+		{
+			name: "maps merge",
+			overlays: []Overlay{{Filename: "prod.yaml", Data: []byte(`
+platform:
+  aws:
+    region: us-west-2
+    userTags:
+      env: prod
+`)}},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3,"platform":{"aws":{"type":"m5.large"}}},{"name":"batch","replicas":1}],"imageMirrors":[{"source":"registry.example.com/tfe","mirrors":["mirror-a.example.com/tfe"]}],"tfe":{"organizations":["platform"]},"platform":{"aws":{"region":"us-west-2","subnets":["subnet-a","subnet-b"],"userTags":{"env":"prod","owner":"platform"}}}}`,
+		},
+		{
+			name:     "unkeyed lists replace",
+			overlays: []Overlay{{Filename: "dev.yaml", Data: []byte("platform: {aws: {subnets: [subnet-c]}}")}},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3,"platform":{"aws":{"type":"m5.large"}}},{"name":"batch","replicas":1}],"imageMirrors":[{"source":"registry.example.com/tfe","mirrors":["mirror-a.example.com/tfe"]}],"tfe":{"organizations":["platform"]},"platform":{"aws":{"region":"us-east-1","subnets":["subnet-c"],"userTags":{"owner":"platform"}}}}`,
+		},
+		{
+			name:     "keyed lists merge",
+			overlays: []Overlay{{Filename: "prod.yaml", Data: []byte("compute: [{name: worker, platform: {aws: {type: m5.2xlarge}}}]")}},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3,"platform":{"aws":{"type":"m5.2xlarge"}}},{"name":"batch","replicas":1}],"imageMirrors":[{"source":"registry.example.com/tfe","mirrors":["mirror-a.example.com/tfe"]}],"tfe":{"organizations":["platform"]},"platform":{"aws":{"region":"us-east-1","subnets":["subnet-a","subnet-b"],"userTags":{"owner":"platform"}}}}`,
+		},
+		{
+			name: "two compute pools merge",
+			overlays: []Overlay{{Filename: "prod.yaml", Data: []byte(`
+compute:
+- name: batch
+  replicas: 2
+- name: worker
+  replicas: 5
+`)}},
+			expected: `{"apiVersion":"v2","compute":[{"name":"batch","replicas":2},{"name":"worker","replicas":5,"platform":{"aws":{"type":"m5.large"}}}],"imageMirrors":[{"source":"registry.example.com/tfe","mirrors":["mirror-a.example.com/tfe"]}],"tfe":{"organizations":["platform"]},"platform":{"aws":{"region":"us-east-1","subnets":["subnet-a","subnet-b"],"userTags":{"owner":"platform"}}}}`,
+		},
+		{
+			name: "image mirrors merge by source and organizations are added",
+			overlays: []Overlay{{Filename: "airgap.yaml", Data: []byte(`
+imageMirrors:
+- source: registry.example.com/tfe
+  mirrors: [mirror-b.example.com/tfe]
+- source: registry.example.com/agent
+  mirrors: [mirror-b.example.com/agent]
+tfe:
+  organizations: [security]
+`)}},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3,"platform":{"aws":{"type":"m5.large"}}},{"name":"batch","replicas":1}],"imageMirrors":[{"source":"registry.example.com/tfe","mirrors":["mirror-b.example.com/tfe"]},{"source":"registry.example.com/agent","mirrors":["mirror-b.example.com/agent"]}],"tfe":{"organizations":["security","platform"]},"platform":{"aws":{"region":"us-east-1","subnets":["subnet-a","subnet-b"],"userTags":{"owner":"platform"}}}}`,
+		},
+		{
+			name: "overlays apply in order and null removes",
+			overlays: []Overlay{
+				{Filename: "10-staging.yaml", Data: []byte("platform: {aws: {region: eu-west-1, userTags: {owner: null}}}")},
+				{Filename: "20-empty.yaml"},
+				{Filename: "30-region.yaml", Data: []byte("platform: {aws: {region: eu-central-1}}")},
+			},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3,"platform":{"aws":{"type":"m5.large"}}},{"name":"batch","replicas":1}],"imageMirrors":[{"source":"registry.example.com/tfe","mirrors":["mirror-a.example.com/tfe"]}],"tfe":{"organizations":["platform"]},"platform":{"aws":{"region":"eu-central-1","subnets":["subnet-a","subnet-b"],"userTags":{}}}}`,
+		},
+		{
+			name:          "invalid overlay",
+			overlays:      []Overlay{{Filename: "bad.yaml", Data: []byte("platform: [")}},
+			expectedError: `^failed to parse overlay bad\.yaml: `,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Merge([]byte(base), tc.overlays)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.JSONEq(t, tc.expected, string(data))
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package overrides layers overlay files, command-line flags and environment
+// variables over an install config.
+package overrides
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+// EnvPrefix is the prefix of the environment variables that override fields
+// of the install config, e.g. TFE_INSTALL_PLATFORM_AWS_REGION.
+const EnvPrefix = "TFE_INSTALL_"
+
+// Override sets the field of the install config at Path to Value.
+type Override struct {
+	// Source is where the override came from, used in messages. It never
+	// includes the value, which may be a secret.
+	Source string
+
+	// Path is the JSON path of the field, one element per segment. Segments
+	// are matched case-insensitively against the JSON field names.
+	Path []string
+
+	// Value is the unparsed value. It is used verbatim for string fields
+	// and parsed as YAML for every other field.
+	Value string
+}
+
+// Parse parses a "path=value" override given with --set, where path is the
+// dot-separated JSON path of the field, e.g. "platform.aws.region=us-west-2".
+func Parse(s string) (Override, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return Override{}, errors.Errorf("invalid override %q: must be of the form path=value", s)
+	}
+	path := strings.Split(parts[0], ".")
+	for _, segment := range path {
+		if segment == "" {
+			return Override{}, errors.Errorf("invalid override --set %s: empty path segment", parts[0])
+		}
+	}
+	return Override{Source: "--set " + parts[0], Path: path, Value: parts[1]}, nil
+}
+
+// FromEnv returns the overrides set by the EnvPrefix variables in environ,
+// which is in the form returned by os.Environ. Path segments are separated by
+// underscores, e.g. TFE_INSTALL_PLATFORM_AWS_REGION=us-west-2.
+func FromEnv(environ []string) ([]Override, error) {
+	var overrides []Override
+	for _, env := range environ {
+		if !strings.HasPrefix(env, EnvPrefix) {
+			continue
+		}
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		path := strings.Split(strings.TrimPrefix(parts[0], EnvPrefix), "_")
+		for _, segment := range path {
+			if segment == "" {
+				return nil, errors.Errorf("invalid environment variable %s: empty path segment", parts[0])
+			}
+		}
+		overrides = append(overrides, Override{Source: parts[0], Path: path, Value: parts[1]})
+	}
+	return overrides, nil
+}
+
+// Apply applies the overrides, in order, to the YAML or JSON install config in
+// data and returns the result as JSON. Every override is checked against the
+// fields of types.InstallConfig before it is applied.
+func Apply(data []byte, overrides []Override) ([]byte, error) {
+	var config interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal install config")
+	}
+
+	for _, o := range overrides {
+		path, t, err := resolve(reflect.TypeOf(types.InstallConfig{}), o.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid override %s", o.Source)
+		}
+		value, err := typedValue(t, o.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid override %s", o.Source)
+		}
+		if config, err = set(config, path, value); err != nil {
+			return nil, errors.Wrapf(err, "invalid override %s", o.Source)
+		}
+	}
+
+	return json.Marshal(config)
+}
+
+// resolve walks path through t and returns the path with struct fields
+// replaced by their JSON names, and the type of the field it addresses.
+func resolve(t reflect.Type, path []string) ([]string, reflect.Type, error) {
+	resolved := make([]string, 0, len(path))
+	for i, segment := range path {
+		t = indirect(t)
+		switch t.Kind() {
+		case reflect.Struct:
+			name, ft, ok := field(t, segment)
+			if !ok {
+				return nil, nil, errors.Errorf("%s has no field %q", describe(resolved), segment)
+			}
+			resolved = append(resolved, name)
+			t = ft
+		case reflect.Map:
+			resolved = append(resolved, segment)
+			t = t.Elem()
+		case reflect.Slice:
+			if _, err := strconv.Atoi(segment); err != nil {
+				return nil, nil, errors.Errorf("%s is a list and must be indexed by a number, not %q", describe(resolved), segment)
+			}
+			resolved = append(resolved, segment)
+			t = t.Elem()
+		default:
+			return nil, nil, errors.Errorf("%s is not an object and has no field %q", describe(resolved), strings.Join(path[i:], "."))
+		}
+	}
+	return resolved, indirect(t), nil
+}
+
+// field returns the JSON name and type of the field of the struct t whose
+// JSON name matches name case-insensitively. Fields of embedded and inlined
+// structs are searched too.
+func field(t reflect.Type, name string) (string, reflect.Type, bool) {
+	jsonName, f, ok := structField(t, name)
+	return jsonName, f.Type, ok
+}
+
+// structField is like field, but returns the struct field itself.
+func structField(t reflect.Type, name string) (string, reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		inline := false
+		for _, option := range tag[1:] {
+			inline = inline || option == "inline"
+		}
+		if tag[0] == "" && (f.Anonymous || inline) {
+			if ft := indirect(f.Type); ft.Kind() == reflect.Struct {
+				if n, sf, ok := structField(ft, name); ok {
+					return n, sf, true
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		jsonName := tag[0]
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+		if strings.EqualFold(jsonName, name) {
+			return jsonName, f, true
+		}
+	}
+	return "", reflect.StructField{}, false
+}
+
+// typedValue parses raw as a value of type t and returns it in the form of
+// a generic JSON value.
+func typedValue(t reflect.Type, raw string) (interface{}, error) {
+	v := reflect.New(t)
+	if t.Kind() == reflect.String {
+		v.Elem().SetString(raw)
+	} else if err := yaml.UnmarshalStrict([]byte(raw), v.Interface(), yaml.DisallowUnknownFields); err != nil {
+		return nil, errors.Wrapf(err, "%q is not a valid %s", raw, t)
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// set sets the element of node at path to value, creating missing objects
+// along the way, and returns the updated node.
+func set(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	if list, ok := node.([]interface{}); ok {
+		index, err := strconv.Atoi(path[0])
+		if err != nil {
+			return nil, errors.Errorf("%q is not a list index", path[0])
+		}
+		if index < 0 || index >= len(list) {
+			return nil, errors.Errorf("index %d is out of range for a list of %d elements", index, len(list))
+		}
+		if list[index], err = set(list[index], path[1:], value); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		if node != nil {
+			return nil, errors.Errorf("cannot set %q on a value of type %T", path[0], node)
+		}
+		if _, err := strconv.Atoi(path[0]); err == nil {
+			return nil, errors.Errorf("index %s is out of range for an empty list", path[0])
+		}
+		object = map[string]interface{}{}
+	}
+	child, err := set(object[path[0]], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	object[path[0]] = child
+	return object, nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func describe(path []string) string {
+	if len(path) == 0 {
+		return "the install config"
+	}
+	return strings.Join(path, ".")
+}
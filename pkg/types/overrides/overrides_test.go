@@ -0,0 +1,136 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	const base = `
+apiVersion: v2
+metadata:
+  name: test
+platform:
+  aws:
+    region: us-east-1
+compute:
+- name: worker
+  replicas: 3
+licence: abc
+`
+
+	cases := []struct {
+		name          string
+		set           []string
+		env           []string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "string field",
+			set:      []string{"platform.aws.region=us-west-2"},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3}],"licence":"abc","metadata":{"name":"test"},"platform":{"aws":{"region":"us-west-2"}}}`,
+		},
+		{
+			name:     "environment variable",
+			env:      []string{"HOME=/root", "TFE_INSTALL_PLATFORM_AWS_REGION=eu-west-1"},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3}],"licence":"abc","metadata":{"name":"test"},"platform":{"aws":{"region":"eu-west-1"}}}`,
+		},
+		{
+			name:     "flag takes precedence over environment",
+			env:      []string{"TFE_INSTALL_PLATFORM_AWS_REGION=eu-west-1"},
+			set:      []string{"platform.aws.region=us-west-2"},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3}],"licence":"abc","metadata":{"name":"test"},"platform":{"aws":{"region":"us-west-2"}}}`,
+		},
+		{
+			name:     "list index and typed value",
+			set:      []string{"compute.0.replicas=5"},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":5}],"licence":"abc","metadata":{"name":"test"},"platform":{"aws":{"region":"us-east-1"}}}`,
+		},
+		{
+			name:     "missing objects are created",
+			set:      []string{"platform.aws.userTags.team=infra", "controlPlane.replicas=3"},
+			expected: `{"apiVersion":"v2","compute":[{"name":"worker","replicas":3}],"controlPlane":{"replicas":3},"licence":"abc","metadata":{"name":"test"},"platform":{"aws":{"region":"us-east-1","userTags":{"team":"infra"}}}}`,
+		},
+		{
+			name:          "unknown field",
+			set:           []string{"platform.aws.zone=a"},
+			expectedError: `invalid override --set platform.aws.zone: platform.aws has no field "zone"`,
+		},
+		{
+			name:          "wrong type",
+			env:           []string{"TFE_INSTALL_COMPUTE_0_REPLICAS=many"},
+			expectedError: `invalid override TFE_INSTALL_COMPUTE_0_REPLICAS: "many" is not a valid int64`,
+		},
+		{
+			name:          "list index out of range",
+			set:           []string{"compute.1.replicas=1"},
+			expectedError: `invalid override --set compute.1.replicas: index 1 is out of range for a list of 1 elements`,
+		},
+		{
+			name:          "field of a string",
+			set:           []string{"licence.key=abc"},
+			expectedError: `invalid override --set licence.key: licence is not an object and has no field "key"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			overrides, err := FromEnv(tc.env)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, s := range tc.set {
+				o, err := Parse(s)
+				if !assert.NoError(t, err) {
+					return
+				}
+				overrides = append(overrides, o)
+			}
+
+			data, err := Apply([]byte(base), overrides)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.JSONEq(t, tc.expected, string(data))
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input         string
+		expected      Override
+		expectedError string
+	}{
+		{
+			input:    "platform.aws.region=us-west-2",
+			expected: Override{Source: "--set platform.aws.region", Path: []string{"platform", "aws", "region"}, Value: "us-west-2"},
+		},
+		{
+			input:    "proxy.noProxy=a=b",
+			expected: Override{Source: "--set proxy.noProxy", Path: []string{"proxy", "noProxy"}, Value: "a=b"},
+		},
+		{
+			input:         "platform.aws.region",
+			expectedError: "must be of the form path=value",
+		},
+		{
+			input:         "platform..region=secret",
+			expectedError: `^invalid override --set platform\.\.region: empty path segment$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			o, err := Parse(tc.input)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, o)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
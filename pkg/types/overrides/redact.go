@@ -0,0 +1,109 @@
+package overrides
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+// Redacted replaces the values of sensitive fields in Redact.
+const Redacted = "REDACTED"
+
+// Redact returns a copy of config in which every non-empty string field
+// tagged `sensitive:"true"` is replaced by Redacted, so that the config can be
+// logged.
+func Redact(config *types.InstallConfig) (*types.InstallConfig, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	redacted := &types.InstallConfig{}
+	if err := json.Unmarshal(data, redacted); err != nil {
+		return nil, err
+	}
+	redact(reflect.ValueOf(redacted).Elem())
+	return redacted, nil
+}
+
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			redact(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if f.Tag.Get("sensitive") == "true" && f.Type.Kind() == reflect.String {
+				if v.Field(i).String() != "" {
+					v.Field(i).SetString(Redacted)
+				}
+				continue
+			}
+			redact(v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			redact(elem)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
+
+// RedactDocument returns a copy of the install config JSON document in data
+// in which every non-empty string in a field tagged `sensitive:"true"` is
+// replaced by Redacted. Unlike Redact, it accepts documents that are not yet
+// valid install configs, and keeps secret references, which hold no secrets.
+func RedactDocument(data []byte) ([]byte, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactDocument(document, reflect.TypeOf(types.InstallConfig{})))
+}
+
+func redactDocument(node interface{}, t reflect.Type) interface{} {
+	switch t = indirect(t); t.Kind() {
+	case reflect.Struct:
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		for name, value := range object {
+			_, f, ok := structField(t, name)
+			if !ok {
+				continue
+			}
+			if f.Tag.Get("sensitive") == "true" {
+				if s, ok := value.(string); ok && s != "" {
+					object[name] = Redacted
+				}
+				continue
+			}
+			object[name] = redactDocument(value, f.Type)
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := node.([]interface{}); ok {
+			for i := range list {
+				list[i] = redactDocument(list[i], t.Elem())
+			}
+		}
+	case reflect.Map:
+		if object, ok := node.(map[string]interface{}); ok {
+			for name, value := range object {
+				object[name] = redactDocument(value, t.Elem())
+			}
+		}
+	}
+	return node
+}
@@ -0,0 +1,64 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+)
+
+func TestRedact(t *testing.T) {
+	config := &types.InstallConfig{
+		BaseDomain: "example.com",
+		Licence:    "secret",
+	}
+
+	redacted, err := Redact(config)
+	assert.NoError(t, err)
+	assert.Equal(t, Redacted, redacted.Licence)
+	assert.Equal(t, "example.com", redacted.BaseDomain)
+	assert.Equal(t, "secret", config.Licence, "the original config must not be modified")
+}
+
+func TestRedactDocument(t *testing.T) {
+	cases := []struct {
+		name     string
+		document string
+		expected string
+	}{
+		{
+			name:     "inline secret",
+			document: `{"baseDomain":"example.com","licence":"secret"}`,
+			expected: `{"baseDomain":"example.com","licence":"REDACTED"}`,
+		},
+		{
+			name:     "secret reference",
+			document: `{"licence":{"fromEnv":"TFE_LICENCE"}}`,
+			expected: `{"licence":{"fromEnv":"TFE_LICENCE"}}`,
+		},
+		{
+			name:     "empty secret",
+			document: `{"licence":""}`,
+			expected: `{"licence":""}`,
+		},
+		{
+			name:     "nested secret",
+			document: `{"tfe":{"adminPassword":"secret","adminUsername":"admin"}}`,
+			expected: `{"tfe":{"adminPassword":"REDACTED","adminUsername":"admin"}}`,
+		},
+		{
+			name:     "unknown fields",
+			document: `{"unknown":{"licence":"kept"}}`,
+			expected: `{"unknown":{"licence":"kept"}}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := RedactDocument([]byte(tc.document))
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, tc.expected, string(data))
+			}
+		})
+	}
+}
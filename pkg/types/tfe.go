@@ -0,0 +1,283 @@
+package types
+
+import (
+	"net"
+	"strings"
+
+	"github.com/bailey84j/terraform_installer/pkg/ipnet"
+)
+
+// DefaultPostgresPort is the port of PostgreSQL when the host has none.
+const DefaultPostgresPort = "5432"
+
+// OperationalMode is how Terraform Enterprise stores its data.
+// +kubebuilder:validation:Enum="";Demo;MountedDisk;ExternalServices
+type OperationalMode string
+
+const (
+	// DemoOperationalMode keeps all data inside the application containers,
+	// where it is lost when the instance is replaced.
+	DemoOperationalMode OperationalMode = "Demo"
+
+	// MountedDiskOperationalMode keeps the data on a disk mounted on the
+	// instance.
+	MountedDiskOperationalMode OperationalMode = "MountedDisk"
+
+	// ExternalServicesOperationalMode keeps the data in an external
+	// PostgreSQL database and object storage.
+	ExternalServicesOperationalMode OperationalMode = "ExternalServices"
+)
+
+// TLSSource is where the TLS certificate of Terraform Enterprise comes from.
+// +kubebuilder:validation:Enum="";Generated;Provided;SelfSigned
+type TLSSource string
+
+const (
+	// GeneratedTLSSource uses a certificate issued by the root CA of the
+	// installer, either the one in tls/root-ca.crt and tls/root-ca.key or
+	// one it generates.
+	GeneratedTLSSource TLSSource = "Generated"
+
+	// SelfSignedTLSSource lets Terraform Enterprise generate a self-signed
+	// certificate when it starts.
+	SelfSignedTLSSource TLSSource = "SelfSigned"
+
+	// ProvidedTLSSource uses the certificate and key the user provides in
+	// tls/tfe.crt and tls/tfe.key, and the CA bundle in tls/tfe-ca.crt if the
+	// certificate is not trusted through the system roots.
+	ProvidedTLSSource TLSSource = "Provided"
+)
+
+// CharacterClass is a class of characters of a password.
+// +kubebuilder:validation:Enum=Lowercase;Uppercase;Digits;Symbols
+type CharacterClass string
+
+const (
+	// LowercaseCharacterClass is the lowercase ASCII letters.
+	LowercaseCharacterClass CharacterClass = "Lowercase"
+
+	// UppercaseCharacterClass is the uppercase ASCII letters.
+	UppercaseCharacterClass CharacterClass = "Uppercase"
+
+	// DigitsCharacterClass is the ASCII digits.
+	DigitsCharacterClass CharacterClass = "Digits"
+
+	// SymbolsCharacterClass is the ASCII punctuation and symbols.
+	SymbolsCharacterClass CharacterClass = "Symbols"
+)
+
+// asciiSymbols are the ASCII punctuation and symbol characters.
+const asciiSymbols = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// Contains returns whether r is a character of the class.
+func (c CharacterClass) Contains(r rune) bool {
+	switch c {
+	case LowercaseCharacterClass:
+		return 'a' <= r && r <= 'z'
+	case UppercaseCharacterClass:
+		return 'A' <= r && r <= 'Z'
+	case DigitsCharacterClass:
+		return '0' <= r && r <= '9'
+	case SymbolsCharacterClass:
+		return strings.ContainsRune(asciiSymbols, r)
+	}
+	return false
+}
+
+// PasswordPolicy is what a password of the initial admin user is made of.
+type PasswordPolicy struct {
+	// Length is the length of the passwords the installer generates, and
+	// the minimum length of a provided password. It must be between 10 and
+	// 72, the most bcrypt hashes.
+	// The default is 23.
+	// +optional
+	Length int `json:"length,omitempty"`
+
+	// CharacterClasses are the classes of characters a password has at
+	// least one character of: "Lowercase", "Uppercase", "Digits" or
+	// "Symbols". Generated passwords are only made of these classes.
+	// The default is Lowercase, Uppercase and Digits.
+	// +optional
+	CharacterClasses []CharacterClass `json:"characterClasses,omitempty"`
+}
+
+// TFE is the configuration of the Terraform Enterprise application.
+type TFE struct {
+	// Hostname is the fully qualified domain name users reach Terraform
+	// Enterprise at.
+	// The default is tfe.<cluster domain>. When publish is Internal, it must
+	// be in the cluster domain, whose private zone holds its record. When
+	// publish is External, it must be in the base domain, whose public zone
+	// holds its record.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// OperationalMode is how Terraform Enterprise stores its data: "Demo",
+	// "MountedDisk" or "ExternalServices".
+	// The default is "Demo".
+	//
+	// +kubebuilder:default=Demo
+	// +optional
+	OperationalMode OperationalMode `json:"operationalMode,omitempty"`
+
+	// ExternalServices are the PostgreSQL database and object storage that
+	// keep the data when the operational mode is "ExternalServices".
+	// +optional
+	ExternalServices *ExternalServices `json:"externalServices,omitempty"`
+
+	// DiskPath is the path on the instance where the data is stored when
+	// the operational mode is "MountedDisk". The data volume mounted there
+	// is kept when the instance is terminated.
+	// The default is /opt/tfe/data.
+	// +optional
+	DiskPath string `json:"diskPath,omitempty"`
+
+	// CapacityConcurrency is the number of Terraform runs that may run at
+	// the same time.
+	// The default is 10.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CapacityConcurrency int `json:"capacityConcurrency,omitempty"`
+
+	// EncryptionPassword is the password that Terraform Enterprise encrypts
+	// its sensitive data with. It must be kept to restore the data. If
+	// unset, the installer generates one once and writes it to
+	// auth/tfe-encryption-password.
+	// +optional
+	EncryptionPassword string `json:"encryptionPassword,omitempty" sensitive:"true"`
+
+	// TLSSource is where the TLS certificate comes from: "Generated",
+	// "Provided" or "SelfSigned".
+	// The default is "Generated".
+	//
+	// +kubebuilder:default=Generated
+	// +optional
+	TLSSource TLSSource `json:"tlsSource,omitempty"`
+
+	// AdminEmail is the email address of the initial admin user. When it
+	// is set, the installer creates the initial admin user with
+	// adminPassword, or the generated password in auth/tfe-password, once
+	// Terraform Enterprise is healthy, and stores the API token of the user
+	// in auth/tfe-api-token. With a SelfSigned certificate, which cannot be
+	// verified, the user is only created when --insecure is passed.
+	// +optional
+	AdminEmail string `json:"adminEmail,omitempty"`
+
+	// AdminUsername is the username of the initial admin user.
+	// The default is "admin".
+	// +optional
+	AdminUsername string `json:"adminUsername,omitempty"`
+
+	// AdminPassword is the password of the initial admin user. It must
+	// follow adminPasswordPolicy. Like every sensitive field, it may
+	// instead hold a secret reference, e.g. {fromFile: path}. If it is
+	// unset, the installer generates a password once and writes it to
+	// auth/tfe-password.
+	// +optional
+	AdminPassword string `json:"adminPassword,omitempty" sensitive:"true"`
+
+	// AdminPasswordPolicy is what the password of the initial admin user
+	// is made of.
+	// +optional
+	AdminPasswordPolicy *PasswordPolicy `json:"adminPasswordPolicy,omitempty"`
+
+	// Organizations are the names of the organizations the installer
+	// creates after the initial admin user. They require adminEmail, which
+	// becomes their email address. Overlays add to the organizations of the
+	// base install config rather than replace them.
+	// +optional
+	Organizations []string `json:"organizations,omitempty" patchStrategy:"merge"`
+
+	// IACTSubnets are the subnets that may retrieve the initial admin
+	// creation token. The installer retrieves it to create the initial
+	// admin user, so the address it runs at must be in one of them. By
+	// default only the instances themselves may retrieve it.
+	// +optional
+	IACTSubnets []ipnet.IPNet `json:"iactSubnets,omitempty"`
+}
+
+// PostgresSSLMode is how the connection to PostgreSQL is secured, as in the
+// sslmode parameter of libpq.
+// +kubebuilder:validation:Enum="";disable;require;verify-ca;verify-full
+type PostgresSSLMode string
+
+const (
+	// PostgresSSLModeDisable does not use SSL.
+	PostgresSSLModeDisable PostgresSSLMode = "disable"
+
+	// PostgresSSLModeRequire uses SSL without verifying the server.
+	PostgresSSLModeRequire PostgresSSLMode = "require"
+
+	// PostgresSSLModeVerifyCA uses SSL and verifies that the server
+	// certificate is issued by a trusted CA.
+	PostgresSSLModeVerifyCA PostgresSSLMode = "verify-ca"
+
+	// PostgresSSLModeVerifyFull uses SSL and verifies that the server
+	// certificate is issued by a trusted CA for the host.
+	PostgresSSLModeVerifyFull PostgresSSLMode = "verify-full"
+)
+
+// ExternalServices are the existing services that keep the data of Terraform
+// Enterprise in the "ExternalServices" operational mode.
+type ExternalServices struct {
+	// Postgres is the PostgreSQL database.
+	Postgres Postgres `json:"postgres"`
+
+	// ObjectStorage is the S3-compatible object storage.
+	ObjectStorage ObjectStorage `json:"objectStorage"`
+}
+
+// Postgres is an existing PostgreSQL database.
+type Postgres struct {
+	// Host is the host name or IP address of the server, optionally
+	// followed by :port. The default port is 5432.
+	Host string `json:"host"`
+
+	// Database is the name of the database.
+	Database string `json:"database"`
+
+	// User is the user Terraform Enterprise connects as.
+	User string `json:"user"`
+
+	// Password is the password of the user. Like every sensitive field, it
+	// may instead hold a secret reference, e.g. {fromEnv: name}.
+	Password string `json:"password" sensitive:"true"`
+
+	// SSLMode is how the connection is secured: "disable", "require",
+	// "verify-ca" or "verify-full".
+	// The default is "require".
+	//
+	// +kubebuilder:default=require
+	// +optional
+	SSLMode PostgresSSLMode `json:"sslMode,omitempty"`
+}
+
+// Address returns the host and port of the server.
+func (p *Postgres) Address() string {
+	if _, _, err := net.SplitHostPort(p.Host); err == nil {
+		return p.Host
+	}
+	return net.JoinHostPort(p.Host, DefaultPostgresPort)
+}
+
+// ObjectStorage is an existing S3-compatible bucket.
+type ObjectStorage struct {
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Region is the region of the bucket.
+	// The default on AWS is the region of the platform.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// KMSKeyARN is the ARN of the KMS key that the objects are encrypted
+	// with. The objects are encrypted with the default S3 key if unset.
+	// +optional
+	KMSKeyARN string `json:"kmsKeyARN,omitempty"`
+
+	// Endpoint is the URL of an S3-compatible service to use instead of
+	// AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
@@ -2,10 +2,17 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"net/mail"
 	"net/url"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
@@ -13,6 +20,7 @@ import (
 	"github.com/bailey84j/terraform_installer/pkg/types"
 	"github.com/bailey84j/terraform_installer/pkg/types/aws"
 	awsvalidation "github.com/bailey84j/terraform_installer/pkg/types/aws/validation"
+	"github.com/bailey84j/terraform_installer/pkg/types/overrides"
 	"github.com/bailey84j/terraform_installer/pkg/validate"
 )
 
@@ -38,18 +46,19 @@ func ValidateInstallConfig(c *types.InstallConfig) field.ErrorList {
 		}
 
 	}
-	/*
-		if c.AdditionalTrustBundle != "" {
-			if err := validate.CABundle(c.AdditionalTrustBundle); err != nil {
-				allErrs = append(allErrs, field.Invalid(field.NewPath("additionalTrustBundle"), c.AdditionalTrustBundle, err.Error()))
-			}
+	allErrs = append(allErrs, validateLicence(c)...)
+	if c.AdditionalTrustBundle != "" {
+		if err := validate.CABundle(c.AdditionalTrustBundle); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("additionalTrustBundle"), c.AdditionalTrustBundle, err.Error()))
 		}
-		if c.AdditionalTrustBundlePolicy != "" {
-			if err := validateAdditionalCABundlePolicy(c); err != nil {
-				allErrs = append(allErrs, field.Invalid(field.NewPath("additionalTrustBundlePolicy"), c.AdditionalTrustBundlePolicy, err.Error()))
-			}
+	}
+	if c.AdditionalTrustBundlePolicy != "" {
+		if err := validateAdditionalCABundlePolicy(c); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("additionalTrustBundlePolicy"), c.AdditionalTrustBundlePolicy, err.Error()))
+		} else if c.AdditionalTrustBundlePolicy == types.PolicyAlways && c.AdditionalTrustBundle == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("additionalTrustBundle"), fmt.Sprintf("additionalTrustBundle is required when additionalTrustBundlePolicy is %s", types.PolicyAlways)))
 		}
-	*/
+	}
 	nameErr := validate.ClusterName(c.ObjectMeta.Name)
 	/*if c.Platform.GCP != nil || c.Platform.Azure != nil {
 		nameErr = validate.ClusterName1035(c.ObjectMeta.Name)
@@ -70,51 +79,114 @@ func ValidateInstallConfig(c *types.InstallConfig) field.ErrorList {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("baseDomain"), clusterDomain, err.Error()))
 		}
 	}
+	if c.Networking != nil {
+		allErrs = append(allErrs, validateNetworking(c.Networking, field.NewPath("networking"))...)
+	} else {
+		allErrs = append(allErrs, field.Required(field.NewPath("networking"), "networking is required"))
+	}
+	if c.Proxy != nil {
+		allErrs = append(allErrs, validateProxy(c.Proxy, c, field.NewPath("proxy"))...)
+	}
+	if c.ControlPlane != nil {
+		allErrs = append(allErrs, validateControlPlane(&c.Platform, c.ControlPlane, field.NewPath("controlPlane"))...)
+	} else {
+		allErrs = append(allErrs, field.Required(field.NewPath("controlPlane"), "controlPlane is required"))
+	}
+	allErrs = append(allErrs, validateCompute(&c.Platform, c.ControlPlane, c.Compute, field.NewPath("compute"))...)
+	allErrs = append(allErrs, validatePlatform(&c.Platform, field.NewPath("platform"), c.Networking, c)...)
+	if len(c.DeprecatedImageContentSources) > 0 {
+		// Conversion moves them to imageMirrors, so they are left only when
+		// set in a config that already has the current version.
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("imageContentSources"), fmt.Sprintf("imageContentSources was replaced by imageMirrors in %s", types.InstallConfigVersion)))
+	}
+	allErrs = append(allErrs, validateImageMirrors(c.ImageMirrors, field.NewPath("imageMirrors"))...)
+	if c.Airgap != nil {
+		allErrs = append(allErrs, validateAirgap(c.Airgap, field.NewPath("airgap"))...)
+	}
+	if c.TFE != nil {
+		allErrs = append(allErrs, validateTFE(c.TFE, field.NewPath("tfe"))...)
+	}
 	/*
-		if c.Networking != nil {
-			allErrs = append(allErrs, validateNetworking(c.Networking, c.IsSingleNodeOpenShift(), field.NewPath("networking"))...)
-			allErrs = append(allErrs, validateNetworkingIPVersion(c.Networking, &c.Platform)...)
-			allErrs = append(allErrs, validateNetworkingForPlatform(c.Networking, &c.Platform, field.NewPath("networking"))...)
-			allErrs = append(allErrs, validateVIPsForPlatform(c.Networking, &c.Platform, field.NewPath("platform"))...)
-		} else {
-			allErrs = append(allErrs, field.Required(field.NewPath("networking"), "networking is required"))
-		}
-		allErrs = append(allErrs, validatePlatform(&c.Platform, field.NewPath("platform"), c.Networking, c)...)
-		if c.ControlPlane != nil {
-			allErrs = append(allErrs, validateControlPlane(&c.Platform, c.ControlPlane, field.NewPath("controlPlane"))...)
-		} else {
-			allErrs = append(allErrs, field.Required(field.NewPath("controlPlane"), "controlPlane is required"))
-		}
-		allErrs = append(allErrs, validateCompute(&c.Platform, c.ControlPlane, c.Compute, field.NewPath("compute"))...)
 		if err := validate.ImagePullSecret(c.PullSecret); err != nil {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("pullSecret"), c.PullSecret, err.Error()))
 		}
-		if c.Proxy != nil {
-			allErrs = append(allErrs, validateProxy(c.Proxy, c, field.NewPath("proxy"))...)
-		}
-		allErrs = append(allErrs, validateImageContentSources(c.ImageContentSources, field.NewPath("imageContentSources"))...)
 		if _, ok := validPublishingStrategies[c.Publish]; !ok {
 			allErrs = append(allErrs, field.NotSupported(field.NewPath("publish"), c.Publish, validPublishingStrategyValues))
 		}
-		allErrs = append(allErrs, validateCloudCredentialsMode(c.CredentialsMode, field.NewPath("credentialsMode"), c.Platform)...)
 		if c.Capabilities != nil {
 			allErrs = append(allErrs, validateCapabilities(c.Capabilities, field.NewPath("capabilities"))...)
 		}
 	*/
 
+	allErrs = append(allErrs, validateCloudCredentialsMode(c.CredentialsMode, field.NewPath("credentialsMode"), c.Platform)...)
+
 	if c.Publish == types.InternalPublishingStrategy {
 		switch platformName := c.Platform.Name(); platformName {
 		case aws.Name:
+			if len(c.Platform.AWS.Subnets) == 0 {
+				allErrs = append(allErrs, field.Required(field.NewPath("platform", "aws", "subnets"), "existing private subnets must be provided when publish is Internal"))
+			}
+			if c.TFE != nil && c.TFE.Hostname != "" && !strings.HasSuffix(strings.TrimSuffix(c.TFE.Hostname, "."), "."+c.ClusterDomain()) {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("tfe", "hostname"), c.TFE.Hostname, "hostname must be in the cluster domain when publish is Internal"))
+			}
 		default:
 			allErrs = append(allErrs, field.Invalid(field.NewPath("publish"), c.Publish, fmt.Sprintf("Internal publish strategy is not supported on %q platform", platformName)))
 		}
 	}
+	if c.Publish == types.ExternalPublishingStrategy && c.Platform.AWS != nil {
+		// The hostname is published in the public zone of the base domain.
+		if c.TFE != nil && c.TFE.Hostname != "" && !strings.HasSuffix(strings.TrimSuffix(c.TFE.Hostname, "."), "."+strings.TrimSuffix(c.BaseDomain, ".")) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("tfe", "hostname"), c.TFE.Hostname, "hostname must be in the base domain when publish is External"))
+		}
+	}
 
-	allErrs = append(allErrs)
+	if c.PurgePolicy != "" {
+		if _, ok := validPurgePolicies[c.PurgePolicy]; !ok {
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("purgePolicy"), c.PurgePolicy, validPurgePolicyValues))
+		}
+	}
 
 	return allErrs
 }
 
+var (
+	validPurgePolicies = map[types.PurgePolicy]struct{}{
+		types.DeletePurgePolicy:  {},
+		types.ArchivePurgePolicy: {},
+		types.KeepPurgePolicy:    {},
+	}
+
+	validPurgePolicyValues = func() []string {
+		v := make([]string, 0, len(validPurgePolicies))
+		for p := range validPurgePolicies {
+			v = append(v, string(p))
+		}
+		sort.Strings(v)
+		return v
+	}()
+)
+
+// validateLicence checks that the licence is given exactly once. The licence
+// itself is read and parsed by the licence asset.
+func validateLicence(c *types.InstallConfig) field.ErrorList {
+	switch {
+	case c.LicencePath == "" && c.Licence == "":
+		return field.ErrorList{field.Required(field.NewPath("licencePath"), "licencePath or licence is required")}
+	case c.LicencePath != "" && c.Licence != "":
+		return field.ErrorList{field.Forbidden(field.NewPath("licence"), "licence must not be set together with licencePath")}
+	}
+	return nil
+}
+
+func validateAdditionalCABundlePolicy(c *types.InstallConfig) error {
+	switch c.AdditionalTrustBundlePolicy {
+	case types.PolicyProxyOnly, types.PolicyAlways:
+		return nil
+	default:
+		return fmt.Errorf("supported values %q, %q", types.PolicyProxyOnly, types.PolicyAlways)
+	}
+}
+
 // ipAddressType indicates the address types provided for a given field
 type ipAddressType struct {
 	IPv4 bool
@@ -136,6 +208,84 @@ func ipnetworksToStrings(networks []ipnet.IPNet) []string {
 	return diag
 }
 
+func validateNetworking(n *types.Networking, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(n.MachineNetwork) > 0 {
+		for i, network := range n.MachineNetwork {
+			if err := validate.SubnetCIDR(&network.CIDR.IPNet); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("machineNetwork").Index(i), network.CIDR.String(), err.Error()))
+			}
+			for j, subNetwork := range n.MachineNetwork[0:i] {
+				if validate.DoCIDRsOverlap(&network.CIDR.IPNet, &subNetwork.CIDR.IPNet) {
+					allErrs = append(allErrs, field.Invalid(fldPath.Child("machineNetwork").Index(i), network.CIDR.String(), fmt.Sprintf("machine network must not overlap with machine network %d", j)))
+				}
+			}
+		}
+	} else {
+		allErrs = append(allErrs, field.Required(fldPath.Child("machineNetwork"), "at least one machine network is required"))
+	}
+
+	for i, sn := range n.ServiceNetwork {
+		if err := validate.ServiceSubnetCIDR(&sn.IPNet); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), err.Error()))
+		}
+		for _, network := range n.MachineNetwork {
+			if validate.DoCIDRsOverlap(&sn.IPNet, &network.CIDR.IPNet) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), "service network must not overlap with any of the machine networks"))
+			}
+		}
+	}
+	switch {
+	case len(n.ServiceNetwork) == 0:
+		allErrs = append(allErrs, field.Required(fldPath.Child("serviceNetwork"), "a service network is required"))
+	case len(n.ServiceNetwork) > 1:
+		allErrs = append(allErrs, field.TooMany(fldPath.Child("serviceNetwork"), len(n.ServiceNetwork), 1))
+	}
+
+	for i, cn := range n.ClusterNetwork {
+		allErrs = append(allErrs, validateClusterNetwork(n, &cn, i, fldPath.Child("clusterNetwork").Index(i))...)
+	}
+	if len(n.ClusterNetwork) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clusterNetwork"), "cluster network required"))
+	}
+	return allErrs
+}
+
+func validateClusterNetwork(n *types.Networking, cn *types.ClusterNetworkEntry, idx int, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if err := validate.SubnetCIDR(&cn.CIDR.IPNet); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.IPNet.String(), err.Error()))
+	}
+	for _, network := range n.MachineNetwork {
+		if validate.DoCIDRsOverlap(&cn.CIDR.IPNet, &network.CIDR.IPNet) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.String(), "cluster network must not overlap with any of the machine networks"))
+		}
+	}
+	for i, sn := range n.ServiceNetwork {
+		if validate.DoCIDRsOverlap(&cn.CIDR.IPNet, &sn.IPNet) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.String(), fmt.Sprintf("cluster network must not overlap with service network %d", i)))
+		}
+	}
+	for i, acn := range n.ClusterNetwork[0:idx] {
+		if validate.DoCIDRsOverlap(&cn.CIDR.IPNet, &acn.CIDR.IPNet) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.String(), fmt.Sprintf("cluster network must not overlap with cluster network %d", i)))
+		}
+	}
+	if cn.HostPrefix < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("hostPrefix"), cn.HostPrefix, "hostPrefix must be positive"))
+	}
+	// hostPrefix is optional, so only check it against the CIDR when it is set
+	if cn.HostPrefix != 0 {
+		if ones, bits := cn.CIDR.Mask.Size(); cn.HostPrefix < int32(ones) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostPrefix"), cn.HostPrefix, "cluster network host subnetwork prefix must not be larger size than CIDR "+cn.CIDR.String()))
+		} else if bits == 128 && cn.HostPrefix != 64 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostPrefix"), cn.HostPrefix, "cluster network host subnetwork prefix must be 64 for IPv6 networks"))
+		}
+	}
+	return allErrs
+}
+
 func validatePlatform(platform *types.Platform, fldPath *field.Path, network *types.Networking, c *types.InstallConfig) field.ErrorList {
 	allErrs := field.ErrorList{}
 	activePlatform := platform.Name()
@@ -239,6 +389,370 @@ func validateCloudCredentialsMode(mode types.CredentialsMode, fldPath *field.Pat
 	} else {
 		allErrs = append(allErrs, field.Invalid(fldPath, mode, fmt.Sprintf("cannot be set when using the %q platform", platform.Name())))
 	}
+	if platform.AWS != nil {
+		allErrs = append(allErrs, awsvalidation.ValidateCredentialsMode(platform.AWS, mode, field.NewPath("platform", "aws"))...)
+	}
+	return allErrs
+}
+
+func validateProxy(p *types.Proxy, c *types.InstallConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if p.HTTPProxy == "" && p.HTTPSProxy == "" {
+		allErrs = append(allErrs, field.Required(fldPath, "must include httpProxy or httpsProxy"))
+	}
+	if p.HTTPProxy != "" {
+		allErrs = append(allErrs, validateURI(p.HTTPProxy, fldPath.Child("httpProxy"), []string{"http"})...)
+		if c.Networking != nil {
+			allErrs = append(allErrs, validateIPProxy(p.HTTPProxy, c.Networking, fldPath.Child("httpProxy"))...)
+		}
+	}
+	if p.HTTPSProxy != "" {
+		allErrs = append(allErrs, validateURI(p.HTTPSProxy, fldPath.Child("httpsProxy"), []string{"http", "https"})...)
+		if c.Networking != nil {
+			allErrs = append(allErrs, validateIPProxy(p.HTTPSProxy, c.Networking, fldPath.Child("httpsProxy"))...)
+		}
+	}
+	if p.NoProxy != "" && p.NoProxy != "*" {
+		if strings.Contains(p.NoProxy, " ") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("noProxy"), p.NoProxy, "noProxy must not have spaces"))
+		}
+		for idx, v := range strings.Split(p.NoProxy, ",") {
+			v = strings.TrimSpace(v)
+			errDomain := validate.NoProxyDomainName(v)
+			_, _, errCIDR := net.ParseCIDR(v)
+			ip := net.ParseIP(v)
+			if errDomain != nil && errCIDR != nil && ip == nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("noProxy"), p.NoProxy, fmt.Sprintf(
+					"each element of noProxy must be a IP, CIDR or domain without wildcard characters, which is violated by element %d %q", idx, v)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateIPProxy checks that a proxy given by IP address is not inside the
+// cluster or service networks, where the instances could not reach it.
+func validateIPProxy(proxy string, n *types.Networking, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	parsed, err := url.ParseRequestURI(proxy)
+	if err != nil {
+		return allErrs
+	}
+	proxyIP := net.ParseIP(parsed.Hostname())
+	if proxyIP == nil {
+		return allErrs
+	}
+	for _, network := range n.ClusterNetwork {
+		if network.CIDR.Contains(proxyIP) {
+			allErrs = append(allErrs, field.Invalid(fldPath, proxy, "proxy value is part of the cluster networks"))
+			break
+		}
+	}
+	for _, network := range n.ServiceNetwork {
+		if network.Contains(proxyIP) {
+			allErrs = append(allErrs, field.Invalid(fldPath, proxy, "proxy value is part of the service networks"))
+			break
+		}
+	}
+	return allErrs
+}
+
+func validateImageMirrors(mirrors []types.ImageMirror, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	sources := map[string]bool{}
+	for i, m := range mirrors {
+		mirrorPath := fldPath.Index(i)
+		if err := validate.ImageRepository(m.Source); err != nil {
+			allErrs = append(allErrs, field.Invalid(mirrorPath.Child("source"), m.Source, err.Error()))
+		} else if sources[m.Source] {
+			allErrs = append(allErrs, field.Duplicate(mirrorPath.Child("source"), m.Source))
+		}
+		sources[m.Source] = true
+		if len(m.Mirrors) == 0 {
+			allErrs = append(allErrs, field.Required(mirrorPath.Child("mirrors"), "at least one mirror is required"))
+		}
+		for j, mirror := range m.Mirrors {
+			if err := validate.ImageRepository(mirror); err != nil {
+				allErrs = append(allErrs, field.Invalid(mirrorPath.Child("mirrors").Index(j), mirror, err.Error()))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateAirgap(a *types.Airgap, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	schemes := []string{"s3", "https"}
+	if a.PackageURL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("packageURL"), "the location of the airgap package is required"))
+	} else {
+		allErrs = append(allErrs, validateURI(a.PackageURL, fldPath.Child("packageURL"), schemes)...)
+	}
+	if a.BootstrapperURL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("bootstrapperURL"), "the location of the bootstrapper archive is required"))
+	} else {
+		allErrs = append(allErrs, validateURI(a.BootstrapperURL, fldPath.Child("bootstrapperURL"), schemes)...)
+	}
+	names := map[string]bool{}
+	for i, artifact := range a.Artifacts {
+		name := filepath.Base(artifact)
+		switch {
+		case artifact == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("artifacts").Index(i), "artifact path must not be empty"))
+		case names[name]:
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("artifacts").Index(i), artifact))
+		}
+		names[name] = true
+	}
+	return allErrs
+}
+
+var (
+	validOperationalModes = map[types.OperationalMode]struct{}{
+		types.DemoOperationalMode:             {},
+		types.MountedDiskOperationalMode:      {},
+		types.ExternalServicesOperationalMode: {},
+	}
+
+	validOperationalModeValues = func() []string {
+		v := make([]string, 0, len(validOperationalModes))
+		for m := range validOperationalModes {
+			v = append(v, string(m))
+		}
+		sort.Strings(v)
+		return v
+	}()
+
+	validTLSSources = map[types.TLSSource]struct{}{
+		types.GeneratedTLSSource:  {},
+		types.SelfSignedTLSSource: {},
+		types.ProvidedTLSSource:   {},
+	}
+
+	validTLSSourceValues = func() []string {
+		v := make([]string, 0, len(validTLSSources))
+		for s := range validTLSSources {
+			v = append(v, string(s))
+		}
+		sort.Strings(v)
+		return v
+	}()
+
+	validCharacterClasses = map[types.CharacterClass]struct{}{
+		types.LowercaseCharacterClass: {},
+		types.UppercaseCharacterClass: {},
+		types.DigitsCharacterClass:    {},
+		types.SymbolsCharacterClass:   {},
+	}
+
+	validCharacterClassValues = func() []string {
+		v := make([]string, 0, len(validCharacterClasses))
+		for c := range validCharacterClasses {
+			v = append(v, string(c))
+		}
+		sort.Strings(v)
+		return v
+	}()
+)
+
+const (
+	// minPasswordLength is the shortest password Terraform Enterprise
+	// accepts, and maxPasswordLength the longest that bcrypt hashes.
+	minPasswordLength = 10
+	maxPasswordLength = 72
+)
+
+func validateTFE(t *types.TFE, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if t.Hostname != "" {
+		if err := validate.DomainName(t.Hostname, false); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostname"), t.Hostname, err.Error()))
+		}
+	}
+	if t.OperationalMode != "" {
+		if _, ok := validOperationalModes[t.OperationalMode]; !ok {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("operationalMode"), t.OperationalMode, validOperationalModeValues))
+		}
+	}
+	if t.DiskPath != "" {
+		switch {
+		case t.OperationalMode != types.MountedDiskOperationalMode:
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("diskPath"), fmt.Sprintf("diskPath may only be set when operationalMode is %s", types.MountedDiskOperationalMode)))
+		case !filepath.IsAbs(t.DiskPath):
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("diskPath"), t.DiskPath, "diskPath must be an absolute path"))
+		}
+	}
+	switch {
+	case t.OperationalMode == types.ExternalServicesOperationalMode && t.ExternalServices == nil:
+		allErrs = append(allErrs, field.Required(fldPath.Child("externalServices"), fmt.Sprintf("externalServices is required when operationalMode is %s", types.ExternalServicesOperationalMode)))
+	case t.OperationalMode != types.ExternalServicesOperationalMode && t.ExternalServices != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("externalServices"), fmt.Sprintf("externalServices may only be set when operationalMode is %s", types.ExternalServicesOperationalMode)))
+	case t.ExternalServices != nil:
+		allErrs = append(allErrs, validatePostgres(&t.ExternalServices.Postgres, fldPath.Child("externalServices", "postgres"))...)
+		allErrs = append(allErrs, validateObjectStorage(&t.ExternalServices.ObjectStorage, fldPath.Child("externalServices", "objectStorage"))...)
+	}
+	if t.CapacityConcurrency < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("capacityConcurrency"), t.CapacityConcurrency, "capacityConcurrency must be positive"))
+	}
+	if t.TLSSource != "" {
+		if _, ok := validTLSSources[t.TLSSource]; !ok {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("tlsSource"), t.TLSSource, validTLSSourceValues))
+		}
+	}
+	if t.AdminEmail != "" {
+		if address, err := mail.ParseAddress(t.AdminEmail); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("adminEmail"), t.AdminEmail, err.Error()))
+		} else if address.Address != t.AdminEmail {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("adminEmail"), t.AdminEmail, "adminEmail must be a bare email address"))
+		}
+	}
+	if t.AdminPasswordPolicy != nil {
+		allErrs = append(allErrs, validatePasswordPolicy(t.AdminPasswordPolicy, fldPath.Child("adminPasswordPolicy"))...)
+	}
+	if t.AdminPassword != "" && t.AdminPasswordPolicy != nil {
+		if err := checkPasswordPolicy(t.AdminPassword, t.AdminPasswordPolicy); err != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("adminPassword"), overrides.Redacted, err))
+		}
+	}
+	if t.AdminUsername != "" && !tfeNameRegexp.MatchString(t.AdminUsername) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("adminUsername"), t.AdminUsername, "adminUsername may only contain letters, numbers, - and _"))
+	}
+	if len(t.Organizations) > 0 && t.AdminEmail == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("adminEmail"), "adminEmail is required to create organizations"))
+	}
+	organizations := sets.NewString()
+	for i, name := range t.Organizations {
+		switch {
+		case !tfeNameRegexp.MatchString(name):
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("organizations").Index(i), name, "organization names may only contain letters, numbers, - and _"))
+		case organizations.Has(name):
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("organizations").Index(i), name))
+		}
+		organizations.Insert(name)
+	}
+	return allErrs
+}
+
+func validatePasswordPolicy(p *types.PasswordPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if p.Length != 0 && (p.Length < minPasswordLength || p.Length > maxPasswordLength) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("length"), p.Length, fmt.Sprintf("length must be between %d and %d", minPasswordLength, maxPasswordLength)))
+	}
+	classes := map[types.CharacterClass]bool{}
+	for i, c := range p.CharacterClasses {
+		switch _, ok := validCharacterClasses[c]; {
+		case !ok:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("characterClasses").Index(i), c, validCharacterClassValues))
+		case classes[c]:
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("characterClasses").Index(i), c))
+		}
+		classes[c] = true
+	}
+	return allErrs
+}
+
+// checkPasswordPolicy returns why the password does not follow the policy, or
+// an empty string if it does.
+func checkPasswordPolicy(password string, p *types.PasswordPolicy) string {
+	if n := utf8.RuneCountInString(password); n < p.Length {
+		return fmt.Sprintf("the password must be at least %d characters long, not %d", p.Length, n)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Sprintf("the password must be at most %d bytes long", maxPasswordLength)
+	}
+	var missing []string
+	for _, c := range p.CharacterClasses {
+		if strings.IndexFunc(password, c.Contains) < 0 {
+			missing = append(missing, string(c))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("the password must have characters of the classes %s", strings.Join(missing, ", "))
+	}
+	return ""
+}
+
+var (
+	validPostgresSSLModes = map[types.PostgresSSLMode]struct{}{
+		types.PostgresSSLModeDisable:    {},
+		types.PostgresSSLModeRequire:    {},
+		types.PostgresSSLModeVerifyCA:   {},
+		types.PostgresSSLModeVerifyFull: {},
+	}
+
+	validPostgresSSLModeValues = func() []string {
+		v := make([]string, 0, len(validPostgresSSLModes))
+		for m := range validPostgresSSLModes {
+			v = append(v, string(m))
+		}
+		sort.Strings(v)
+		return v
+	}()
+
+	// tfeNameRegexp matches the names of Terraform Enterprise users and
+	// organizations.
+	tfeNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+	// bucketNameRegexp matches the names of S3 buckets.
+	bucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+)
+
+func validatePostgres(p *types.Postgres, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if p.Host == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("host"), "the host of the database is required"))
+	} else {
+		host, port, err := net.SplitHostPort(p.Host)
+		if err != nil {
+			host, port = p.Host, ""
+		}
+		if err := validate.Host(host); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("host"), p.Host, err.Error()))
+		}
+		if port != "" {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("host"), p.Host, "port must be between 1 and 65535"))
+			}
+		}
+	}
+	if p.Database == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("database"), "the name of the database is required"))
+	}
+	if p.User == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("user"), "the user of the database is required"))
+	}
+	if p.Password == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("password"), "the password of the database user is required"))
+	}
+	if p.SSLMode != "" {
+		if _, ok := validPostgresSSLModes[p.SSLMode]; !ok {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("sslMode"), p.SSLMode, validPostgresSSLModeValues))
+		}
+	}
+	return allErrs
+}
+
+func validateObjectStorage(s *types.ObjectStorage, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch {
+	case s.Bucket == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("bucket"), "the name of the bucket is required"))
+	case !bucketNameRegexp.MatchString(s.Bucket) || strings.Contains(s.Bucket, ".."):
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("bucket"), s.Bucket, "bucket names must be 3 to 63 lowercase letters, digits, dots and hyphens, starting and ending with a letter or digit"))
+	}
+	if s.Region == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("region"), "the region of the bucket is required"))
+	}
+	if s.KMSKeyARN != "" {
+		if parsed, err := arn.Parse(s.KMSKeyARN); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("kmsKeyARN"), s.KMSKeyARN, err.Error()))
+		} else if parsed.Service != "kms" || !strings.HasPrefix(parsed.Resource, "key/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("kmsKeyARN"), s.KMSKeyARN, "must be the ARN of a KMS key"))
+		}
+	}
+	if s.Endpoint != "" {
+		allErrs = append(allErrs, validateURI(s.Endpoint, fldPath.Child("endpoint"), []string{"http", "https"})...)
+	}
 	return allErrs
 }
 
@@ -0,0 +1,387 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bailey84j/terraform_installer/pkg/ipnet"
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+)
+
+const testCABundle = `-----BEGIN CERTIFICATE-----
+MIIF2zCCA8OgAwIBAgICEAAwDQYJKoZIhvcNAQELBQAwgYExCzAJBgNVBAYTAlVT
+MRcwFQYDVQQIDA5Ob3J0aCBDYXJvbGluYTEQMA4GA1UEBwwHUmFsZWlnaDEUMBIG
+A1UECgwLUmVkIEhhdCBJbmMxHzAdBgNVBAsMFk9wZW5TaGlmdCBJbnN0YWxsIFRl
+c3QxEDAOBgNVBAMMB1Jvb3QgQ0EwHhcNMTkwNzIyMjAwNzUxWhcNMjkwNzE5MjAw
+NzUxWjB3MQswCQYDVQQGEwJVUzEXMBUGA1UECAwOTm9ydGggQ2Fyb2xpbmExFDAS
+BgNVBAoMC1JlZCBIYXQgSW5jMR8wHQYDVQQLDBZPcGVuU2hpZnQgSW5zdGFsbCBU
+ZXN0MRgwFgYDVQQDDA9JbnRlcm1lZGlhdGUgQ0EwggIiMA0GCSqGSIb3DQEBAQUA
+A4ICDwAwggIKAoICAQDZhc69vEq9XyG+vcOW4rPx9aYJgn7NFXaE88xrKajFyu2v
+kD5Mz7geQV/RQKp1RMvj/1JCW5Npw8QwoPXNGQ8M+d+ajGgSkUZNVBQRXiR/hpfK
+ohox9gJRsOVCAvhyE15iZHkEVFFcchiWbsTM9QllLsiiI0qZ/QpkUmJmDyXUV4Hq
+hoAGXsojp0xaEQhrl+Hayiwao7qZkbKFCbNIDFU++ZDNT41qqDwcYmbkBJgYoGdS
+IAk4Mjf7+rLJPXWNYtYB3g1cuN4pH8FkFT9zocNr0xrsx2itY4gvXgIe/vzts8aw
+sHx1h2HcZK7iJEHs25QGrsZhiADeb0i5pN1kaPqpY0qgQUCIaqZAtMMeHXQ0k3PB
+xTz8vk0388oFLaJFuI0P9Q6CRf5+4rc9O201aUIuue3Y4IS6zAcd8yL5d5vxvCiN
+Dbl7YenBS4C9xSEEiVZwN7AtIdKFq5pGrlptmhVbGFW1CLQNsVWpetCY12Sh9FOq
+2IBaAup+XgRgO4kHs3t7euVaS2viH3MplPsOUim8NZPZBdZkTtS3W9SynBDriy1d
+KtrYgz0zrgEAa82mq4INaR+7Utct97zhKa1zM47KlHgkauiTPkUcqVhoNWxdM5tI
+nSWym/9pPHUmzt8v/F8COA/8Xv+db2QX14S3fStI+8mp084RWuevtbh5WcoypQID
+AQABo2YwZDAdBgNVHQ4EFgQUPUqJPYDZeUXbBlR0xXA/F+DYYagwHwYDVR0jBBgw
+FoAUjWflPh3KYZ5o3BP3Po4v2ZBshVkwEgYDVR0TAQH/BAgwBgEB/wIBADAOBgNV
+HQ8BAf8EBAMCAYYwDQYJKoZIhvcNAQELBQADggIBAH665ntrBhyf+MPFnkY+1VUr
+VrfRlP4SccoujdLB/sUKqydYsED+mDJ+V8uFOgoi7PHqwvsRS+yR/bB0bNNYSfKY
+slCMQA3sJ7SNDPBsec955ehYPNdquhem+oICzgFaQwL9ULDG87fKZjmaKO25dIYX
+ttLqn+0b0GjpfQRuZ3NpAnCTWevodc5A3aYQm6vYeCyeIHGPpmtLE6oPRFib7wtD
+n4DFVM57F34ClnnF4m8jq9HoTcM1Y3qOFyslK/4FRyx3HXbEVsm5L289l0AS866U
+WEVM9DCqpFNLTwRk0mn4mspNcRxTDUTiHAxMhKxHGgbPcFzCJXqZzkW56bDcAGA5
+sQr+MOfa1P/K7pVcFtOAhsBi5ff1G4t1G1+amqXEDalL+qKRGFugGVf+poyb2C3g
+sfxkPBp9jPPMgMzXULQglwU4IUm8GtBb9Lh6AFPvt78XAWvNvHLP1Rf8JNZ9prx5
+N9RzIKSWKm6CVEjSDvQ42j4OpW0eecHAoluZFMrykVl+KmapWUwQF6v0xz1RJdQ+
+q3vGJ6shhiFd6y0ygxPwMaEjhhpbRy4tK9iDBj5yRpo+HE5X+FQSN6NHOYWMeDoZ
+uzd86/huEH5qIAL4unM9YFTzJ4CFOC8EJMDW6ul0uKjOwGPP3R1Vss6sC7kR0gXI
+rLWYdt40z0pjcR3FDVzh
+-----END CERTIFICATE-----
+`
+
+func validInstallConfig() *types.InstallConfig {
+	replicas := int64(1)
+	return &types.InstallConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: types.InstallConfigVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-cluster",
+		},
+		BaseDomain:  "example.com",
+		LicencePath: "tfe-licence.rli",
+		Networking: &types.Networking{
+			MachineNetwork: []types.MachineNetworkEntry{
+				{CIDR: *ipnet.MustParseCIDR("10.0.0.0/16")},
+			},
+			ServiceNetwork: []ipnet.IPNet{*ipnet.MustParseCIDR("172.30.0.0/16")},
+			ClusterNetwork: []types.ClusterNetworkEntry{
+				{CIDR: *ipnet.MustParseCIDR("192.168.0.0/16"), HostPrefix: 23},
+			},
+		},
+		ControlPlane: &types.MachinePool{
+			Name:         types.MachinePoolControlPlaneRoleName,
+			Replicas:     &replicas,
+			Architecture: types.ArchitectureAMD64,
+		},
+		Platform: types.Platform{
+			AWS: &aws.Platform{Region: "us-east-1"},
+		},
+	}
+}
+
+func TestValidateInstallConfig(t *testing.T) {
+	cases := []struct {
+		name          string
+		installConfig *types.InstallConfig
+		expectedError string
+	}{
+		{
+			name:          "valid",
+			installConfig: validInstallConfig(),
+		},
+		{
+			name: "missing networking",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking = nil
+				return c
+			}(),
+			expectedError: `^networking: Required value: networking is required$`,
+		},
+		{
+			name: "missing machine network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.MachineNetwork = nil
+				return c
+			}(),
+			expectedError: `^networking.machineNetwork: Required value: at least one machine network is required$`,
+		},
+		{
+			name: "invalid machine network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.MachineNetwork[0].CIDR = *ipnet.MustParseCIDR("10.0.0.1/16")
+				return c
+			}(),
+			expectedError: `^networking.machineNetwork\[0\]: Invalid value: "10.0.0.1/16": invalid network address. got 10.0.0.1/16, expecting 10.0.0.0/16$`,
+		},
+		{
+			name: "overlapping machine networks",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.MachineNetwork = append(c.Networking.MachineNetwork, types.MachineNetworkEntry{CIDR: *ipnet.MustParseCIDR("10.0.128.0/17")})
+				return c
+			}(),
+			expectedError: `^networking.machineNetwork\[1\]: Invalid value: "10.0.128.0/17": machine network must not overlap with machine network 0$`,
+		},
+		{
+			name: "missing service network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ServiceNetwork = nil
+				return c
+			}(),
+			expectedError: `^networking.serviceNetwork: Required value: a service network is required$`,
+		},
+		{
+			name: "too many service networks",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ServiceNetwork = append(c.Networking.ServiceNetwork, *ipnet.MustParseCIDR("172.31.0.0/16"))
+				return c
+			}(),
+			expectedError: `^networking.serviceNetwork: Too many: 2: must have at most 1 items$`,
+		},
+		{
+			name: "service network overlapping machine network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ServiceNetwork = []ipnet.IPNet{*ipnet.MustParseCIDR("10.0.0.0/24")}
+				return c
+			}(),
+			expectedError: `^networking.serviceNetwork\[0\]: Invalid value: "10.0.0.0/24": service network must not overlap with any of the machine networks$`,
+		},
+		{
+			name: "missing cluster network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ClusterNetwork = nil
+				return c
+			}(),
+			expectedError: `^networking.clusterNetwork: Required value: cluster network required$`,
+		},
+		{
+			name: "cluster network overlapping machine network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ClusterNetwork[0].CIDR = *ipnet.MustParseCIDR("10.0.0.0/8")
+				return c
+			}(),
+			expectedError: `^networking.clusterNetwork\[0\].cidr: Invalid value: "10.0.0.0/8": cluster network must not overlap with any of the machine networks$`,
+		},
+		{
+			name: "cluster network overlapping service network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ClusterNetwork[0].CIDR = *ipnet.MustParseCIDR("172.30.0.0/16")
+				return c
+			}(),
+			expectedError: `^networking.clusterNetwork\[0\].cidr: Invalid value: "172.30.0.0/16": cluster network must not overlap with service network 0$`,
+		},
+		{
+			name: "overlapping cluster networks",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ClusterNetwork = append(c.Networking.ClusterNetwork, types.ClusterNetworkEntry{CIDR: *ipnet.MustParseCIDR("192.168.0.0/24"), HostPrefix: 24})
+				return c
+			}(),
+			expectedError: `^networking.clusterNetwork\[1\].cidr: Invalid value: "192.168.0.0/24": cluster network must not overlap with cluster network 0$`,
+		},
+		{
+			name: "negative host prefix",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ClusterNetwork[0].HostPrefix = -1
+				return c
+			}(),
+			expectedError: `^\[networking.clusterNetwork\[0\].hostPrefix: Invalid value: -1: hostPrefix must be positive, `,
+		},
+		{
+			name: "host prefix larger than cluster network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Networking.ClusterNetwork[0].HostPrefix = 8
+				return c
+			}(),
+			expectedError: `^networking.clusterNetwork\[0\].hostPrefix: Invalid value: 8: cluster network host subnetwork prefix must not be larger size than CIDR 192.168.0.0/16$`,
+		},
+		{
+			name: "valid proxy",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{
+					HTTPProxy:  "http://proxy.example.com:3128",
+					HTTPSProxy: "https://proxy.example.com:3129",
+					NoProxy:    "example.org,.internal,10.1.0.0/16,10.2.0.1",
+				}
+				return c
+			}(),
+		},
+		{
+			name: "proxy without a proxy URL",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{NoProxy: "example.org"}
+				return c
+			}(),
+			expectedError: `^proxy: Required value: must include httpProxy or httpsProxy$`,
+		},
+		{
+			name: "unsupported httpProxy scheme",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPProxy: "https://proxy.example.com:3128"}
+				return c
+			}(),
+			expectedError: `^proxy.httpProxy: Unsupported value: "https": supported values: "http"$`,
+		},
+		{
+			name: "unsupported httpsProxy scheme",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPSProxy: "ftp://proxy.example.com:3128"}
+				return c
+			}(),
+			expectedError: `^proxy.httpsProxy: Unsupported value: "ftp": supported values: "http", "https"$`,
+		},
+		{
+			name: "invalid httpProxy URL",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPProxy: "proxy"}
+				return c
+			}(),
+			expectedError: `^proxy.httpProxy: Invalid value: "proxy": parse "proxy": invalid URI for request$`,
+		},
+		{
+			name: "proxy in the cluster network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPProxy: "http://192.168.0.10:3128"}
+				return c
+			}(),
+			expectedError: `^proxy.httpProxy: Invalid value: "http://192.168.0.10:3128": proxy value is part of the cluster networks$`,
+		},
+		{
+			name: "proxy in the service network",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPSProxy: "http://172.30.0.10:3128"}
+				return c
+			}(),
+			expectedError: `^proxy.httpsProxy: Invalid value: "http://172.30.0.10:3128": proxy value is part of the service networks$`,
+		},
+		{
+			name: "wildcard noProxy",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPProxy: "http://proxy.example.com:3128", NoProxy: "*"}
+				return c
+			}(),
+		},
+		{
+			name: "noProxy with spaces",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPProxy: "http://proxy.example.com:3128", NoProxy: "example.org, example.net"}
+				return c
+			}(),
+			expectedError: `^proxy.noProxy: Invalid value: "example.org, example.net": noProxy must not have spaces$`,
+		},
+		{
+			name: "invalid noProxy entry",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Proxy = &types.Proxy{HTTPProxy: "http://proxy.example.com:3128", NoProxy: "example.org,*.example.net"}
+				return c
+			}(),
+			expectedError: `^proxy.noProxy: Invalid value: "example.org,\*.example.net": each element of noProxy must be a IP, CIDR or domain without wildcard characters, which is violated by element 1 "\*.example.net"$`,
+		},
+		{
+			name: "valid additional trust bundle",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.AdditionalTrustBundle = testCABundle
+				c.AdditionalTrustBundlePolicy = types.PolicyAlways
+				return c
+			}(),
+		},
+		{
+			name: "invalid additional trust bundle",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.AdditionalTrustBundle = "not a bundle"
+				return c
+			}(),
+			expectedError: `^additionalTrustBundle: Invalid value: "not a bundle": invalid block$`,
+		},
+		{
+			name: "unsupported additional trust bundle policy",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.AdditionalTrustBundle = testCABundle
+				c.AdditionalTrustBundlePolicy = "Never"
+				return c
+			}(),
+			expectedError: `^additionalTrustBundlePolicy: Invalid value: "Never": supported values "Proxyonly", "Always"$`,
+		},
+		{
+			name: "proxy-only policy without a bundle",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.AdditionalTrustBundlePolicy = types.PolicyProxyOnly
+				return c
+			}(),
+		},
+		{
+			name: "always policy without a bundle",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.AdditionalTrustBundlePolicy = types.PolicyAlways
+				return c
+			}(),
+			expectedError: `^additionalTrustBundle: Required value: additionalTrustBundle is required when additionalTrustBundlePolicy is Always$`,
+		},
+		{
+			name: "imageContentSources in the current version",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.DeprecatedImageContentSources = []types.ImageContentSource{{
+					Source:  "quay.io/hashicorp/tfe",
+					Mirrors: []string{"registry.example.com/hashicorp/tfe"},
+				}}
+				return c
+			}(),
+			expectedError: `^imageContentSources: Forbidden: imageContentSources was replaced by imageMirrors in v2$`,
+		},
+		{
+			name: "external hostname in the base domain",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Publish = types.ExternalPublishingStrategy
+				c.TFE = &types.TFE{Hostname: "tfe.example.com"}
+				return c
+			}(),
+		},
+		{
+			name: "external hostname outside the base domain",
+			installConfig: func() *types.InstallConfig {
+				c := validInstallConfig()
+				c.Publish = types.ExternalPublishingStrategy
+				c.TFE = &types.TFE{Hostname: "tfe.example.org"}
+				return c
+			}(),
+			expectedError: `^tfe.hostname: Invalid value: "tfe.example.org": hostname must be in the base domain when publish is External$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateInstallConfig(tc.installConfig).ToAggregate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+	awsvalidation "github.com/bailey84j/terraform_installer/pkg/types/aws/validation"
+)
+
+var (
+	validArchitectures = map[types.Architecture]bool{
+		types.ArchitectureAMD64: true,
+		types.ArchitectureARM64: true,
+	}
+
+	validArchitectureValues = func() []string {
+		v := make([]string, 0, len(validArchitectures))
+		for n := range validArchitectures {
+			v = append(v, string(n))
+		}
+		sort.Strings(v)
+		return v
+	}()
+)
+
+// ValidateMachinePool checks that the specified machine pool is valid.
+func ValidateMachinePool(platform *types.Platform, p *types.MachinePool, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if p.Replicas != nil {
+		if *p.Replicas < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), p.Replicas, "number of replicas must not be negative"))
+		}
+	} else {
+		allErrs = append(allErrs, field.Required(fldPath.Child("replicas"), "replicas is required"))
+	}
+	if !validArchitectures[p.Architecture] {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("architecture"), p.Architecture, validArchitectureValues))
+	}
+	allErrs = append(allErrs, validateMachinePoolPlatform(platform, &p.Platform, fldPath.Child("platform"))...)
+	return allErrs
+}
+
+func validateMachinePoolPlatform(platform *types.Platform, p *types.MachinePoolPlatform, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	platformName := platform.Name()
+	validate := func(n string, value interface{}, validation func(*field.Path) field.ErrorList) {
+		f := fldPath.Child(n)
+		if platformName != n {
+			allErrs = append(allErrs, field.Invalid(f, value, fmt.Sprintf("cannot specify %q for machine pool when cluster is using %q", n, platformName)))
+		} else {
+			allErrs = append(allErrs, validation(f)...)
+		}
+	}
+	if p.AWS != nil {
+		validate(aws.Name, p.AWS, func(f *field.Path) field.ErrorList { return awsvalidation.ValidateMachinePool(platform.AWS, p.AWS, f) })
+	}
+	return allErrs
+}
+
+func validateControlPlane(platform *types.Platform, pool *types.MachinePool, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if pool.Name != types.MachinePoolControlPlaneRoleName {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("name"), pool.Name, []string{types.MachinePoolControlPlaneRoleName}))
+	}
+	if pool.Replicas != nil && *pool.Replicas == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), pool.Replicas, "number of control plane replicas must be positive"))
+	}
+	allErrs = append(allErrs, ValidateMachinePool(platform, pool, fldPath)...)
+	return allErrs
+}
+
+func validateCompute(platform *types.Platform, control *types.MachinePool, pools []types.MachinePool, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	poolNames := map[string]bool{}
+	for i, p := range pools {
+		poolFldPath := fldPath.Index(i)
+		if p.Name != types.MachinePoolComputeRoleName {
+			allErrs = append(allErrs, field.NotSupported(poolFldPath.Child("name"), p.Name, []string{types.MachinePoolComputeRoleName}))
+		}
+		if poolNames[p.Name] {
+			allErrs = append(allErrs, field.Duplicate(poolFldPath.Child("name"), p.Name))
+		}
+		poolNames[p.Name] = true
+		if control != nil && control.Architecture != p.Architecture {
+			allErrs = append(allErrs, field.Invalid(poolFldPath.Child("architecture"), p.Architecture, "heterogeneous multi-arch is not supported; compute pool architecture must match control plane"))
+		}
+		allErrs = append(allErrs, ValidateMachinePool(platform, &p, poolFldPath)...)
+	}
+	return allErrs
+}
@@ -0,0 +1,222 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bailey84j/terraform_installer/pkg/types"
+	"github.com/bailey84j/terraform_installer/pkg/types/aws"
+	"github.com/bailey84j/terraform_installer/pkg/types/azure"
+)
+
+func validMachinePool(name string) *types.MachinePool {
+	replicas := int64(1)
+	return &types.MachinePool{
+		Name:         name,
+		Replicas:     &replicas,
+		Architecture: types.ArchitectureAMD64,
+	}
+}
+
+func validAWSPlatform() *types.Platform {
+	return &types.Platform{AWS: &aws.Platform{Region: "us-east-1"}}
+}
+
+func TestValidateMachinePool(t *testing.T) {
+	cases := []struct {
+		name          string
+		platform      *types.Platform
+		pool          *types.MachinePool
+		expectedError string
+	}{
+		{
+			name:     "valid",
+			platform: validAWSPlatform(),
+			pool:     validMachinePool(types.MachinePoolComputeRoleName),
+		},
+		{
+			name:     "zero replicas",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				*p.Replicas = 0
+				return p
+			}(),
+		},
+		{
+			name:     "negative replicas",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				*p.Replicas = -1
+				return p
+			}(),
+			expectedError: `^test-path.replicas: Invalid value: -1: number of replicas must not be negative$`,
+		},
+		{
+			name:     "missing replicas",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Replicas = nil
+				return p
+			}(),
+			expectedError: `^test-path.replicas: Required value: replicas is required$`,
+		},
+		{
+			name:     "arm64",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Architecture = types.ArchitectureARM64
+				return p
+			}(),
+		},
+		{
+			name:     "unsupported architecture",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Architecture = "s390x"
+				return p
+			}(),
+			expectedError: `^test-path.architecture: Unsupported value: "s390x": supported values: "amd64", "arm64"$`,
+		},
+		{
+			name:     "valid AWS machine pool",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Platform.AWS = &aws.MachinePool{Zones: []string{"us-east-1a"}}
+				return p
+			}(),
+		},
+		{
+			name:     "invalid AWS machine pool",
+			platform: validAWSPlatform(),
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Platform.AWS = &aws.MachinePool{Zones: []string{"eu-west-1a"}}
+				return p
+			}(),
+			expectedError: `^test-path.platform.aws.zones\[0\]: Invalid value: "eu-west-1a": Zone not in configured region \(us-east-1\)$`,
+		},
+		{
+			name:     "AWS machine pool on another platform",
+			platform: &types.Platform{Azure: &azure.Platform{Region: "eastus"}},
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Platform.AWS = &aws.MachinePool{}
+				return p
+			}(),
+			expectedError: `^test-path.platform.aws: Invalid value: aws.MachinePool{.*}: cannot specify "aws" for machine pool when cluster is using "azure"$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMachinePool(tc.platform, tc.pool, field.NewPath("test-path")).ToAggregate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateControlPlane(t *testing.T) {
+	cases := []struct {
+		name          string
+		pool          *types.MachinePool
+		expectedError string
+	}{
+		{
+			name: "valid",
+			pool: validMachinePool(types.MachinePoolControlPlaneRoleName),
+		},
+		{
+			name:          "wrong name",
+			pool:          validMachinePool("control-plane"),
+			expectedError: `^test-path.name: Unsupported value: "control-plane": supported values: "master"$`,
+		},
+		{
+			name: "zero replicas",
+			pool: func() *types.MachinePool {
+				p := validMachinePool(types.MachinePoolControlPlaneRoleName)
+				*p.Replicas = 0
+				return p
+			}(),
+			expectedError: `^test-path.replicas: Invalid value: 0: number of control plane replicas must be positive$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateControlPlane(validAWSPlatform(), tc.pool, field.NewPath("test-path")).ToAggregate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateCompute(t *testing.T) {
+	cases := []struct {
+		name          string
+		pools         []types.MachinePool
+		expectedError string
+	}{
+		{
+			name:  "valid",
+			pools: []types.MachinePool{*validMachinePool(types.MachinePoolComputeRoleName)},
+		},
+		{
+			name: "no pools",
+		},
+		{
+			name:          "wrong name",
+			pools:         []types.MachinePool{*validMachinePool("agents")},
+			expectedError: `^test-path\[0\].name: Unsupported value: "agents": supported values: "worker"$`,
+		},
+		{
+			name: "duplicate pools",
+			pools: []types.MachinePool{
+				*validMachinePool(types.MachinePoolComputeRoleName),
+				*validMachinePool(types.MachinePoolComputeRoleName),
+			},
+			expectedError: `^test-path\[1\].name: Duplicate value: "worker"$`,
+		},
+		{
+			name: "architecture differs from the control plane",
+			pools: func() []types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Architecture = types.ArchitectureARM64
+				return []types.MachinePool{*p}
+			}(),
+			expectedError: `^test-path\[0\].architecture: Invalid value: "arm64": heterogeneous multi-arch is not supported; compute pool architecture must match control plane$`,
+		},
+		{
+			name: "invalid pool",
+			pools: func() []types.MachinePool {
+				p := validMachinePool(types.MachinePoolComputeRoleName)
+				p.Replicas = nil
+				return []types.MachinePool{*p}
+			}(),
+			expectedError: `^test-path\[0\].replicas: Required value: replicas is required$`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			control := validMachinePool(types.MachinePoolControlPlaneRoleName)
+			err := validateCompute(validAWSPlatform(), control, tc.pools, field.NewPath("test-path")).ToAggregate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Regexp(t, tc.expectedError, err)
+			}
+		})
+	}
+}
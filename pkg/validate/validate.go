@@ -287,3 +287,21 @@ func OnPremClusterName(v string) error {
 	}
 	return ClusterName(v)
 }
+
+var (
+	repositoryDomain    = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?`
+	repositoryComponent = `[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*`
+	repositoryRegexp    = regexp.MustCompile(`^(?:` + repositoryDomain + `/)?` + repositoryComponent + `(?:/` + repositoryComponent + `)*$`)
+)
+
+// ImageRepository checks that the given string is an image repository, e.g.
+// quay.io/hashicorp/terraform-enterprise, without a tag or digest.
+func ImageRepository(v string) error {
+	if i := strings.LastIndex(v, "/"); strings.Contains(v[i+1:], ":") || strings.Contains(v, "@") {
+		return errors.New("must be a repository, not an image reference with a tag or digest")
+	}
+	if !repositoryRegexp.MatchString(v) {
+		return fmt.Errorf("%q is not a valid image repository", v)
+	}
+	return nil
+}
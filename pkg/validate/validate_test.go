@@ -702,3 +702,30 @@ func TestMAC(t *testing.T) {
 		})
 	}
 }
+
+func TestImageRepository(t *testing.T) {
+	cases := []struct {
+		repository string
+		valid      bool
+	}{
+		{"quay.io/hashicorp/terraform-enterprise", true},
+		{"registry.example.com:5000/mirror/tfe", true},
+		{"hashicorp/terraform-enterprise", true},
+		{"terraform-enterprise", true},
+		{"quay.io/hashicorp/terraform-enterprise:v202301-1", false},
+		{"quay.io/hashicorp/terraform-enterprise@sha256:0123456789abcdef", false},
+		{"quay.io/HashiCorp/tfe", false},
+		{"quay.io//tfe", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.repository, func(t *testing.T) {
+			err := ImageRepository(tc.repository)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}